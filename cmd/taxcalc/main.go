@@ -0,0 +1,282 @@
+// Command taxcalc calculates tax offline with the same engine as the API
+// server, so a customer's quote can be reproduced without running the server.
+// The request is read from a JSON file, from stdin or built from flags, and
+// the result is printed as a table, JSON or CSV.
+//
+// Usage:
+//
+//	taxcalc [flags] [request.json | -]
+//	taxcalc --state NY --zip 10001 --item "Laptop,999.99,1"
+//	taxcalc --format json --date 2025-06-01 < request.json
+//
+// Flags given alongside a request file override its fields; --item replaces
+// its items. The rate table, boundary file, exemption certificates and nexus
+// settings are read from the same environment variables as the server unless
+// set by flag.
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+	"github.com/vijayraghavareddy/tax-calculation/services"
+)
+
+// Output formats
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+// usageError is returned for bad flags or arguments, as opposed to a request
+// the engine rejects
+type usageError struct {
+	err error
+}
+
+func (e *usageError) Error() string {
+	return e.err.Error()
+}
+
+// itemList collects the --item flags
+type itemList []models.Item
+
+func (l *itemList) String() string {
+	return fmt.Sprintf("%d items", len(*l))
+}
+
+// Set parses an item given as "name,price[,quantity[,tax_category]]". The
+// value is read as a CSV record, so a name holding a comma can be quoted.
+func (l *itemList) Set(value string) error {
+	fields, err := csv.NewReader(strings.NewReader(value)).Read()
+	if err != nil || len(fields) < 2 || len(fields) > 4 {
+		return fmt.Errorf("want name,price[,quantity[,tax_category]], got %q", value)
+	}
+	item := models.Item{
+		ID:       fmt.Sprintf("item%d", len(*l)+1),
+		Name:     strings.TrimSpace(fields[0]),
+		Quantity: 1,
+	}
+	if item.Price, err = models.ParseMoney(strings.TrimSpace(fields[1])); err != nil {
+		return err
+	}
+	if len(fields) > 2 {
+		if item.Quantity, err = strconv.Atoi(strings.TrimSpace(fields[2])); err != nil {
+			return fmt.Errorf("invalid quantity %q", fields[2])
+		}
+	}
+	if len(fields) > 3 {
+		item.TaxCategory = strings.TrimSpace(fields[3])
+	}
+	*l = append(*l, item)
+	return nil
+}
+
+// options holds the parsed flags
+type options struct {
+	format string
+
+	country, state, city, zip, street string
+	items                             itemList
+	currency, date                    string
+	sellerID, customerID              string
+
+	ratesFile, boundaryFile, exemptionsFile, nexusFile string
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command and returns its exit status: 0 on success, 1 when the
+// request cannot be calculated and 2 for bad flags or arguments
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("taxcalc", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	opts := &options{}
+	flags.StringVar(&opts.format, "format", formatTable, "output format: table, json or csv")
+	flags.StringVar(&opts.country, "country", "", "destination country code (default US when no request file is given)")
+	flags.StringVar(&opts.state, "state", "", "destination state or region")
+	flags.StringVar(&opts.city, "city", "", "destination city")
+	flags.StringVar(&opts.zip, "zip", "", "destination ZIP or postal code")
+	flags.StringVar(&opts.street, "street", "", "destination street")
+	flags.Var(&opts.items, "item", `item as "name,price[,quantity[,tax_category]]"; repeat for more items`)
+	flags.StringVar(&opts.currency, "currency", "", "ISO 4217 currency code")
+	flags.StringVar(&opts.date, "date", "", "transaction date, YYYY-MM-DD; the rates in force that day are used")
+	flags.StringVar(&opts.sellerID, "seller-id", "", "seller whose nexus settings apply")
+	flags.StringVar(&opts.customerID, "customer-id", "", "customer whose exemption certificates apply")
+	flags.StringVar(&opts.ratesFile, "rates", os.Getenv("TAX_RATES_FILE"), "rate table file (default $TAX_RATES_FILE or the bundled table)")
+	flags.StringVar(&opts.boundaryFile, "boundaries", os.Getenv("TAX_BOUNDARY_FILE"), "ZIP+4 boundary file (default $TAX_BOUNDARY_FILE)")
+	flags.StringVar(&opts.exemptionsFile, "exemptions", os.Getenv("TAX_EXEMPTIONS_FILE"), "exemption certificate file (default $TAX_EXEMPTIONS_FILE)")
+	flags.StringVar(&opts.nexusFile, "nexus", os.Getenv("TAX_NEXUS_FILE"), "seller nexus settings file (default $TAX_NEXUS_FILE)")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: taxcalc [flags] [request.json | -]")
+		fmt.Fprintln(stderr, `       taxcalc --state NY --zip 10001 --item "Laptop,999.99,1"`)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	err := calculate(opts, flags, stdin, stdout)
+	if err == nil {
+		return 0
+	}
+	fmt.Fprintf(stderr, "taxcalc: %v\n", err)
+	var usage *usageError
+	if errors.As(err, &usage) {
+		return 2
+	}
+	return 1
+}
+
+// calculate builds the request, calculates it and writes the result
+func calculate(opts *options, flags *flag.FlagSet, stdin io.Reader, stdout io.Writer) error {
+	var write func(io.Writer, *models.TaxResponse) error
+	switch opts.format {
+	case formatTable:
+		write = writeTable
+	case formatJSON:
+		write = writeJSON
+	case formatCSV:
+		write = writeCSV
+	default:
+		return &usageError{fmt.Errorf("format %q is not one of table, json or csv", opts.format)}
+	}
+
+	req, err := buildRequest(opts, flags, stdin)
+	if err != nil {
+		return err
+	}
+	service, err := newService(opts)
+	if err != nil {
+		return err
+	}
+	result, err := service.CalculateTax(req)
+	if err != nil {
+		return err
+	}
+	return write(stdout, result)
+}
+
+// buildRequest reads the request from the file named by the argument, from
+// stdin when the argument is "-" or there is neither an argument nor an
+// --item flag, or starts from an empty request, and applies the flags set
+func buildRequest(opts *options, flags *flag.FlagSet, stdin io.Reader) (*models.TaxRequest, error) {
+	if flags.NArg() > 1 {
+		return nil, &usageError{fmt.Errorf("want at most one request file, got %d", flags.NArg())}
+	}
+
+	req := &models.TaxRequest{}
+	path := flags.Arg(0)
+	switch {
+	case path == "-" || (path == "" && len(opts.items) == 0):
+		if err := decodeRequest(stdin, "stdin", req); err != nil {
+			return nil, err
+		}
+	case path != "":
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		if err := decodeRequest(f, path, req); err != nil {
+			return nil, err
+		}
+	default:
+		req.Address.Country = "US"
+	}
+
+	set := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	override := func(name string, field *string, value string) {
+		if set[name] {
+			*field = value
+		}
+	}
+	override("country", &req.Address.Country, opts.country)
+	override("state", &req.Address.State, opts.state)
+	override("city", &req.Address.City, opts.city)
+	override("zip", &req.Address.ZipCode, opts.zip)
+	override("zip", &req.Address.PostalCode, "")
+	override("street", &req.Address.Street, opts.street)
+	override("currency", &req.Currency, opts.currency)
+	override("seller-id", &req.SellerID, opts.sellerID)
+	override("customer-id", &req.CustomerID, opts.customerID)
+	if len(opts.items) > 0 {
+		req.Items = opts.items
+	}
+	if opts.date != "" {
+		date, err := models.ParseDate(opts.date)
+		if err != nil {
+			return nil, &usageError{err}
+		}
+		req.TransactionDate = &date
+	}
+	// The server takes either address field
+	if req.Address.ZipCode == "" {
+		req.Address.ZipCode = req.Address.PostalCode
+	}
+	return req, nil
+}
+
+// decodeRequest decodes one JSON tax request
+func decodeRequest(r io.Reader, name string, req *models.TaxRequest) error {
+	if err := json.NewDecoder(r).Decode(req); err != nil {
+		return fmt.Errorf("invalid request in %s: %w", name, err)
+	}
+	return nil
+}
+
+// newService creates a tax service with the configured rate table, boundary
+// file, exemption certificates and nexus settings, as the server does
+func newService(opts *options) (*services.TaxService, error) {
+	provider := services.DefaultRateProvider()
+	if opts.ratesFile != "" {
+		var err error
+		if provider, err = services.LoadRateTable(opts.ratesFile); err != nil {
+			return nil, fmt.Errorf("failed to load rate table %s: %w", opts.ratesFile, err)
+		}
+	}
+	if opts.boundaryFile != "" {
+		index, err := services.LoadBoundaryFile(opts.boundaryFile)
+		if err == nil {
+			err = provider.UseBoundaries(index)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load boundary file %s: %w", opts.boundaryFile, err)
+		}
+	}
+	service := services.NewTaxService(provider)
+	if opts.exemptionsFile != "" {
+		store, err := services.OpenCertificateStore(opts.exemptionsFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open exemption certificate file %s: %w", opts.exemptionsFile, err)
+		}
+		service.SetExemptionStore(store)
+	}
+	if opts.nexusFile != "" {
+		sellers, err := services.LoadNexusConfig(opts.nexusFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load nexus config %s: %w", opts.nexusFile, err)
+		}
+		for _, seller := range sellers {
+			if err := service.NexusTracker().Configure(seller); err != nil {
+				return nil, fmt.Errorf("failed to load nexus config %s: %w", opts.nexusFile, err)
+			}
+		}
+	}
+	return service, nil
+}
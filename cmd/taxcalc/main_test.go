@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+const requestJSON = `{
+	"address": {"country": "US", "state": "NY", "postal_code": "10001"},
+	"items": [{"id": "sku-1", "name": "Laptop", "price": "999.99", "quantity": 1}],
+	"transaction_date": "2025-06-01"
+}`
+
+// runCommand runs taxcalc with the environment's configuration files unset
+func runCommand(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	for _, name := range []string{"TAX_RATES_FILE", "TAX_BOUNDARY_FILE", "TAX_EXEMPTIONS_FILE", "TAX_NEXUS_FILE"} {
+		t.Setenv(name, "")
+	}
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_Flags(t *testing.T) {
+	code, stdout, stderr := runCommand(t, "",
+		"--state", "NY", "--zip", "10001", "--date", "2025-06-01",
+		"--item", "Laptop,999.99,1", "--item", `"Shirt, blue",50,2,clothing`)
+	if code != 0 {
+		t.Fatalf("Expected exit 0, got %d: %s", code, stderr)
+	}
+	for _, want := range []string{
+		"Ship to:  NY 10001, US",
+		"item1  Laptop       1    999.99",
+		"item2  Shirt, blue  2    50.00",
+		"NYC           New York City",
+		"Tax       88.75",
+		"Total     1188.74",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, stdout)
+		}
+	}
+}
+
+func TestRun_JSONFromStdin(t *testing.T) {
+	code, stdout, stderr := runCommand(t, requestJSON, "--format", "json")
+	if code != 0 {
+		t.Fatalf("Expected exit 0, got %d: %s", code, stderr)
+	}
+	var result models.TaxResponse
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("Failed to decode output: %v", err)
+	}
+	if result.Items[0].ItemID != "sku-1" || result.TotalTax.String() != "88.75" || result.TransactionDate.String() != "2025-06-01" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+}
+
+func TestRun_FileWithOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "request.json")
+	if err := os.WriteFile(path, []byte(requestJSON), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A quote to an Oregon ZIP has no sales tax
+	code, stdout, stderr := runCommand(t, "", "--format", "csv", "--state", "OR", "--zip", "97201", path)
+	if code != 0 {
+		t.Fatalf("Expected exit 0, got %d: %s", code, stderr)
+	}
+	records, err := csv.NewReader(strings.NewReader(stdout)).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read CSV: %v", err)
+	}
+	if strings.Join(records[0], ",") != strings.Join(csvColumns, ",") {
+		t.Errorf("Unexpected header %v", records[0])
+	}
+	item, total := records[1], records[len(records)-1]
+	if item[0] != "item" || item[1] != "sku-1" || item[8] != "0.00" {
+		t.Errorf("Unexpected item row %v", item)
+	}
+	if total[0] != "total" || total[6] != "999.99" || total[9] != "999.99" {
+		t.Errorf("Unexpected total row %v", total)
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		stdin  string
+		args   []string
+		code   int
+		stderr string
+	}{
+		{"bad item", "", []string{"--item", "Laptop"}, 2, "want name,price"},
+		{"bad quantity", "", []string{"--item", "Laptop,10,two"}, 2, "invalid quantity"},
+		{"bad format", "", []string{"--format", "xml", "--item", "Laptop,10"}, 2, "format \"xml\""},
+		{"bad date", "", []string{"--date", "June", "--item", "Laptop,10"}, 2, "June"},
+		{"two files", "", []string{"a.json", "b.json"}, 2, "at most one request file"},
+		{"missing file", "", []string{filepath.Join(t.TempDir(), "none.json")}, 1, "no such file"},
+		{"bad JSON", "{", nil, 1, "invalid request in stdin"},
+		{"unsupported country", "", []string{"--country", "JP", "--item", "Laptop,10"}, 1, "unsupported jurisdiction"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runCommand(t, tt.stdin, tt.args...)
+			if code != tt.code || !strings.Contains(stderr, tt.stderr) {
+				t.Errorf("Expected exit %d with %q, got %d: %s", tt.code, tt.stderr, code, stderr)
+			}
+			if stdout != "" {
+				t.Errorf("Expected no output, got %s", stdout)
+			}
+		})
+	}
+}
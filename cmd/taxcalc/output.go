@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+	"github.com/vijayraghavareddy/tax-calculation/services"
+)
+
+// csvColumns is the header of the CSV output
+var csvColumns = []string{
+	"type", "id", "name", "quantity", "price", "discount", "subtotal", "tax_rate", "tax_amount", "total",
+}
+
+// writeJSON writes the response as the API would return it, indented
+func writeJSON(w io.Writer, result *models.TaxResponse) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
+
+// writeCSV writes one row per item and charge, one per jurisdiction rate
+// with its taxable amount as the subtotal, and a closing total row
+func writeCSV(w io.Writer, result *models.TaxResponse) error {
+	writer := csv.NewWriter(w)
+	records := [][]string{csvColumns}
+	for _, item := range result.Items {
+		records = append(records, []string{
+			"item", item.ItemID, item.ItemName, strconv.Itoa(item.Quantity), item.Price.String(),
+			item.DiscountAmount.String(), item.Subtotal.String(), lineRate(item.Taxes).String(),
+			item.TaxAmount.String(), item.TotalAmount.String(),
+		})
+	}
+	for _, charge := range result.Charges {
+		records = append(records, []string{
+			"charge", charge.ChargeID, chargeName(charge), "", charge.Amount.String(),
+			"", charge.Subtotal.String(), "", charge.TaxAmount.String(), charge.TotalAmount.String(),
+		})
+	}
+	for _, entry := range result.Jurisdictions {
+		records = append(records, []string{
+			"tax", entry.Code, entry.Name, "", "", "", entry.TaxableAmount.String(), entry.Rate.String(),
+			entry.TaxAmount.String(), "",
+		})
+	}
+	records = append(records, []string{
+		"total", "", "", "", "", result.TotalDiscount.String(), result.Subtotal.Add(result.ChargeTotal).String(),
+		"", result.TotalTax.String(), result.GrandTotal.String(),
+	})
+	if err := writer.WriteAll(records); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// writeTable writes the response for reading in a terminal: the lines, the
+// tax owed to each jurisdiction and the totals
+func writeTable(w io.Writer, result *models.TaxResponse) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	row := func(cells ...string) {
+		fmt.Fprintln(table, strings.Join(cells, "\t"))
+	}
+
+	fmt.Fprintf(w, "Ship to:  %s\n", formatAddress(result.Address))
+	fmt.Fprintf(w, "Date:     %s (%s rates", result.TransactionDate, result.Currency)
+	if result.RateMatchLevel != "" {
+		fmt.Fprintf(w, ", matched by %s", result.RateMatchLevel)
+	}
+	fmt.Fprintln(w, ")")
+	if nexus := result.Nexus; nexus != nil && nexus.Reason == services.NoNexusReason {
+		fmt.Fprintf(w, "Nexus:    none in %s, no tax collected\n", nexus.State)
+	}
+	for _, exemption := range result.Exemptions {
+		fmt.Fprintf(w, "Exempt:   certificate %s (%s) in %s\n",
+			exemption.CertificateID, exemption.Reason, strings.Join(exemption.Jurisdictions, ", "))
+	}
+	fmt.Fprintln(w)
+
+	row("ID", "NAME", "QTY", "PRICE", "DISCOUNT", "SUBTOTAL", "RATE %", "TAX", "TOTAL")
+	for _, item := range result.Items {
+		row(item.ItemID, item.ItemName, strconv.Itoa(item.Quantity), item.Price.String(),
+			item.DiscountAmount.String(), item.Subtotal.String(), lineRate(item.Taxes).String(),
+			item.TaxAmount.String(), item.TotalAmount.String())
+	}
+	for _, charge := range result.Charges {
+		row(charge.ChargeID, chargeName(charge), "", charge.Amount.String(), "", charge.Subtotal.String(), "",
+			charge.TaxAmount.String(), charge.TotalAmount.String())
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	if len(result.Jurisdictions) > 0 {
+		fmt.Fprintln(w)
+		row("JURISDICTION", "NAME", "TYPE", "RATE %", "TAXABLE", "TAX")
+		for _, entry := range result.Jurisdictions {
+			row(entry.Code, entry.Name, entry.Type, entry.Rate.String(), entry.TaxableAmount.String(),
+				entry.TaxAmount.String())
+		}
+		if err := table.Flush(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintln(w)
+	row("Subtotal", result.Subtotal.String())
+	if !result.TotalDiscount.IsZero() {
+		row("Discounts", result.TotalDiscount.String())
+	}
+	if len(result.Charges) > 0 {
+		row("Charges", result.ChargeTotal.String())
+	}
+	row("Tax", result.TotalTax.String())
+	row("Total", result.GrandTotal.String())
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	for _, note := range result.Notes {
+		fmt.Fprintf(w, "\nNote: %s\n", note)
+	}
+	return nil
+}
+
+// lineRate returns the combined rate of a line's taxes
+func lineRate(taxes []models.Tax) models.Rate {
+	var rate models.Rate
+	for _, tax := range taxes {
+		rate += tax.Rate
+	}
+	return rate
+}
+
+// formatAddress formats an address on one line
+func formatAddress(address models.Address) string {
+	var parts []string
+	for _, part := range []string{address.Street, address.City,
+		strings.TrimSpace(address.State + " " + address.ZipCode), address.Country} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// chargeName names a charge by its description, or its type without one
+func chargeName(charge models.ChargeTaxDetail) string {
+	if charge.Description != "" {
+		return charge.Description
+	}
+	return charge.Type
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/vijayraghavareddy/tax-calculation/models"
+	"github.com/vijayraghavareddy/tax-calculation/services"
+)
+
+// defaultExpiringDays is the window used when ListExpiringExemptions is not
+// given days
+const defaultExpiringDays = 30
+
+// CertificateList is the response body for certificate listings
+type CertificateList struct {
+	Certificates []models.ExemptionCertificate `json:"certificates"`
+}
+
+// CreateExemption handles POST requests that file a new exemption certificate
+func CreateExemption(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var cert models.ExemptionCertificate
+	if err := json.NewDecoder(r.Body).Decode(&cert); err != nil {
+		sendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	store := taxService.ExemptionStore()
+	if err := store.Create(cert); err != nil {
+		sendErrorResponse(w, err.Error(), statusForExemptionError(err))
+		return
+	}
+	saved, err := store.Certificate(strings.TrimSpace(cert.ID))
+	if err != nil {
+		sendErrorResponse(w, err.Error(), statusForExemptionError(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(saved)
+}
+
+// ListExemptions handles GET requests for every certificate on file, or the
+// certificates of the customer given by the customer_id query parameter
+func ListExemptions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	store := taxService.ExemptionStore()
+	var certs []models.ExemptionCertificate
+	var err error
+	if customerID := r.URL.Query().Get("customer_id"); customerID != "" {
+		certs, err = store.CertificatesFor(customerID)
+	} else {
+		certs, err = store.List()
+	}
+	if err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sendCertificates(w, certs)
+}
+
+// ListExpiringExemptions handles GET requests for the certificates expiring
+// within the number of days given by the days query parameter
+func ListExpiringExemptions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	days := defaultExpiringDays
+	if text := r.URL.Query().Get("days"); text != "" {
+		n, err := strconv.Atoi(text)
+		if err != nil || n < 0 {
+			sendErrorResponse(w, "days must be a non-negative whole number", http.StatusBadRequest)
+			return
+		}
+		days = n
+	}
+
+	certs, err := taxService.ExpiringCertificates(days)
+	if err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sendCertificates(w, certs)
+}
+
+// GetExemption handles GET requests for one certificate
+func GetExemption(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	cert, err := taxService.ExemptionStore().Certificate(mux.Vars(r)["id"])
+	if err != nil {
+		sendErrorResponse(w, err.Error(), statusForExemptionError(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(cert)
+}
+
+// UpdateExemption handles PUT requests that replace a certificate
+func UpdateExemption(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var cert models.ExemptionCertificate
+	if err := json.NewDecoder(r.Body).Decode(&cert); err != nil {
+		sendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	id := mux.Vars(r)["id"]
+	if cert.ID == "" {
+		cert.ID = id
+	}
+	if cert.ID != id {
+		sendErrorResponse(w, "certificate id does not match the URL", http.StatusBadRequest)
+		return
+	}
+
+	store := taxService.ExemptionStore()
+	if err := store.Update(cert); err != nil {
+		sendErrorResponse(w, err.Error(), statusForExemptionError(err))
+		return
+	}
+	saved, err := store.Certificate(id)
+	if err != nil {
+		sendErrorResponse(w, err.Error(), statusForExemptionError(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(saved)
+}
+
+// DeleteExemption handles DELETE requests that remove a certificate
+func DeleteExemption(w http.ResponseWriter, r *http.Request) {
+	if err := taxService.ExemptionStore().Delete(mux.Vars(r)["id"]); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		sendErrorResponse(w, err.Error(), statusForExemptionError(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ValidateExemption handles GET requests asking whether a certificate exempts
+// sales in the jurisdiction query parameter on the date query parameter,
+// which defaults to today. The optional state and country parameters give
+// the sale's destination, so a state or country certificate is matched to
+// the local jurisdictions it covers as it is on an order.
+func ValidateExemption(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	jurisdiction := r.URL.Query().Get("jurisdiction")
+	if jurisdiction == "" {
+		sendErrorResponse(w, "jurisdiction is required", http.StatusBadRequest)
+		return
+	}
+	date := taxService.Today()
+	if text := r.URL.Query().Get("date"); text != "" {
+		parsed, err := models.ParseDate(text)
+		if err != nil {
+			sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		date = parsed
+	}
+
+	cert, err := taxService.ExemptionStore().Certificate(mux.Vars(r)["id"])
+	if err != nil {
+		sendErrorResponse(w, err.Error(), statusForExemptionError(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	address := &models.Address{State: r.URL.Query().Get("state"), Country: r.URL.Query().Get("country")}
+	json.NewEncoder(w).Encode(services.CheckCertificate(cert, jurisdiction, address, date))
+}
+
+// statusForExemptionError maps a certificate store error to an HTTP status
+// code. Errors other than a missing, duplicate or invalid certificate are the
+// store failing, e.g. to save its file, not the client's fault.
+func statusForExemptionError(err error) int {
+	var invalid *services.InvalidCertificateError
+	switch {
+	case errors.Is(err, services.ErrCertificateNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrCertificateExists):
+		return http.StatusConflict
+	case errors.As(err, &invalid):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// sendCertificates sends a certificate listing
+func sendCertificates(w http.ResponseWriter, certs []models.ExemptionCertificate) {
+	if certs == nil {
+		certs = []models.ExemptionCertificate{}
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(CertificateList{Certificates: certs})
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/vijayraghavareddy/tax-calculation/models"
+	"github.com/vijayraghavareddy/tax-calculation/services"
+)
+
+func TestExemptionCRUD(t *testing.T) {
+	SetTaxService(services.NewTaxService(services.DefaultRateProvider()))
+
+	body := `{"id": "RS-1", "customer_id": "shop", "reason": "resale", "jurisdictions": ["ny"], "expires_on": "2030-12-31"}`
+	w := httptest.NewRecorder()
+	CreateExemption(w, httptest.NewRequest(http.MethodPost, "/api/v1/exemptions", bytes.NewBufferString(body)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, w.Code, w.Body)
+	}
+	var created models.ExemptionCertificate
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if created.Jurisdictions[0] != "NY" {
+		t.Errorf("Expected jurisdiction NY, got %v", created.Jurisdictions)
+	}
+
+	w = httptest.NewRecorder()
+	CreateExemption(w, httptest.NewRequest(http.MethodPost, "/api/v1/exemptions", bytes.NewBufferString(body)))
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status code %d, got %d", http.StatusConflict, w.Code)
+	}
+
+	update := `{"reason": "resale", "customer_id": "shop", "jurisdictions": ["NY", "NJ"]}`
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodPut, "/api/v1/exemptions/RS-1", bytes.NewBufferString(update)), map[string]string{"id": "RS-1"})
+	w = httptest.NewRecorder()
+	UpdateExemption(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+
+	req = mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/api/v1/exemptions/RS-1", nil), map[string]string{"id": "RS-1"})
+	w = httptest.NewRecorder()
+	GetExemption(w, req)
+	var got models.ExemptionCertificate
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(got.Jurisdictions) != 2 || got.ExpiresOn != nil {
+		t.Errorf("Expected the update to replace the certificate, got %+v", got)
+	}
+
+	w = httptest.NewRecorder()
+	ListExemptions(w, httptest.NewRequest(http.MethodGet, "/api/v1/exemptions?customer_id=shop", nil))
+	var list CertificateList
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(list.Certificates) != 1 {
+		t.Errorf("Expected 1 certificate, got %d", len(list.Certificates))
+	}
+
+	req = mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/api/v1/exemptions/RS-1/validity?jurisdiction=CT&date=2025-06-01", nil), map[string]string{"id": "RS-1"})
+	w = httptest.NewRecorder()
+	ValidateExemption(w, req)
+	var validity models.CertificateValidity
+	if err := json.NewDecoder(w.Body).Decode(&validity); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if validity.Valid || validity.Reason == "" {
+		t.Errorf("Expected certificate not to cover CT, got %+v", validity)
+	}
+
+	req = mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/api/v1/exemptions/RS-1/validity?jurisdiction=NY-MCTD&state=NY&country=US&date=2025-06-01", nil), map[string]string{"id": "RS-1"})
+	w = httptest.NewRecorder()
+	ValidateExemption(w, req)
+	validity = models.CertificateValidity{}
+	if err := json.NewDecoder(w.Body).Decode(&validity); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !validity.Valid {
+		t.Errorf("Expected a NY certificate to cover NY-MCTD, got %+v", validity)
+	}
+
+	req = mux.SetURLVars(httptest.NewRequest(http.MethodDelete, "/api/v1/exemptions/RS-1", nil), map[string]string{"id": "RS-1"})
+	w = httptest.NewRecorder()
+	DeleteExemption(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected status code %d, got %d", http.StatusNoContent, w.Code)
+	}
+
+	req = mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/api/v1/exemptions/RS-1", nil), map[string]string{"id": "RS-1"})
+	w = httptest.NewRecorder()
+	GetExemption(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestListExpiringExemptions(t *testing.T) {
+	SetTaxService(services.NewTaxService(services.DefaultRateProvider()))
+
+	w := httptest.NewRecorder()
+	ListExpiringExemptions(w, httptest.NewRequest(http.MethodGet, "/api/v1/exemptions/expiring?days=soon", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	ListExpiringExemptions(w, httptest.NewRequest(http.MethodGet, "/api/v1/exemptions/expiring", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if got := w.Body.String(); got != "{\"certificates\":[]}\n" {
+		t.Errorf("Expected an empty listing, got %s", got)
+	}
+}
+
+func TestCalculateTax_ExpiredCertificate(t *testing.T) {
+	service := services.NewTaxService(services.DefaultRateProvider())
+	expired := models.MustParseDate("2020-01-31")
+	cert := models.ExemptionCertificate{ID: "RS-9", Reason: "resale", Jurisdictions: []string{"NY"}, ExpiresOn: &expired}
+	if err := service.ExemptionStore().Create(cert); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	SetTaxService(service)
+
+	body, _ := json.Marshal(models.TaxRequest{
+		Address:       models.Address{Country: "US", State: "NY", ZipCode: "10001"},
+		Items:         []models.Item{{ID: "item1", Name: "Product A", Price: models.MustParseMoney("100.00"), Quantity: 1}},
+		CertificateID: "RS-9",
+	})
+	w := httptest.NewRecorder()
+	CalculateTax(w, httptest.NewRequest(http.MethodPost, "/api/v1/calculate-tax", bytes.NewBuffer(body)))
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status code %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+}
+
+func TestValidateExemption_ForeignCountry(t *testing.T) {
+	SetTaxService(services.NewTaxService(services.DefaultRateProvider()))
+
+	for _, body := range []string{
+		`{"id": "CA-1", "reason": "resale", "jurisdictions": ["CA"]}`,
+		`{"id": "DE-1", "reason": "resale", "jurisdictions": ["DE"]}`,
+		`{"id": "DE-2", "reason": "government", "country": "DE", "jurisdictions": ["DE"]}`,
+	} {
+		w := httptest.NewRecorder()
+		CreateExemption(w, httptest.NewRequest(http.MethodPost, "/api/v1/exemptions", bytes.NewBufferString(body)))
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, w.Code, w.Body)
+		}
+	}
+
+	tests := []struct {
+		id, query string
+		valid     bool
+	}{
+		{"CA-1", "jurisdiction=CA&state=CA&country=US", true},
+		{"CA-1", "jurisdiction=ON&state=ON&country=CA", false},
+		{"CA-1", "jurisdiction=CA&country=CA", false},
+		{"DE-1", "jurisdiction=DE&state=DE", true},
+		{"DE-1", "jurisdiction=DE&country=DE", false},
+		{"DE-2", "jurisdiction=DE&country=DE", true},
+		{"DE-2", "jurisdiction=DE&state=DE&country=US", false},
+	}
+	for _, tt := range tests {
+		url := "/api/v1/exemptions/" + tt.id + "/validity?date=2025-06-01&" + tt.query
+		w := httptest.NewRecorder()
+		ValidateExemption(w, mux.SetURLVars(httptest.NewRequest(http.MethodGet, url, nil), map[string]string{"id": tt.id}))
+		var validity models.CertificateValidity
+		if err := json.NewDecoder(w.Body).Decode(&validity); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if validity.Valid != tt.valid {
+			t.Errorf("%s with %s: expected valid=%v, got %+v", tt.id, tt.query, tt.valid, validity)
+		}
+		if tt.id == "DE-1" && !tt.valid && validity.Reason != "certificate was issued in US, not DE" {
+			t.Errorf("Expected the country mismatch as the reason, got %q", validity.Reason)
+		}
+	}
+}
+
+func TestCreateExemption_ErrorStatus(t *testing.T) {
+	// The store's directory does not exist, so saving it fails
+	store, err := services.OpenCertificateStore(filepath.Join(t.TempDir(), "missing", "certificates.json"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	service := services.NewTaxService(services.DefaultRateProvider())
+	service.SetExemptionStore(store)
+	SetTaxService(service)
+
+	tests := []struct {
+		name, body string
+		status     int
+	}{
+		{"invalid certificate", `{"id": "RS-1", "reason": "gift", "jurisdictions": ["NY"]}`, http.StatusBadRequest},
+		{"storage failure", `{"id": "RS-1", "reason": "resale", "jurisdictions": ["NY"]}`, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		CreateExemption(w, httptest.NewRequest(http.MethodPost, "/api/v1/exemptions", bytes.NewBufferString(tt.body)))
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status code %d, got %d: %s", tt.name, tt.status, w.Code, w.Body)
+		}
+	}
+}
@@ -2,15 +2,59 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"runtime"
 
 	"github.com/vijayraghavareddy/tax-calculation/models"
 	"github.com/vijayraghavareddy/tax-calculation/services"
 )
 
-var taxService = services.NewTaxService()
+var taxService = services.NewTaxService(services.DefaultRateProvider())
 
-// CalculateTax handles POST requests to calculate tax
+var ledger = newMemoryLedger(taxService)
+
+// MaxBatchSize is the most requests a batch may hold
+const MaxBatchSize = 10000
+
+// MaxBatchBodySize is the largest batch request body, in bytes
+const MaxBatchBodySize = 32 << 20
+
+// batchParallelism is how many requests of a batch are calculated at once
+var batchParallelism = runtime.GOMAXPROCS(0)
+
+// SetTaxService replaces the service used by the handlers, e.g. to serve a
+// rate table loaded from disk at startup. The ledger is reset to an in-memory
+// one over the new service; call SetLedger afterwards to persist it.
+func SetTaxService(service *services.TaxService) {
+	taxService = service
+	ledger = newMemoryLedger(service)
+}
+
+// SetLedger replaces the ledger used by the transaction handlers
+func SetLedger(l *services.Ledger) {
+	ledger = l
+}
+
+// SetBatchParallelism sets how many requests of a batch are calculated at
+// once; n below 1 is treated as 1
+func SetBatchParallelism(n int) {
+	batchParallelism = n
+}
+
+// newMemoryLedger creates a ledger that keeps transactions in memory
+func newMemoryLedger(service *services.TaxService) *services.Ledger {
+	l, err := services.NewLedger(service, services.NewMemoryTransactionRepository())
+	if err != nil {
+		panic(err) // an empty repository cannot fail
+	}
+	return l
+}
+
+// CalculateTax handles POST requests to calculate tax. The calculated order
+// is recorded in the ledger for the reports.
 func CalculateTax(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -20,14 +64,15 @@ func CalculateTax(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Normalize postal_code to zipcode if provided
-	if req.Address.ZipCode == "" && req.Address.PostalCode != "" {
-		req.Address.ZipCode = req.Address.PostalCode
-	}
+	normalizeRequest(&req)
 
 	response, err := taxService.CalculateTax(&req)
 	if err != nil {
-		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		sendErrorResponse(w, err.Error(), statusForError(err))
+		return
+	}
+	if err := recordCalculation(&req, response); err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
@@ -35,23 +80,160 @@ func CalculateTax(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// CalculateTaxBatch handles POST requests with an array of tax requests. Each
+// is calculated on its own and gets a result or an error; a bad request does
+// not fail the batch. Each calculated order is recorded for the reports.
+func CalculateTaxBatch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	entries, err := decodeBatch(http.MaxBytesReader(w, r.Body, MaxBatchBodySize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			sendErrorResponse(w, fmt.Sprintf("a batch body may be at most %d bytes", MaxBatchBodySize), http.StatusRequestEntityTooLarge)
+			return
+		}
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Entries that do not decode are reported on their own and left out of
+	// the calculation
+	results := make([]models.BatchTaxResult, len(entries))
+	var reqs []models.TaxRequest
+	var indexes []int
+	for i, entry := range entries {
+		results[i].Index = i
+		var req models.TaxRequest
+		if err := json.Unmarshal(entry, &req); err != nil {
+			results[i].Error = newErrorResponse("Invalid request body", http.StatusBadRequest)
+			continue
+		}
+		normalizeRequest(&req)
+		reqs = append(reqs, req)
+		indexes = append(indexes, i)
+	}
+
+	responses, errs := taxService.CalculateTaxBatch(reqs, batchParallelism)
+	for j, i := range indexes {
+		if errs[j] != nil {
+			results[i].Error = newErrorResponse(errs[j].Error(), statusForError(errs[j]))
+			continue
+		}
+		if err := recordCalculation(&reqs[j], responses[j]); err != nil {
+			results[i].Error = newErrorResponse(err.Error(), http.StatusInternalServerError)
+			continue
+		}
+		results[i].Result = responses[j]
+	}
+
+	batch := models.BatchTaxResponse{Results: results}
+	for _, result := range results {
+		if result.Error != nil {
+			batch.Failed++
+		} else {
+			batch.Succeeded++
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(batch)
+}
+
+// decodeBatch reads a batch's JSON array of requests, leaving each entry to be
+// decoded on its own. It stops at the first entry past MaxBatchSize rather
+// than reading the rest of the body.
+func decodeBatch(r io.Reader) ([]json.RawMessage, error) {
+	invalid := errors.New("Invalid request body: expected an array of tax requests")
+	decoder := json.NewDecoder(r)
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, readError(err, invalid)
+	}
+	if token != json.Delim('[') {
+		return nil, invalid
+	}
+	var entries []json.RawMessage
+	for decoder.More() {
+		if len(entries) == MaxBatchSize {
+			return nil, fmt.Errorf("a batch may hold at most %d requests", MaxBatchSize)
+		}
+		var entry json.RawMessage
+		if err := decoder.Decode(&entry); err != nil {
+			return nil, readError(err, invalid)
+		}
+		entries = append(entries, entry)
+	}
+	if _, err := decoder.Token(); err != nil {
+		return nil, readError(err, invalid)
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("at least one request is required")
+	}
+	return entries, nil
+}
+
+// readError returns err if reading the body failed for its size, otherwise
+// invalid
+func readError(err, invalid error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return err
+	}
+	return invalid
+}
+
+// recordCalculation records a calculated order in the ledger
+func recordCalculation(req *models.TaxRequest, response *models.TaxResponse) error {
+	if _, err := ledger.RecordCalculation(req, response); err != nil {
+		return fmt.Errorf("recording the calculation: %w", err)
+	}
+	return nil
+}
+
 // HealthCheck handles GET requests for health check
 func HealthCheck(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{
-		"status":  "healthy",
-		"service": "tax-calculation-api",
-		"version": "1.0.0",
+		"status":             "healthy",
+		"service":            "tax-calculation-api",
+		"version":            "1.0.0",
+		"rate_table_version": taxService.RateTableVersion(),
 	})
 }
 
+// normalizeRequest copies postal_code to zipcode where only postal_code is
+// provided
+func normalizeRequest(req *models.TaxRequest) {
+	if req.Address.ZipCode == "" && req.Address.PostalCode != "" {
+		req.Address.ZipCode = req.Address.PostalCode
+	}
+}
+
+// statusForError maps a service error to an HTTP status code
+func statusForError(err error) int {
+	var unsupported *services.UnsupportedJurisdictionError
+	if errors.As(err, &unsupported) {
+		return http.StatusUnprocessableEntity
+	}
+	var expired *services.ExpiredCertificateError
+	if errors.As(err, &expired) {
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusBadRequest
+}
+
 // sendErrorResponse sends an error response
 func sendErrorResponse(w http.ResponseWriter, message string, statusCode int) {
 	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(models.ErrorResponse{
+	json.NewEncoder(w).Encode(newErrorResponse(message, statusCode))
+}
+
+// newErrorResponse builds the error body for a status code
+func newErrorResponse(message string, statusCode int) *models.ErrorResponse {
+	return &models.ErrorResponse{
 		Error:   http.StatusText(statusCode),
 		Message: message,
 		Code:    statusCode,
-	})
+	}
 }
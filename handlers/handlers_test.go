@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/vijayraghavareddy/tax-calculation/models"
@@ -23,7 +24,7 @@ func TestCalculateTax_ValidRequest(t *testing.T) {
 			{
 				ID:       "item1",
 				Name:     "Product A",
-				Price:    100.00,
+				Price:    models.MustParseMoney("100.00"),
 				Quantity: 2,
 			},
 		},
@@ -45,11 +46,11 @@ func TestCalculateTax_ValidRequest(t *testing.T) {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
 
-	if resp.Subtotal != 200.00 {
-		t.Errorf("Expected subtotal 200.00, got %f", resp.Subtotal)
+	if resp.Subtotal.String() != "200.00" {
+		t.Errorf("Expected subtotal 200.00, got %s", resp.Subtotal)
 	}
 
-	if resp.TotalTax <= 0 {
+	if resp.TotalTax.Units <= 0 {
 		t.Error("Expected tax to be greater than 0")
 	}
 }
@@ -76,7 +77,36 @@ func TestCalculateTax_MissingState(t *testing.T) {
 			{
 				ID:       "item1",
 				Name:     "Product A",
-				Price:    100.00,
+				Price:    models.MustParseMoney("100.00"),
+				Quantity: 1,
+			},
+		},
+	}
+
+	body, _ := json.Marshal(reqBody)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/calculate-tax", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	CalculateTax(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestCalculateTax_MissingSellerState(t *testing.T) {
+	reqBody := models.TaxRequest{
+		Address: models.Address{
+			State:   "KA",
+			Country: "IN",
+			ZipCode: "560001",
+		},
+		Items: []models.Item{
+			{
+				ID:       "item1",
+				Name:     "Product A",
+				Price:    models.MustParseMoney("100.00"),
 				Quantity: 1,
 			},
 		},
@@ -107,7 +137,7 @@ func TestCalculateTax_WithPostalCode(t *testing.T) {
 			{
 				ID:       "item1",
 				Name:     "Product A",
-				Price:    100.00,
+				Price:    models.MustParseMoney("100.00"),
 				Quantity: 1,
 			},
 		},
@@ -178,3 +208,92 @@ func TestCalculateTax_EmptyItems(t *testing.T) {
 		t.Errorf("Expected error code %d, got %d", http.StatusBadRequest, resp.Code)
 	}
 }
+
+func TestCalculateTax_UnsupportedCountry(t *testing.T) {
+	reqBody := models.TaxRequest{
+		Address: models.Address{
+			City:    "Tokyo",
+			Country: "JP",
+			ZipCode: "100-0001",
+		},
+		Items: []models.Item{
+			{
+				ID:       "item1",
+				Name:     "Product A",
+				Price:    models.MustParseMoney("100.00"),
+				Quantity: 1,
+			},
+		},
+	}
+
+	body, _ := json.Marshal(reqBody)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/calculate-tax", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	CalculateTax(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status code %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+}
+
+func TestCalculateTaxBatch(t *testing.T) {
+	body := `[
+		{"address": {"country": "US", "state": "NY", "postal_code": "10001"},
+		 "items": [{"id": "item1", "name": "Product A", "price": "100.00", "quantity": 1}]},
+		{"address": {"city": "Tokyo", "country": "JP", "zipcode": "100-0001"},
+		 "items": [{"id": "item1", "name": "Product A", "price": "100.00", "quantity": 1}]},
+		{"address": {"country": "US", "state": "NY", "zipcode": "10001"}, "items": "not a list"},
+		{"address": {"country": "US", "state": "NY", "zipcode": "10001"}, "items": []}
+	]`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/calculate-tax/batch", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+
+	CalculateTaxBatch(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	var response models.BatchTaxResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Results) != 4 || response.Succeeded != 1 || response.Failed != 3 {
+		t.Fatalf("Expected one success and three failures, got %+v", response)
+	}
+	if result := response.Results[0]; result.Result == nil || result.Result.TotalTax.IsZero() || result.Error != nil {
+		t.Errorf("Expected the first request to be taxed, got %+v", result)
+	}
+	for i, code := range map[int]int{1: http.StatusUnprocessableEntity, 2: http.StatusBadRequest, 3: http.StatusBadRequest} {
+		if result := response.Results[i]; result.Index != i || result.Result != nil || result.Error == nil || result.Error.Code != code {
+			t.Errorf("Expected request %d to fail with %d, got %+v", i, code, result)
+		}
+	}
+}
+
+func TestCalculateTaxBatch_InvalidBatch(t *testing.T) {
+	for _, body := range []string{`{"address": {}}`, `[]`, `not json`} {
+		w := httptest.NewRecorder()
+		CalculateTaxBatch(w, httptest.NewRequest(http.MethodPost, "/api/v1/calculate-tax/batch", bytes.NewBufferString(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status code %d, got %d", body, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+func TestCalculateTaxBatch_Limits(t *testing.T) {
+	tooMany := "[" + strings.Repeat("{},", MaxBatchSize) + "{}]"
+	w := httptest.NewRecorder()
+	CalculateTaxBatch(w, httptest.NewRequest(http.MethodPost, "/api/v1/calculate-tax/batch", strings.NewReader(tooMany)))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "at most 10000 requests") {
+		t.Errorf("Expected status code %d for too many requests, got %d: %s", http.StatusBadRequest, w.Code, w.Body)
+	}
+
+	tooLarge := `[{"address": {"street": "` + strings.Repeat("x", MaxBatchBodySize) + `"}}]`
+	w = httptest.NewRecorder()
+	CalculateTaxBatch(w, httptest.NewRequest(http.MethodPost, "/api/v1/calculate-tax/batch", strings.NewReader(tooLarge)))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status code %d for an oversized body, got %d: %s", http.StatusRequestEntityTooLarge, w.Code, w.Body)
+	}
+}
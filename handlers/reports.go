@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/vijayraghavareddy/tax-calculation/models"
+	"github.com/vijayraghavareddy/tax-calculation/services"
+)
+
+// returnExporters lays out state returns
+var returnExporters = services.DefaultReturnExporters()
+
+// SetReturnExporters replaces the exporters used for state returns, e.g. to
+// add a state's form
+func SetReturnExporters(e *services.ReturnExporters) {
+	returnExporters = e
+}
+
+// LiabilityReport handles GET requests for the tax collected by jurisdiction
+// and period, as JSON or, with format=csv, as CSV. It reports the calculated
+// orders or, with source=committed, the ledger's committed sales.
+func LiabilityReport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.URL.Query()
+	query := services.LiabilityQuery{Period: params.Get("period"), SellerID: params.Get("seller_id"), Source: params.Get("source")}
+	var err error
+	if query.From, err = dateParam(r, "from"); err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if query.To, err = dateParam(r, "to"); err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	format := params.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		sendErrorResponse(w, fmt.Sprintf("format %q is not one of json or csv", format), http.StatusBadRequest)
+		return
+	}
+
+	report, err := ledger.LiabilityReport(query)
+	if err != nil {
+		sendErrorResponse(w, err.Error(), statusForError(err))
+		return
+	}
+
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="liability.csv"`)
+		w.WriteHeader(http.StatusOK)
+		services.WriteLiabilityCSV(w, report)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(report)
+}
+
+// StateReturn handles GET requests for a state's return figures for a month
+// or quarter, as the state's return export or, with format=json, as JSON. It
+// covers the calculated orders or, with source=committed, the ledger's
+// committed sales.
+func StateReturn(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.URL.Query()
+	format := params.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		sendErrorResponse(w, fmt.Sprintf("format %q is not one of json or csv", format), http.StatusBadRequest)
+		return
+	}
+
+	ret, err := ledger.StateReturn(services.ReturnQuery{
+		State:    mux.Vars(r)["state"],
+		Period:   params.Get("period"),
+		SellerID: params.Get("seller_id"),
+		Source:   params.Get("source"),
+	})
+	if err != nil {
+		sendErrorResponse(w, err.Error(), statusForError(err))
+		return
+	}
+
+	if format == "json" {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(ret)
+		return
+	}
+	var buf bytes.Buffer
+	if err := returnExporters.For(ret.State).Export(&buf, ret); err != nil {
+		sendErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.csv"`, ret.State, ret.Period))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// dateParam parses the optional date query parameter name
+func dateParam(r *http.Request, name string) (*models.Date, error) {
+	text := r.URL.Query().Get(name)
+	if text == "" {
+		return nil, nil
+	}
+	date, err := models.ParseDate(text)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return &date, nil
+}
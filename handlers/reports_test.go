@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/vijayraghavareddy/tax-calculation/models"
+	"github.com/vijayraghavareddy/tax-calculation/services"
+)
+
+func TestLiabilityReport(t *testing.T) {
+	SetTaxService(services.NewTaxService(services.DefaultRateProvider()))
+
+	body := `{"code": "INV-1", "commit": true, "request": {"address": {"country": "US", "state": "NY", "zipcode": "10001"},
+		"transaction_date": "2025-05-10", "items": [{"id": "item1", "name": "Product A", "price": "100.00", "quantity": 1}]}}`
+	w := httptest.NewRecorder()
+	CreateTransaction(w, httptest.NewRequest(http.MethodPost, "/api/v1/transactions", bytes.NewBufferString(body)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, w.Code, w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	LiabilityReport(w, httptest.NewRequest(http.MethodGet, "/api/v1/reports/liability?period=quarter&source=committed", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	var report models.LiabilityReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if report.Period != "quarter" || len(report.Rows) == 0 || report.Rows[0].Period != "2025-Q2" {
+		t.Errorf("Expected quarterly rows for 2025-Q2, got %+v", report)
+	}
+
+	w = httptest.NewRecorder()
+	LiabilityReport(w, httptest.NewRequest(http.MethodGet, "/api/v1/reports/liability?format=csv&source=committed", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/csv" {
+		t.Fatalf("Expected a CSV report, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	if !strings.HasPrefix(w.Body.String(), "period,") || !strings.Contains(w.Body.String(), "2025-05,2025-05-01,2025-05-31,NY,New York,state,USD,1,100.00") {
+		t.Errorf("Expected the New York May row, got %s", w.Body)
+	}
+
+	for _, query := range []string{"period=year", "from=2025-13-01", "format=xml", "source=quoted"} {
+		w = httptest.NewRecorder()
+		LiabilityReport(w, httptest.NewRequest(http.MethodGet, "/api/v1/reports/liability?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status code %d, got %d", query, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+func TestLiabilityReport_CalculatedOrders(t *testing.T) {
+	SetTaxService(services.NewTaxService(services.DefaultRateProvider()))
+
+	order := `{"address": {"country": "US", "state": "NY", "zipcode": "10001"}, "transaction_date": "2025-05-10",
+		"items": [{"id": "item1", "name": "Product A", "price": "100.00", "quantity": 1}]}`
+	w := httptest.NewRecorder()
+	CalculateTax(w, httptest.NewRequest(http.MethodPost, "/api/v1/calculate-tax", bytes.NewBufferString(order)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	w = httptest.NewRecorder()
+	CalculateTaxBatch(w, httptest.NewRequest(http.MethodPost, "/api/v1/calculate-tax/batch", bytes.NewBufferString("["+order+", {}]")))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	w = httptest.NewRecorder()
+	CalculateTaxStream(w, httptest.NewRequest(http.MethodPost, "/api/v1/calculate-tax/stream", strings.NewReader(strings.ReplaceAll(order, "\n", " ")+"\n")))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	LiabilityReport(w, httptest.NewRequest(http.MethodGet, "/api/v1/reports/liability", nil))
+	var report models.LiabilityReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if report.Source != "calculated" || len(report.Rows) == 0 || report.Rows[0].Code != "NY" ||
+		report.Rows[0].Transactions != 3 || report.Rows[0].GrossSales.String() != "300.00" {
+		t.Errorf("Expected the three calculated New York orders, got %+v", report)
+	}
+
+	w = httptest.NewRecorder()
+	LiabilityReport(w, httptest.NewRequest(http.MethodGet, "/api/v1/reports/liability?source=committed", nil))
+	report = models.LiabilityReport{}
+	json.NewDecoder(w.Body).Decode(&report)
+	if len(report.Rows) != 0 {
+		t.Errorf("Expected no committed sales, got %+v", report.Rows)
+	}
+}
+
+func TestStateReturn(t *testing.T) {
+	SetTaxService(services.NewTaxService(services.DefaultRateProvider()))
+
+	body := `{"code": "INV-1", "commit": true, "request": {"address": {"country": "US", "state": "TX", "zipcode": "78701"},
+		"transaction_date": "2025-05-10", "items": [{"id": "item1", "name": "Product A", "price": "100.00", "quantity": 1}]}}`
+	w := httptest.NewRecorder()
+	CreateTransaction(w, httptest.NewRequest(http.MethodPost, "/api/v1/transactions", bytes.NewBufferString(body)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, w.Code, w.Body)
+	}
+
+	vars := map[string]string{"state": "TX"}
+	w = httptest.NewRecorder()
+	StateReturn(w, mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/api/v1/reports/returns/TX?period=2025-Q2&source=committed", nil), vars))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/csv" {
+		t.Fatalf("Expected a CSV export, got %d %s: %s", w.Code, w.Header().Get("Content-Type"), w.Body)
+	}
+	if !strings.Contains(w.Body.String(), "total_sales,Total Texas sales,,,100.00") {
+		t.Errorf("Expected the Texas form, got %s", w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	StateReturn(w, mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/api/v1/reports/returns/TX?period=2025-05&format=json&source=committed", nil), vars))
+	var ret models.StateReturn
+	if err := json.NewDecoder(w.Body).Decode(&ret); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if ret.Period != "2025-05" || len(ret.Locations) == 0 || ret.Locations[0].TaxDue.String() != "6.25" {
+		t.Errorf("Expected Texas state tax of 6.25 for May, got %+v", ret)
+	}
+
+	// A calculated order is returned by default, without the committed sale
+	order := `{"address": {"country": "US", "state": "TX", "zipcode": "78701"}, "transaction_date": "2025-05-12",
+		"items": [{"id": "item1", "name": "Product A", "price": "40.00", "quantity": 1}]}`
+	w = httptest.NewRecorder()
+	CalculateTax(w, httptest.NewRequest(http.MethodPost, "/api/v1/calculate-tax", bytes.NewBufferString(order)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	w = httptest.NewRecorder()
+	StateReturn(w, mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/api/v1/reports/returns/TX?period=2025-Q2", nil), vars))
+	if !strings.Contains(w.Body.String(), "total_sales,Total Texas sales,,,40.00") {
+		t.Errorf("Expected the calculated order only, got %s", w.Body)
+	}
+
+	for _, query := range []string{"period=2025", "period=2025-05&format=xml", "period=2025-05&source=quoted"} {
+		w = httptest.NewRecorder()
+		StateReturn(w, mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/api/v1/reports/returns/TX?"+query, nil), vars))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status code %d, got %d", query, http.StatusBadRequest, w.Code)
+		}
+	}
+}
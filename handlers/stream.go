@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+// MaxStreamLineSize is the longest request line a stream may hold, in bytes
+const MaxStreamLineSize = 4 << 20
+
+// CalculateTaxStream handles POST requests with an NDJSON stream of tax
+// requests, one per line, and streams back one NDJSON result per line in the
+// same order. Lines are read, calculated and written a few at a time, so
+// memory use does not grow with the size of the stream. Each calculated order
+// is recorded for the reports.
+func CalculateTaxStream(w http.ResponseWriter, r *http.Request) {
+	// Read the request while writing the response rather than reading it all
+	// first. HTTP/2 does so already and does not support the call.
+	controller := http.NewResponseController(w)
+	if err := controller.EnableFullDuplex(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		sendErrorResponse(w, fmt.Sprintf("enabling full duplex: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	streamTax(r.Body, w, true, func() { controller.Flush() })
+}
+
+// StreamTax reads an NDJSON stream of tax requests from r and writes an
+// NDJSON stream of results to w, as the stream endpoint does. It returns the
+// first error reading r or writing w; errors in individual requests are
+// written as results. Unlike the endpoint it does not record the orders.
+func StreamTax(r io.Reader, w io.Writer) error {
+	return streamTax(r, w, false, nil)
+}
+
+// streamJob is one request line on its way through the stream
+type streamJob struct {
+	line    int
+	data    []byte
+	tooLong bool
+	err     error // reading the line failed
+	result  chan models.StreamTaxResult
+}
+
+// streamTax runs the stream: one goroutine reads lines, batchParallelism
+// workers calculate them and the caller's goroutine writes the results in
+// input order. At most batchParallelism lines wait to be written, which
+// bounds memory. record sets whether calculated orders are recorded in the
+// ledger. flush, if set, is called whenever the writer catches up with the
+// workers.
+func streamTax(r io.Reader, w io.Writer, record bool, flush func()) error {
+	parallelism := batchParallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	pending := make(chan *streamJob, parallelism) // in input order
+	jobs := make(chan *streamJob)
+
+	go readStream(r, pending, jobs)
+	for i := 0; i < parallelism; i++ {
+		go func() {
+			for job := range jobs {
+				job.result <- calculateStreamLine(job, record)
+			}
+		}()
+	}
+
+	out := bufio.NewWriter(w)
+	encoder := json.NewEncoder(out)
+	var firstErr error
+	for job := range pending {
+		result := <-job.result
+		if job.err != nil && firstErr == nil {
+			firstErr = job.err
+		}
+		// Keep draining after a write error so the reader and workers finish
+		if err := encoder.Encode(result); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if len(pending) == 0 {
+			if err := out.Flush(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+			if flush != nil {
+				flush()
+			}
+		}
+	}
+	if err := out.Flush(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
+}
+
+// readStream reads r line by line, queueing each non-blank line for the
+// writer in order and handing it to the workers. A line longer than
+// MaxStreamLineSize is skipped over and reported as an error. A read error is
+// reported as a final line.
+func readStream(r io.Reader, pending, jobs chan<- *streamJob) {
+	defer close(pending)
+	defer close(jobs)
+
+	reader := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		job := &streamJob{line: line, result: make(chan models.StreamTaxResult, 1)}
+		var err error
+		for {
+			var chunk []byte
+			var more bool
+			chunk, more, err = reader.ReadLine()
+			if len(job.data)+len(chunk) > MaxStreamLineSize {
+				job.tooLong, job.data = true, nil
+			}
+			if !job.tooLong {
+				job.data = append(job.data, chunk...)
+			}
+			if !more || err != nil {
+				break
+			}
+		}
+		if err == io.EOF && len(job.data) == 0 && !job.tooLong {
+			return
+		}
+		if err != nil && err != io.EOF {
+			job.err, job.data = fmt.Errorf("reading request line %d: %w", line, err), nil
+		}
+		if job.err == nil && !job.tooLong && len(bytes.TrimSpace(job.data)) == 0 {
+			continue
+		}
+		pending <- job
+		jobs <- job
+		if err != nil {
+			return
+		}
+	}
+}
+
+// calculateStreamLine calculates one request line, recording the order when
+// record is set
+func calculateStreamLine(job *streamJob, record bool) models.StreamTaxResult {
+	result := models.StreamTaxResult{Line: job.line}
+	if job.err != nil {
+		result.Error = newErrorResponse(job.err.Error(), http.StatusBadRequest)
+		return result
+	}
+	if job.tooLong {
+		result.Error = newErrorResponse(fmt.Sprintf("request line is longer than %d bytes", MaxStreamLineSize), http.StatusRequestEntityTooLarge)
+		return result
+	}
+
+	var req models.StreamTaxRequest
+	if err := json.Unmarshal(job.data, &req); err != nil {
+		// Report the caller's ID when it can still be read
+		var id struct {
+			CorrelationID string `json:"correlation_id"`
+		}
+		json.Unmarshal(job.data, &id)
+		result.CorrelationID = id.CorrelationID
+		result.Error = newErrorResponse("Invalid request line", http.StatusBadRequest)
+		return result
+	}
+	result.CorrelationID = req.CorrelationID
+
+	normalizeRequest(&req.TaxRequest)
+	response, err := taxService.CalculateTax(&req.TaxRequest)
+	if err != nil {
+		result.Error = newErrorResponse(err.Error(), statusForError(err))
+		return result
+	}
+	if record {
+		if err := recordCalculation(&req.TaxRequest, response); err != nil {
+			result.Error = newErrorResponse(err.Error(), http.StatusInternalServerError)
+			return result
+		}
+	}
+	result.Result = response
+	return result
+}
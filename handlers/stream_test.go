@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+	"github.com/vijayraghavareddy/tax-calculation/services"
+)
+
+const streamLine = `{"correlation_id": "%s", "address": {"country": "US", "state": "NY", "postal_code": "10001"},
+	"items": [{"id": "item1", "name": "Product A", "price": "%s", "quantity": 1}]}`
+
+func decodeStream(t *testing.T, r io.Reader) []models.StreamTaxResult {
+	t.Helper()
+	var results []models.StreamTaxResult
+	decoder := json.NewDecoder(r)
+	for decoder.More() {
+		var result models.StreamTaxResult
+		if err := decoder.Decode(&result); err != nil {
+			t.Fatalf("Failed to decode result: %v", err)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func TestStreamTax_PreservesOrder(t *testing.T) {
+	SetTaxService(services.NewTaxService(services.DefaultRateProvider()))
+	SetBatchParallelism(4)
+
+	var input strings.Builder
+	for i := 0; i < 200; i++ {
+		price := fmt.Sprintf("%d.00", i+1)
+		if i%7 == 0 {
+			price = "-1.00"
+		}
+		fmt.Fprintf(&input, strings.ReplaceAll(streamLine, "\n\t", " ")+"\n", fmt.Sprintf("order-%d", i), price)
+	}
+
+	var output bytes.Buffer
+	if err := StreamTax(strings.NewReader(input.String()), &output); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	results := decodeStream(t, &output)
+	if len(results) != 200 {
+		t.Fatalf("Expected 200 results, got %d", len(results))
+	}
+	for i, result := range results {
+		if result.Line != i+1 || result.CorrelationID != fmt.Sprintf("order-%d", i) {
+			t.Fatalf("Expected line %d order-%d, got line %d %s", i+1, i, result.Line, result.CorrelationID)
+		}
+		if failed := result.Error != nil; failed != (i%7 == 0) {
+			t.Errorf("line %d: unexpected outcome %+v", i+1, result)
+		}
+		if result.Result != nil && result.Result.Subtotal.String() != fmt.Sprintf("%d.00", i+1) {
+			t.Errorf("line %d: expected subtotal %d.00, got %s", i+1, i+1, result.Result.Subtotal)
+		}
+	}
+}
+
+func TestStreamTax_BadLines(t *testing.T) {
+	SetTaxService(services.NewTaxService(services.DefaultRateProvider()))
+
+	input := `{"correlation_id": "a", "address": {"country": "US"}, "items": "none"}` + "\n" +
+		"\n" +
+		"not json\n" +
+		`{"correlation_id": "big", "padding": "` + strings.Repeat("x", MaxStreamLineSize) + `"}` + "\n" +
+		fmt.Sprintf(strings.ReplaceAll(streamLine, "\n\t", " "), "last", "10.00") // no trailing newline
+
+	var output bytes.Buffer
+	if err := StreamTax(strings.NewReader(input), &output); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	results := decodeStream(t, &output)
+	expected := []struct {
+		line int
+		id   string
+		code int
+	}{
+		{1, "a", http.StatusBadRequest},
+		{3, "", http.StatusBadRequest},
+		{4, "", http.StatusRequestEntityTooLarge},
+		{5, "last", 0},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d results, got %+v", len(expected), results)
+	}
+	for i, e := range expected {
+		result := results[i]
+		code := 0
+		if result.Error != nil {
+			code = result.Error.Code
+		}
+		if result.Line != e.line || result.CorrelationID != e.id || code != e.code {
+			t.Errorf("Expected line %d %q with code %d, got %+v", e.line, e.id, e.code, result)
+		}
+	}
+}
+
+func TestStreamTax_WritesBeforeInputEnds(t *testing.T) {
+	SetTaxService(services.NewTaxService(services.DefaultRateProvider()))
+
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- StreamTax(inReader, outWriter)
+		outWriter.Close()
+	}()
+
+	fmt.Fprintf(inWriter, strings.ReplaceAll(streamLine, "\n\t", " ")+"\n", "first", "10.00")
+	output := bufio.NewReader(outReader)
+	line, err := output.ReadBytes('\n')
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var result models.StreamTaxResult
+	if err := json.Unmarshal(line, &result); err != nil || result.CorrelationID != "first" {
+		t.Errorf("Expected the first result while the input is open, got %s", line)
+	}
+
+	inWriter.Close()
+	io.Copy(io.Discard, output)
+	if err := <-done; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCalculateTaxStream(t *testing.T) {
+	SetTaxService(services.NewTaxService(services.DefaultRateProvider()))
+
+	body := fmt.Sprintf(strings.ReplaceAll(streamLine, "\n\t", " "), "a", "100.00") + "\n" + `{"correlation_id": "b"}` + "\n"
+	w := httptest.NewRecorder()
+	CalculateTaxStream(w, httptest.NewRequest(http.MethodPost, "/api/v1/calculate-tax/stream", strings.NewReader(body)))
+
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("Expected an NDJSON stream, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	results := decodeStream(t, w.Body)
+	if len(results) != 2 || results[0].Result == nil || results[0].Result.TotalTax.IsZero() || results[1].Error == nil {
+		t.Errorf("Expected a result then an error, got %+v", results)
+	}
+}
+
+// duplexFailingWriter is a response writer whose connection cannot be made
+// full duplex
+type duplexFailingWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (duplexFailingWriter) EnableFullDuplex() error {
+	return errors.New("connection hijacked")
+}
+
+func TestCalculateTaxStream_FullDuplexFailure(t *testing.T) {
+	SetTaxService(services.NewTaxService(services.DefaultRateProvider()))
+
+	body := fmt.Sprintf(strings.ReplaceAll(streamLine, "\n\t", " "), "a", "100.00") + "\n"
+	w := duplexFailingWriter{httptest.NewRecorder()}
+	CalculateTaxStream(w, httptest.NewRequest(http.MethodPost, "/api/v1/calculate-tax/stream", strings.NewReader(body)))
+
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "connection hijacked") {
+		t.Errorf("Expected a 500 naming the duplex error, got %d: %s", w.Code, w.Body)
+	}
+}
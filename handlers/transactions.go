@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/vijayraghavareddy/tax-calculation/models"
+	"github.com/vijayraghavareddy/tax-calculation/services"
+)
+
+// CreateTransaction handles POST requests that calculate tax and save the
+// result under a document code, as a quote or a committed sale
+func CreateTransaction(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var body models.CreateTransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	normalizeRequest(&body.Request)
+
+	txn, err := ledger.Create(body.Code, &body.Request, body.Commit)
+	if err != nil {
+		sendErrorResponse(w, err.Error(), statusForTransactionError(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(txn)
+}
+
+// GetTransaction handles GET requests for a transaction by document code
+func GetTransaction(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	txn, err := ledger.Transaction(mux.Vars(r)["code"])
+	if err != nil {
+		sendErrorResponse(w, err.Error(), statusForTransactionError(err))
+		return
+	}
+	sendTransaction(w, txn)
+}
+
+// CommitTransaction handles POST requests that turn a quote into a sale
+func CommitTransaction(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	txn, err := ledger.Commit(mux.Vars(r)["code"])
+	if err != nil {
+		sendErrorResponse(w, err.Error(), statusForTransactionError(err))
+		return
+	}
+	sendTransaction(w, txn)
+}
+
+// AdjustTransaction handles POST requests that recalculate a transaction from
+// a corrected request
+func AdjustTransaction(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var body models.AdjustTransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	normalizeRequest(&body.Request)
+
+	txn, err := ledger.Adjust(mux.Vars(r)["code"], body.Reason, &body.Request)
+	if err != nil {
+		sendErrorResponse(w, err.Error(), statusForTransactionError(err))
+		return
+	}
+	sendTransaction(w, txn)
+}
+
+// VoidTransaction handles POST requests that cancel a transaction
+func VoidTransaction(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var body models.VoidTransactionRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			sendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	txn, err := ledger.Void(mux.Vars(r)["code"], body.Reason)
+	if err != nil {
+		sendErrorResponse(w, err.Error(), statusForTransactionError(err))
+		return
+	}
+	sendTransaction(w, txn)
+}
+
+// RefundTransaction handles POST requests that return some or all of a
+// committed sale. An empty body refunds everything not yet refunded.
+func RefundTransaction(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var body models.RefundRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			sendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	txn, err := ledger.Refund(mux.Vars(r)["code"], &body)
+	if err != nil {
+		sendErrorResponse(w, err.Error(), statusForTransactionError(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(txn)
+}
+
+// statusForTransactionError maps a ledger error to an HTTP status code
+func statusForTransactionError(err error) int {
+	var state *services.TransactionStateError
+	switch {
+	case errors.Is(err, services.ErrTransactionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrTransactionExists), errors.As(err, &state):
+		return http.StatusConflict
+	}
+	return statusForError(err)
+}
+
+// sendTransaction sends a transaction
+func sendTransaction(w http.ResponseWriter, txn *models.Transaction) {
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(txn)
+}
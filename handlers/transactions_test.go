@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/vijayraghavareddy/tax-calculation/models"
+	"github.com/vijayraghavareddy/tax-calculation/services"
+)
+
+func TestTransactionLifecycle(t *testing.T) {
+	SetTaxService(services.NewTaxService(services.DefaultRateProvider()))
+
+	body := `{"code": "INV-1", "request": {"address": {"country": "US", "state": "NY", "postal_code": "10001"},
+		"items": [{"id": "item1", "name": "Product A", "price": "100.00", "quantity": 1}]}}`
+	w := httptest.NewRecorder()
+	CreateTransaction(w, httptest.NewRequest(http.MethodPost, "/api/v1/transactions", bytes.NewBufferString(body)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, w.Code, w.Body)
+	}
+	var txn models.Transaction
+	if err := json.NewDecoder(w.Body).Decode(&txn); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if txn.Status != services.TransactionQuoted || txn.Result.TotalTax.IsZero() {
+		t.Errorf("Expected a taxed quote, got %+v", txn)
+	}
+
+	w = httptest.NewRecorder()
+	CreateTransaction(w, httptest.NewRequest(http.MethodPost, "/api/v1/transactions", bytes.NewBufferString(body)))
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status code %d, got %d", http.StatusConflict, w.Code)
+	}
+
+	vars := map[string]string{"code": "INV-1"}
+	w = httptest.NewRecorder()
+	CommitTransaction(w, mux.SetURLVars(httptest.NewRequest(http.MethodPost, "/api/v1/transactions/INV-1/commit", nil), vars))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+
+	adjust := `{"reason": "quantity change", "request": {"address": {"country": "US", "state": "NY", "zipcode": "10001"},
+		"items": [{"id": "item1", "name": "Product A", "price": "100.00", "quantity": 2}]}}`
+	w = httptest.NewRecorder()
+	AdjustTransaction(w, mux.SetURLVars(httptest.NewRequest(http.MethodPost, "/api/v1/transactions/INV-1/adjust", bytes.NewBufferString(adjust)), vars))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	VoidTransaction(w, mux.SetURLVars(httptest.NewRequest(http.MethodPost, "/api/v1/transactions/INV-1/void", nil), vars))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	GetTransaction(w, mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/api/v1/transactions/INV-1", nil), vars))
+	if err := json.NewDecoder(w.Body).Decode(&txn); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if txn.Status != services.TransactionVoided || txn.Version != 2 || txn.Result.Subtotal.String() != "200.00" {
+		t.Errorf("Expected the voided second version, got %+v", txn)
+	}
+
+	w = httptest.NewRecorder()
+	CommitTransaction(w, mux.SetURLVars(httptest.NewRequest(http.MethodPost, "/api/v1/transactions/INV-1/commit", nil), vars))
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status code %d, got %d", http.StatusConflict, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	GetTransaction(w, mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/api/v1/transactions/INV-2", nil), map[string]string{"code": "INV-2"}))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestRefundTransaction(t *testing.T) {
+	SetTaxService(services.NewTaxService(services.DefaultRateProvider()))
+
+	body := `{"code": "INV-1", "commit": true, "request": {"address": {"country": "US", "state": "NY", "zipcode": "10001"},
+		"items": [{"id": "item1", "name": "Product A", "price": "100.00", "quantity": 2}]}}`
+	w := httptest.NewRecorder()
+	CreateTransaction(w, httptest.NewRequest(http.MethodPost, "/api/v1/transactions", bytes.NewBufferString(body)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, w.Code, w.Body)
+	}
+
+	vars := map[string]string{"code": "INV-1"}
+	refund := `{"reason": "returned", "items": [{"item_id": "item1", "quantity": 1}]}`
+	w = httptest.NewRecorder()
+	RefundTransaction(w, mux.SetURLVars(httptest.NewRequest(http.MethodPost, "/api/v1/transactions/INV-1/refund", bytes.NewBufferString(refund)), vars))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, w.Code, w.Body)
+	}
+	var txn models.Transaction
+	if err := json.NewDecoder(w.Body).Decode(&txn); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if txn.Type != services.TransactionRefund || txn.Result.Subtotal.String() != "-100.00" || !txn.Result.TotalTax.IsNegative() {
+		t.Errorf("Expected a refund of one item, got %+v", txn)
+	}
+
+	w = httptest.NewRecorder()
+	RefundTransaction(w, mux.SetURLVars(httptest.NewRequest(http.MethodPost, "/api/v1/transactions/INV-1/refund", bytes.NewBufferString(`{"items": [{"item_id": "item1", "quantity": 5}]}`)), vars))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	RefundTransaction(w, mux.SetURLVars(httptest.NewRequest(http.MethodPost, "/api/v1/transactions/INV-1/refund", nil), vars))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, w.Code, w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	VoidTransaction(w, mux.SetURLVars(httptest.NewRequest(http.MethodPost, "/api/v1/transactions/INV-1/void", nil), vars))
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status code %d, got %d", http.StatusConflict, w.Code)
+	}
+}
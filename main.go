@@ -1,20 +1,121 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 
 	"github.com/gorilla/mux"
 	"github.com/vijayraghavareddy/tax-calculation/handlers"
+	"github.com/vijayraghavareddy/tax-calculation/services"
 )
 
+// memoryStorage, given as a store's file, keeps that store in memory only
+const memoryStorage = ":memory:"
+
 func main() {
+	stream := flag.Bool("ndjson", false, "calculate the NDJSON tax requests on stdin, write NDJSON results to stdout and exit")
+	flag.Parse()
+
+	// Load a rate table from disk if one is configured, otherwise the bundled table is used
+	provider := services.DefaultRateProvider()
+	if path := os.Getenv("TAX_RATES_FILE"); path != "" {
+		var err error
+		provider, err = services.LoadRateTable(path)
+		if err != nil {
+			log.Fatalf("Failed to load rate table %s: %v", path, err)
+		}
+		log.Printf("Loaded rate table %s (version %s)", path, provider.Version())
+	}
+	// A boundary file refines local jurisdictions down to ZIP+4 ranges
+	if path := os.Getenv("TAX_BOUNDARY_FILE"); path != "" {
+		index, err := services.LoadBoundaryFile(path)
+		if err != nil {
+			log.Fatalf("Failed to load boundary file %s: %v", path, err)
+		}
+		if err := provider.UseBoundaries(index); err != nil {
+			log.Fatalf("Failed to load boundary file %s: %v", path, err)
+		}
+		log.Printf("Loaded boundary file %s", path)
+	}
+	service := services.NewTaxService(provider)
+	// Exemption certificates are saved to a file unless kept in memory
+	if path := storagePath("TAX_EXEMPTIONS_FILE", "exemptions.json"); path != "" {
+		store, err := services.OpenCertificateStore(path)
+		if err != nil {
+			log.Fatalf("Failed to open exemption certificate file %s: %v", path, err)
+		}
+		service.SetExemptionStore(store)
+		log.Printf("Using exemption certificate file %s", path)
+	}
+	// Sellers' nexus settings limit where tax is collected
+	if path := os.Getenv("TAX_NEXUS_FILE"); path != "" {
+		sellers, err := services.LoadNexusConfig(path)
+		if err != nil {
+			log.Fatalf("Failed to load nexus config %s: %v", path, err)
+		}
+		for _, seller := range sellers {
+			if err := service.NexusTracker().Configure(seller); err != nil {
+				log.Fatalf("Failed to load nexus config %s: %v", path, err)
+			}
+		}
+		log.Printf("Loaded nexus settings for %d sellers from %s", len(sellers), path)
+	}
+	handlers.SetTaxService(service)
+	// Batch requests are calculated a few at a time, one per CPU by default
+	if text := os.Getenv("TAX_BATCH_WORKERS"); text != "" {
+		workers, err := strconv.Atoi(text)
+		if err != nil || workers < 1 {
+			log.Fatalf("TAX_BATCH_WORKERS must be a positive whole number, got %q", text)
+		}
+		handlers.SetBatchParallelism(workers)
+	}
+	if *stream {
+		if err := handlers.StreamTax(os.Stdin, os.Stdout); err != nil {
+			log.Fatalf("Failed to stream tax calculations: %v", err)
+		}
+		return
+	}
+
+	// Transactions are saved to a log file unless kept in memory
+	if path := storagePath("TAX_LEDGER_FILE", "transactions.log"); path != "" {
+		repo, err := services.OpenTransactionFile(path)
+		if err != nil {
+			log.Fatalf("Failed to open transaction log %s: %v", path, err)
+		}
+		defer repo.Close()
+		ledger, err := services.NewLedger(service, repo)
+		if err != nil {
+			log.Fatalf("Failed to load transaction log %s: %v", path, err)
+		}
+		handlers.SetLedger(ledger)
+		log.Printf("Using transaction log %s", path)
+	}
+
 	router := mux.NewRouter()
 
 	// API routes
 	router.HandleFunc("/api/v1/calculate-tax", corsMiddleware(handlers.CalculateTax)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/v1/calculate-tax/batch", corsMiddleware(handlers.CalculateTaxBatch)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/v1/calculate-tax/stream", corsMiddleware(handlers.CalculateTaxStream)).Methods("POST", "OPTIONS")
 	router.HandleFunc("/api/v1/health", corsMiddleware(handlers.HealthCheck)).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/v1/transactions", corsMiddleware(handlers.CreateTransaction)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/v1/transactions/{code}", corsMiddleware(handlers.GetTransaction)).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/v1/transactions/{code}/commit", corsMiddleware(handlers.CommitTransaction)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/v1/transactions/{code}/adjust", corsMiddleware(handlers.AdjustTransaction)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/v1/transactions/{code}/void", corsMiddleware(handlers.VoidTransaction)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/v1/transactions/{code}/refund", corsMiddleware(handlers.RefundTransaction)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/v1/reports/liability", corsMiddleware(handlers.LiabilityReport)).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/v1/reports/returns/{state}", corsMiddleware(handlers.StateReturn)).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/v1/exemptions", corsMiddleware(handlers.ListExemptions)).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/v1/exemptions", corsMiddleware(handlers.CreateExemption)).Methods("POST")
+	router.HandleFunc("/api/v1/exemptions/expiring", corsMiddleware(handlers.ListExpiringExemptions)).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/v1/exemptions/{id}", corsMiddleware(handlers.GetExemption)).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/v1/exemptions/{id}", corsMiddleware(handlers.UpdateExemption)).Methods("PUT")
+	router.HandleFunc("/api/v1/exemptions/{id}", corsMiddleware(handlers.DeleteExemption)).Methods("DELETE")
+	router.HandleFunc("/api/v1/exemptions/{id}/validity", corsMiddleware(handlers.ValidateExemption)).Methods("GET", "OPTIONS")
 
 	// Serve static files
 	staticDir := "./static"
@@ -39,11 +140,24 @@ func main() {
 	}
 }
 
+// storagePath returns the file named by the environment variable, def when
+// it is unset, or "" when it is ":memory:" so the store is kept in memory
+func storagePath(name, def string) string {
+	path := os.Getenv(name)
+	switch path {
+	case "":
+		return def
+	case memoryStorage:
+		return ""
+	}
+	return path
+}
+
 // corsMiddleware adds CORS headers to responses
 func corsMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 
 		if r.Method == "OPTIONS" {
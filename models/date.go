@@ -0,0 +1,99 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DateLayout is the JSON and text form of a Date
+const DateLayout = "2006-01-02"
+
+// Date is a calendar day with no time of day or time zone, encoded in JSON as
+// "YYYY-MM-DD"
+type Date struct {
+	t time.Time // midnight UTC
+}
+
+// NewDate returns the given calendar day
+func NewDate(year int, month time.Month, day int) Date {
+	return Date{t: time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+// DateOf returns the calendar day of t in t's location
+func DateOf(t time.Time) Date {
+	return NewDate(t.Date())
+}
+
+// ParseDate parses a "YYYY-MM-DD" date
+func ParseDate(s string) (Date, error) {
+	t, err := time.Parse(DateLayout, s)
+	if err != nil {
+		return Date{}, fmt.Errorf("invalid date %q: expected YYYY-MM-DD", s)
+	}
+	return Date{t: t}, nil
+}
+
+// MustParseDate is like ParseDate but panics on error; intended for
+// constants and tests
+func MustParseDate(s string) Date {
+	d, err := ParseDate(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// IsZero reports whether the date is unset
+func (d Date) IsZero() bool {
+	return d.t.IsZero()
+}
+
+// Before reports whether d is an earlier day than o
+func (d Date) Before(o Date) bool {
+	return d.t.Before(o.t)
+}
+
+// After reports whether d is a later day than o
+func (d Date) After(o Date) bool {
+	return d.t.After(o.t)
+}
+
+// Year returns the date's calendar year
+func (d Date) Year() int {
+	return d.t.Year()
+}
+
+// Month returns the date's month of the year
+func (d Date) Month() time.Month {
+	return d.t.Month()
+}
+
+// AddDays returns the date n days after d, or before it when n is negative
+func (d Date) AddDays(n int) Date {
+	return Date{t: d.t.AddDate(0, 0, n)}
+}
+
+// String formats the date as "YYYY-MM-DD"
+func (d Date) String() string {
+	return d.t.Format(DateLayout)
+}
+
+// MarshalJSON encodes the date as a "YYYY-MM-DD" string
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON decodes a "YYYY-MM-DD" string
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("invalid date %s: expected a \"YYYY-MM-DD\" string", data)
+	}
+	parsed, err := ParseDate(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
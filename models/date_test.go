@@ -0,0 +1,59 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestDateJSON(t *testing.T) {
+	var req struct {
+		Date Date `json:"date"`
+	}
+	if err := json.Unmarshal([]byte(`{"date": "2024-02-29"}`), &req); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if req.Date != NewDate(2024, time.February, 29) {
+		t.Errorf("Expected 2024-02-29, got %s", req.Date)
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(data) != `{"date":"2024-02-29"}` {
+		t.Errorf("Expected date string, got %s", data)
+	}
+
+	for _, input := range []string{`{"date": "2023-02-29"}`, `{"date": "29/02/2024"}`, `{"date": 20240229}`} {
+		if err := json.Unmarshal([]byte(input), &req); err == nil {
+			t.Errorf("Expected error for %s", input)
+		}
+	}
+}
+
+func TestDateOf(t *testing.T) {
+	est := time.FixedZone("EST", -5*60*60)
+	if got := DateOf(time.Date(2024, time.March, 31, 23, 30, 0, 0, est)); got.String() != "2024-03-31" {
+		t.Errorf("Expected 2024-03-31, got %s", got)
+	}
+	if !MustParseDate("2024-03-31").Before(MustParseDate("2024-04-01")) {
+		t.Error("Expected 2024-03-31 to be before 2024-04-01")
+	}
+}
+
+func TestDateAddDays(t *testing.T) {
+	d := MustParseDate("2024-02-28")
+	if got := d.AddDays(1); got.String() != "2024-02-29" {
+		t.Errorf("Expected 2024-02-29, got %s", got)
+	}
+	if got := d.AddDays(2); got.String() != "2024-03-01" {
+		t.Errorf("Expected 2024-03-01, got %s", got)
+	}
+	if got := d.AddDays(-28); got.String() != "2024-01-31" {
+		t.Errorf("Expected 2024-01-31, got %s", got)
+	}
+	if got := d.AddDays(-59).Year(); got != 2023 {
+		t.Errorf("Expected 2023, got %d", got)
+	}
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // Address represents the customer's address for tax calculation
 type Address struct {
 	Street     string `json:"street"`
@@ -12,39 +14,378 @@ type Address struct {
 
 // Item represents a product or service to be taxed
 type Item struct {
-	ID          string  `json:"id"`
-	Name        string  `json:"name"`
-	Description string  `json:"description,omitempty"`
-	Price       float64 `json:"price"`
-	Quantity    int     `json:"quantity"`
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Price       Money      `json:"price"`
+	Quantity    int        `json:"quantity"`
+	TaxCategory string     `json:"tax_category,omitempty"` // e.g. "standard", "books", "groceries", "clothing"
+	TaxCode     string     `json:"tax_code,omitempty"`     // product classification, e.g. an HSN/SAC code
+	Discounts   []Discount `json:"discounts,omitempty"`    // taken off this line before tax
+	ShipFrom    *Address   `json:"ship_from,omitempty"`    // overrides the request's ship_from
+}
+
+// Discount is a coupon or promotion that reduces the taxable amount. Exactly
+// one of Amount (a fixed amount off the line or order) and Percent is set.
+type Discount struct {
+	Code        string `json:"code,omitempty"`
+	Description string `json:"description,omitempty"`
+	Amount      *Money `json:"amount,omitempty"`
+	Percent     *Rate  `json:"percent,omitempty"`
+}
+
+// AppliedDiscount is the part of a discount taken off one line
+type AppliedDiscount struct {
+	Code        string `json:"code,omitempty"`
+	Description string `json:"description,omitempty"`
+	Level       string `json:"level"` // "item" or "order"
+	Amount      Money  `json:"amount"`
 }
 
 // TaxRequest represents the incoming request for tax calculation
 type TaxRequest struct {
-	Address Address `json:"address"`
-	Items   []Item  `json:"items"`
+	Address     Address `json:"address"`
+	Items       []Item  `json:"items"`
+	Currency    string  `json:"currency,omitempty"`     // ISO 4217 code, defaults to the country's currency
+	SellerState string  `json:"seller_state,omitempty"` // seller's registered state, used for India GST
+	BuyerVATID  string  `json:"buyer_vat_id,omitempty"` // business buyer's VAT number, used for EU reverse charge
+	// TransactionDate selects the rates in force on that day, defaults to today
+	TransactionDate *Date `json:"transaction_date,omitempty"`
+	// PricesIncludeTax marks item prices as gross; tax is backed out of them
+	PricesIncludeTax bool `json:"prices_include_tax,omitempty"`
+	// Discounts are order-level discounts, prorated across items by subtotal
+	Discounts []Discount `json:"discounts,omitempty"`
+	// Charges are shipping, handling and other fees billed with the items
+	Charges []Charge `json:"charges,omitempty"`
+	// CustomerID selects the exemption certificates on file for the buyer
+	CustomerID string `json:"customer_id,omitempty"`
+	// Exemption is a certificate presented with this order only
+	Exemption *ExemptionCertificate `json:"exemption,omitempty"`
+	// CertificateID selects one stored certificate, which must be unexpired
+	CertificateID string `json:"certificate_id,omitempty"`
+	// ShipFrom is where the goods ship from, used by origin-sourcing states
+	ShipFrom *Address `json:"ship_from,omitempty"`
+	// SellerID selects the seller's nexus settings; sellers without settings
+	// collect in every state
+	SellerID string `json:"seller_id,omitempty"`
+}
+
+// ExemptionCertificate exempts a buyer such as a reseller, nonprofit or
+// government agency from tax in the listed jurisdictions of the country it was
+// issued in, US unless set. A state code covers the state and its local
+// jurisdictions; the country's own code covers the whole country.
+type ExemptionCertificate struct {
+	ID            string   `json:"id"` // certificate number
+	CustomerID    string   `json:"customer_id,omitempty"`
+	Reason        string   `json:"reason"`            // e.g. "resale", "nonprofit", "government"
+	Country       string   `json:"country,omitempty"` // ISO 3166-1 alpha-2, default "US"
+	Jurisdictions []string `json:"jurisdictions"`     // e.g. ["NY", "NJ"], or ["GB"] with country "GB"
+	ExpiresOn     *Date    `json:"expires_on,omitempty"`
+}
+
+// AppliedExemption records a certificate used on an order and the
+// jurisdictions whose tax it removed
+type AppliedExemption struct {
+	CertificateID string   `json:"certificate_id"`
+	CustomerID    string   `json:"customer_id,omitempty"`
+	Reason        string   `json:"reason"`
+	Jurisdictions []string `json:"jurisdictions"`
+}
+
+// CertificateValidity reports whether a certificate exempts sales in one
+// jurisdiction on one day, and why not when it does not
+type CertificateValidity struct {
+	CertificateID string `json:"certificate_id"`
+	Jurisdiction  string `json:"jurisdiction"`
+	Date          Date   `json:"date"`
+	Valid         bool   `json:"valid"`
+	Reason        string `json:"reason,omitempty"`
+}
+
+// Charge is a shipping, handling, gift wrap or other fee billed with an order
+type Charge struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"` // "shipping", "handling", "gift_wrap" or "fee"
+	Description string `json:"description,omitempty"`
+	Amount      Money  `json:"amount"`
+}
+
+// ChargeTaxDetail represents tax details for a single charge
+type ChargeTaxDetail struct {
+	ChargeID      string          `json:"charge_id"`
+	Type          string          `json:"type"`
+	Description   string          `json:"description,omitempty"`
+	Amount        Money           `json:"amount"`
+	Subtotal      Money           `json:"subtotal"`       // net of tax
+	TaxableAmount Money           `json:"taxable_amount"` // part of the subtotal that is taxed
+	TaxAmount     Money           `json:"tax_amount"`
+	TotalAmount   Money           `json:"total_amount"`
+	Taxes         []Tax           `json:"taxes"`
+	Taxability    *ItemTaxability `json:"taxability,omitempty"`
+	Sourcing      string          `json:"sourcing,omitempty"` // "destination", "origin" or "hybrid"
 }
 
 // ItemTaxDetail represents tax details for a single item
 type ItemTaxDetail struct {
-	ItemID      string  `json:"item_id"`
-	ItemName    string  `json:"item_name"`
-	Price       float64 `json:"price"`
-	Quantity    int     `json:"quantity"`
-	Subtotal    float64 `json:"subtotal"`
-	TaxRate     float64 `json:"tax_rate"`
-	TaxAmount   float64 `json:"tax_amount"`
-	TotalAmount float64 `json:"total_amount"`
+	ItemID         string            `json:"item_id"`
+	ItemName       string            `json:"item_name"`
+	TaxCategory    string            `json:"tax_category,omitempty"`
+	TaxCode        string            `json:"tax_code,omitempty"`
+	Price          Money             `json:"price"`
+	Quantity       int               `json:"quantity"`
+	OriginalAmount Money             `json:"original_amount"` // price x quantity
+	DiscountAmount Money             `json:"discount_amount"`
+	Discounts      []AppliedDiscount `json:"discounts,omitempty"`
+	Subtotal       Money             `json:"subtotal"` // taxable base: after discounts, net of tax
+	// TaxRate is the combined rate of all taxes on the line.
+	//
+	// Deprecated: Taxes lists each component tax and its rate. TaxRate is
+	// still filled in so existing clients keep working.
+	TaxRate     Rate            `json:"tax_rate"`
+	TaxAmount   Money           `json:"tax_amount"`
+	TotalAmount Money           `json:"total_amount"` // subtotal + tax; the gross price when prices include tax
+	Taxes       []Tax           `json:"taxes"`
+	Taxability  *ItemTaxability `json:"taxability,omitempty"` // product rule that exempted or reduced the line
+	Sourcing    string          `json:"sourcing,omitempty"`   // "destination", "origin" or "hybrid"
+}
+
+// ItemTaxability identifies the product taxability rule applied to a line
+type ItemTaxability struct {
+	Rule        string `json:"rule"`      // e.g. "NY-clothing-under-110"
+	Treatment   string `json:"treatment"` // "exempt", "reduced", "taxable" or "follows_goods"
+	Description string `json:"description,omitempty"`
+}
+
+// Tax is one component of the tax charged on a line, e.g. CGST or SGST
+type Tax struct {
+	Name          string `json:"name"`
+	Rate          Rate   `json:"rate"`
+	Jurisdiction  string `json:"jurisdiction,omitempty"` // code of the authority the tax is owed to
+	TaxableAmount Money  `json:"taxable_amount"`
+	TaxAmount     Money  `json:"tax_amount"`
 }
 
 // TaxResponse represents the response with calculated taxes
 type TaxResponse struct {
-	Address         Address         `json:"address"`
-	Items           []ItemTaxDetail `json:"items"`
-	Subtotal        float64         `json:"subtotal"`
-	TotalTax        float64         `json:"total_tax"`
-	GrandTotal      float64         `json:"grand_total"`
-	TaxJurisdiction string          `json:"tax_jurisdiction"`
+	Address       Address           `json:"address"`
+	Items         []ItemTaxDetail   `json:"items"`
+	Currency      string            `json:"currency"`
+	TotalDiscount Money             `json:"total_discount"`
+	Subtotal      Money             `json:"subtotal"`
+	Charges       []ChargeTaxDetail `json:"charges,omitempty"`
+	ChargeTotal   Money             `json:"charge_total"`
+	TotalTax      Money             `json:"total_tax"`
+	GrandTotal    Money             `json:"grand_total"`
+	// TaxJurisdiction summarises where the order was taxed, e.g. "NY, USA".
+	//
+	// Deprecated: Jurisdictions lists each taxing authority with its code,
+	// type, rate and tax. TaxJurisdiction is still filled in so existing
+	// clients keep working.
+	TaxJurisdiction  string             `json:"tax_jurisdiction"`
+	TransactionDate  Date               `json:"transaction_date"` // day whose rates were applied
+	PricesIncludeTax bool               `json:"prices_include_tax,omitempty"`
+	TaxBreakdown     []TaxBreakdown     `json:"tax_breakdown"`
+	Jurisdictions    []JurisdictionTax  `json:"jurisdictions"`
+	RateMatchLevel   string             `json:"rate_match_level,omitempty"` // e.g. "zip4", "zip5", "city", "state"
+	Exemptions       []AppliedExemption `json:"exemptions,omitempty"`
+	Nexus            *NexusStatus       `json:"nexus,omitempty"`
+	Notes            []string           `json:"notes,omitempty"` // legal wording to print on the invoice
+}
+
+// NexusStatus reports whether the seller must collect in the destination
+// state and the seller's sales there so far this calendar year
+type NexusStatus struct {
+	State        string `json:"state"`
+	HasNexus     bool   `json:"has_nexus"`
+	Basis        string `json:"basis,omitempty"`  // "physical", "registered" or "economic"
+	Reason       string `json:"reason,omitempty"` // "no_nexus" when tax was not collected
+	Sales        Money  `json:"sales"`
+	Transactions int    `json:"transactions"`
+}
+
+// TaxBreakdown summarises the tax collected at one named rate, e.g. the
+// UK VAT standard, reduced and zero rate bands
+type TaxBreakdown struct {
+	Name          string `json:"name"`
+	Rate          Rate   `json:"rate"`
+	TaxableAmount Money  `json:"taxable_amount"`
+	TaxAmount     Money  `json:"tax_amount"`
+}
+
+// Jurisdiction identifies a taxing authority such as a state, county, city
+// or special district
+type Jurisdiction struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// JurisdictionTax is the tax owed to one jurisdiction at one rate
+type JurisdictionTax struct {
+	Jurisdiction
+	Rate          Rate  `json:"rate"`
+	TaxableAmount Money `json:"taxable_amount"`
+	TaxAmount     Money `json:"tax_amount"`
+}
+
+// Transaction is a calculation saved under a document code so it can be
+// committed as a sale, adjusted and voided
+type Transaction struct {
+	Code        string                  `json:"code"`
+	Type        string                  `json:"type"`    // "sale" or "refund"
+	Status      string                  `json:"status"`  // "quoted", "committed", "voided" or "calculated"
+	Version     int                     `json:"version"` // 1, then one more per adjustment
+	Request     TaxRequest              `json:"request"`
+	Result      TaxResponse             `json:"result"`
+	CreatedAt   time.Time               `json:"created_at"`
+	UpdatedAt   time.Time               `json:"updated_at"`
+	CommittedAt *time.Time              `json:"committed_at,omitempty"`
+	VoidedAt    *time.Time              `json:"voided_at,omitempty"`
+	VoidReason  string                  `json:"void_reason,omitempty"`
+	Adjustments []TransactionAdjustment `json:"adjustments,omitempty"` // earlier versions, oldest first
+	// RefundOf is the sale a refund returns goods from
+	RefundOf     string `json:"refund_of,omitempty"`
+	RefundReason string `json:"refund_reason,omitempty"`
+	// Refunds are the codes of the refunds issued against a sale
+	Refunds []string `json:"refunds,omitempty"`
+	// Returned is the quantity of each item of a sale refunded so far
+	Returned map[string]int `json:"returned,omitempty"`
+	// RefundedCharges are the IDs of a sale's charges refunded so far
+	RefundedCharges []string `json:"refunded_charges,omitempty"`
+}
+
+// TransactionAdjustment is a version of a transaction replaced by an
+// adjustment, kept for the audit trail
+type TransactionAdjustment struct {
+	Version    int         `json:"version"`
+	Reason     string      `json:"reason"` // why it was replaced
+	AdjustedAt time.Time   `json:"adjusted_at"`
+	Request    TaxRequest  `json:"request"`
+	Result     TaxResponse `json:"result"`
+}
+
+// CreateTransactionRequest saves a calculation under a document code, as a
+// quote or as a committed sale
+type CreateTransactionRequest struct {
+	Code    string     `json:"code"`
+	Commit  bool       `json:"commit,omitempty"`
+	Request TaxRequest `json:"request"`
+}
+
+// AdjustTransactionRequest replaces a transaction's request with a corrected
+// one
+type AdjustTransactionRequest struct {
+	Reason  string     `json:"reason"`
+	Request TaxRequest `json:"request"`
+}
+
+// RefundRequest returns some or all of a committed sale. With no items and no
+// charges everything not yet refunded is returned.
+type RefundRequest struct {
+	Code    string       `json:"code,omitempty"` // refund's document code, defaults to "<sale>-R<n>"
+	Reason  string       `json:"reason,omitempty"`
+	Items   []RefundItem `json:"items,omitempty"`
+	Charges []string     `json:"charges,omitempty"` // IDs of charges refunded in full
+}
+
+// RefundItem is a quantity of one of a sale's items being returned
+type RefundItem struct {
+	ItemID   string `json:"item_id"`
+	Quantity int    `json:"quantity"`
+}
+
+// VoidTransactionRequest cancels a transaction
+type VoidTransactionRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// LiabilityReport totals the tax collected on committed transactions by
+// jurisdiction and filing period
+type LiabilityReport struct {
+	Period string         `json:"period"` // "month" or "quarter"
+	Source string         `json:"source"` // "calculated" or "committed"
+	From   *Date          `json:"from,omitempty"`
+	To     *Date          `json:"to,omitempty"`
+	Rows   []LiabilityRow `json:"rows"`
+}
+
+// LiabilityRow is one jurisdiction's sales and tax in one period. Exempt
+// sales are the sales in the jurisdiction it charged no tax on, and gross
+// sales are exempt plus taxable sales. Refunds count as negative sales in the
+// period they were issued in.
+type LiabilityRow struct {
+	Jurisdiction
+	Period       string `json:"period"` // e.g. "2025-06" or "2025-Q2"
+	PeriodStart  Date   `json:"period_start"`
+	PeriodEnd    Date   `json:"period_end"`
+	Currency     string `json:"currency"`
+	Transactions int    `json:"transactions"`
+	GrossSales   Money  `json:"gross_sales"`
+	ExemptSales  Money  `json:"exempt_sales"`
+	TaxableSales Money  `json:"taxable_sales"`
+	TaxCollected Money  `json:"tax_collected"`
+}
+
+// StateReturn holds the figures of a US state's sales tax return for one
+// filing period: for the state and each local jurisdiction, the gross sales,
+// the deductions by reason, the taxable sales and the tax due
+type StateReturn struct {
+	State       string           `json:"state"`
+	Period      string           `json:"period"` // e.g. "2025-06" or "2025-Q2"
+	Source      string           `json:"source"` // "calculated" or "committed"
+	PeriodStart Date             `json:"period_start"`
+	PeriodEnd   Date             `json:"period_end"`
+	Currency    string           `json:"currency"`
+	Locations   []ReturnLocation `json:"locations"` // the state first, then local jurisdictions by code
+}
+
+// ReturnLocation is one jurisdiction's part of a state return
+type ReturnLocation struct {
+	Jurisdiction
+	Transactions    int               `json:"transactions"`
+	GrossSales      Money             `json:"gross_sales"`
+	Deductions      []ReturnDeduction `json:"deductions"` // by reason
+	TotalDeductions Money             `json:"total_deductions"`
+	TaxableSales    Money             `json:"taxable_sales"` // gross sales less deductions
+	TaxDue          Money             `json:"tax_due"`
+}
+
+// ReturnDeduction is the sales deducted from gross sales for one reason, e.g.
+// "resale", "exempt_groceries", "nontaxable_shipping" or "returns"
+type ReturnDeduction struct {
+	Reason string `json:"reason"`
+	Amount Money  `json:"amount"`
+}
+
+// BatchTaxResponse holds the outcome of each request in a batch, in request
+// order
+type BatchTaxResponse struct {
+	Results   []BatchTaxResult `json:"results"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+}
+
+// BatchTaxResult is the response or the error for one request in a batch
+type BatchTaxResult struct {
+	Index  int            `json:"index"`
+	Result *TaxResponse   `json:"result,omitempty"`
+	Error  *ErrorResponse `json:"error,omitempty"`
+}
+
+// StreamTaxRequest is one line of an NDJSON stream of tax requests: a tax
+// request with an optional ID the caller uses to match up its result
+type StreamTaxRequest struct {
+	CorrelationID string `json:"correlation_id,omitempty"`
+	TaxRequest
+}
+
+// StreamTaxResult is one line of an NDJSON stream of results: the response
+// or the error for the request on input line Line
+type StreamTaxResult struct {
+	Line          int            `json:"line"`
+	CorrelationID string         `json:"correlation_id,omitempty"`
+	Result        *TaxResponse   `json:"result,omitempty"`
+	Error         *ErrorResponse `json:"error,omitempty"`
 }
 
 // ErrorResponse represents an error response
@@ -0,0 +1,357 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// MoneyScale is the number of decimal places held in a Money's minor units
+const MoneyScale = 2
+
+// MaxMoneyUnits is the largest absolute amount, in minor units, accepted as
+// input or produced by multiplication (10 trillion major units)
+const MaxMoneyUnits int64 = 1_000_000_000_000_000
+
+// Money is an exact monetary amount held as an integer number of minor units
+// (cents, pence, paise). It is encoded in JSON as a decimal string such as
+// "19.99"; decoding accepts either a decimal string or a JSON number, parsed
+// textually so no binary floating point is involved.
+type Money struct {
+	Units    int64  `json:"-"`
+	Currency string `json:"-"`
+}
+
+// NewMoney returns an amount of minor units in the given currency
+func NewMoney(units int64, currency string) Money {
+	return Money{Units: units, Currency: currency}
+}
+
+// ParseMoney parses a decimal string such as "19.99" or "-5" into Money
+func ParseMoney(s string) (Money, error) {
+	units, err := parseDecimal(s, MoneyScale)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount %q: %w", s, err)
+	}
+	if units > MaxMoneyUnits || units < -MaxMoneyUnits {
+		return Money{}, fmt.Errorf("invalid amount %q: exceeds maximum supported amount", s)
+	}
+	return Money{Units: units}, nil
+}
+
+// MustParseMoney is like ParseMoney but panics on error; intended for
+// constants and tests
+func MustParseMoney(s string) Money {
+	m, err := ParseMoney(s)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// In returns the amount tagged with the given currency
+func (m Money) In(currency string) Money {
+	m.Currency = currency
+	return m
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.Units == 0
+}
+
+// IsNegative reports whether the amount is below zero
+func (m Money) IsNegative() bool {
+	return m.Units < 0
+}
+
+// Add returns m + o, keeping m's currency (or o's if m has none)
+func (m Money) Add(o Money) Money {
+	return Money{Units: m.Units + o.Units, Currency: pickCurrency(m, o)}
+}
+
+// Sub returns m - o, keeping m's currency (or o's if m has none)
+func (m Money) Sub(o Money) Money {
+	return Money{Units: m.Units - o.Units, Currency: pickCurrency(m, o)}
+}
+
+// Neg returns -m
+func (m Money) Neg() Money {
+	return Money{Units: -m.Units, Currency: m.Currency}
+}
+
+// MulQuantity returns m * quantity, failing if the result exceeds MaxMoneyUnits
+func (m Money) MulQuantity(quantity int) (Money, error) {
+	product := new(big.Int).Mul(big.NewInt(m.Units), big.NewInt(int64(quantity)))
+	if product.CmpAbs(big.NewInt(MaxMoneyUnits)) > 0 {
+		return Money{}, fmt.Errorf("amount %s x %d exceeds maximum supported amount", m, quantity)
+	}
+	return Money{Units: product.Int64(), Currency: m.Currency}, nil
+}
+
+// MulRate returns m * rate rounded to the nearest minor unit, with halves
+// rounded away from zero
+func (m Money) MulRate(rate Rate) Money {
+	product := new(big.Int).Mul(big.NewInt(m.Units), big.NewInt(int64(rate)))
+	return Money{Units: divRound(product, big.NewInt(RateScale)), Currency: m.Currency}
+}
+
+// TaxIncluded returns the tax contained in a gross amount charged at rate,
+// gross - gross / (1 + rate), rounded so that the net amount m - tax plus the
+// tax is exactly m
+func (m Money) TaxIncluded(rate Rate) Money {
+	product := new(big.Int).Mul(big.NewInt(m.Units), big.NewInt(RateScale))
+	net := divRound(product, big.NewInt(RateScale+int64(rate)))
+	return Money{Units: m.Units - net, Currency: m.Currency}
+}
+
+// Prorate returns m * part / whole rounded to the nearest minor unit, with
+// halves rounded away from zero. whole must be positive.
+func (m Money) Prorate(part, whole int64) Money {
+	product := new(big.Int).Mul(big.NewInt(m.Units), big.NewInt(part))
+	return Money{Units: divRound(product, big.NewInt(whole)), Currency: m.Currency}
+}
+
+// Allocate splits m into parts proportional to weights using the largest
+// remainder method, so the parts always add up to m exactly. Parts for zero
+// weights are zero; if every weight is zero the whole amount goes to the
+// first part.
+func (m Money) Allocate(weights []int64) []Money {
+	parts := make([]Money, len(weights))
+	for i := range parts {
+		parts[i] = Money{Currency: m.Currency}
+	}
+	if len(weights) == 0 {
+		return parts
+	}
+
+	var total int64
+	for _, w := range weights {
+		total += w
+	}
+	if total <= 0 {
+		parts[0].Units = m.Units
+		return parts
+	}
+
+	units := m.Units
+	if units < 0 {
+		units = -units
+	}
+	remainders := make([]*big.Int, len(weights))
+	allocated := int64(0)
+	for i, w := range weights {
+		q, r := new(big.Int).QuoRem(
+			new(big.Int).Mul(big.NewInt(units), big.NewInt(w)), big.NewInt(total), new(big.Int))
+		parts[i].Units = q.Int64()
+		remainders[i] = r
+		allocated += parts[i].Units
+	}
+	// Hand the units lost to truncation to the largest remainders, earliest first
+	for left := units - allocated; left > 0; left-- {
+		best := 0
+		for i := range remainders {
+			if remainders[i].Cmp(remainders[best]) > 0 {
+				best = i
+			}
+		}
+		parts[best].Units++
+		remainders[best].SetInt64(-1)
+	}
+
+	if m.Units < 0 {
+		for i := range parts {
+			parts[i].Units = -parts[i].Units
+		}
+	}
+	return parts
+}
+
+// String formats the amount as a plain decimal string, e.g. "-12.50"
+func (m Money) String() string {
+	return formatDecimal(m.Units, MoneyScale, MoneyScale)
+}
+
+// MarshalJSON encodes the amount as a decimal string
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// UnmarshalJSON accepts a decimal string or a JSON number
+func (m *Money) UnmarshalJSON(data []byte) error {
+	text, err := decimalText(data)
+	if err != nil {
+		return err
+	}
+	parsed, err := ParseMoney(text)
+	if err != nil {
+		return err
+	}
+	m.Units = parsed.Units
+	return nil
+}
+
+// RateScale is the number of Rate units in a whole (100%), so a Rate holds
+// millionths: 8.875% is Rate(88750)
+const RateScale = 1_000_000
+
+// rateDecimalPlaces is the precision of a Rate expressed as a percentage
+const rateDecimalPlaces = 4
+
+// Rate is an exact tax rate held in millionths of the taxable amount. It is
+// encoded in JSON as a percentage decimal string such as "8.875"; decoding
+// accepts a percentage string or JSON number.
+type Rate int64
+
+// ParseRate parses a percentage such as "8.875" into a Rate
+func ParseRate(percent string) (Rate, error) {
+	units, err := parseDecimal(percent, rateDecimalPlaces)
+	if err != nil {
+		return 0, fmt.Errorf("invalid rate %q: %w", percent, err)
+	}
+	return Rate(units), nil
+}
+
+// MustParseRate is like ParseRate but panics on error; intended for
+// constants and tests
+func MustParseRate(percent string) Rate {
+	r, err := ParseRate(percent)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// String formats the rate as a percentage with at least two decimal places
+func (r Rate) String() string {
+	return formatDecimal(int64(r), rateDecimalPlaces, 2)
+}
+
+// MarshalJSON encodes the rate as a percentage decimal string
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
+// UnmarshalJSON accepts a percentage decimal string or a JSON number
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	text, err := decimalText(data)
+	if err != nil {
+		return err
+	}
+	parsed, err := ParseRate(text)
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
+
+// decimalText extracts the literal text of a JSON string or number
+func decimalText(data []byte) (string, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return "", err
+		}
+		return s, nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return "", fmt.Errorf("expected decimal string or number, got %s", data)
+	}
+	return n.String(), nil
+}
+
+// parseDecimal parses a plain decimal string into an integer scaled by
+// 10^scale. Digits beyond the scale are only allowed if they are zeros.
+func parseDecimal(s string, scale int) (int64, error) {
+	s = strings.TrimSpace(s)
+	negative := false
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		negative = s[0] == '-'
+		s = s[1:]
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return 0, fmt.Errorf("empty number")
+	}
+	if !isDigits(whole) || !isDigits(frac) {
+		return 0, fmt.Errorf("not a plain decimal number")
+	}
+	if len(frac) > scale {
+		if strings.Trim(frac[scale:], "0") != "" {
+			return 0, fmt.Errorf("more than %d decimal places", scale)
+		}
+		frac = frac[:scale]
+	}
+	frac += strings.Repeat("0", scale-len(frac))
+
+	value, ok := new(big.Int).SetString(whole+frac, 10)
+	if !ok {
+		return 0, fmt.Errorf("not a plain decimal number")
+	}
+	if !value.IsInt64() {
+		return 0, fmt.Errorf("out of range")
+	}
+	if negative {
+		return -value.Int64(), nil
+	}
+	return value.Int64(), nil
+}
+
+// formatDecimal formats an integer scaled by 10^scale, trimming trailing
+// zeros but keeping at least minPlaces decimal places
+func formatDecimal(value int64, scale, minPlaces int) string {
+	sign := ""
+	abs := new(big.Int).Abs(big.NewInt(value))
+	if value < 0 {
+		sign = "-"
+	}
+
+	digits := abs.String()
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+	whole, frac := digits[:len(digits)-scale], digits[len(digits)-scale:]
+	for len(frac) > minPlaces && strings.HasSuffix(frac, "0") {
+		frac = frac[:len(frac)-1]
+	}
+	if frac == "" {
+		return sign + whole
+	}
+	return sign + whole + "." + frac
+}
+
+// divRound divides n by d rounding halves away from zero; the result must
+// fit in an int64
+func divRound(n, d *big.Int) int64 {
+	q, r := new(big.Int).QuoRem(n, d, new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(r), big.NewInt(2)).Cmp(new(big.Int).Abs(d)) >= 0 {
+		if n.Sign()*d.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q.Int64()
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func pickCurrency(m, o Money) string {
+	if m.Currency != "" {
+		return m.Currency
+	}
+	return o.Currency
+}
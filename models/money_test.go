@@ -0,0 +1,158 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"19.99", 1999},
+		{"100", 10000},
+		{"100.0", 10000},
+		{"1.500", 150},
+		{"-5.05", -505},
+		{".5", 50},
+	}
+
+	for _, tt := range tests {
+		m, err := ParseMoney(tt.input)
+		if err != nil {
+			t.Errorf("ParseMoney(%q) unexpected error: %v", tt.input, err)
+			continue
+		}
+		if m.Units != tt.expected {
+			t.Errorf("ParseMoney(%q) = %d, expected %d", tt.input, m.Units, tt.expected)
+		}
+	}
+
+	for _, bad := range []string{"", "abc", "0.145", "1e3", "1.2.3", "99999999999999999999"} {
+		if _, err := ParseMoney(bad); err == nil {
+			t.Errorf("ParseMoney(%q) expected error", bad)
+		}
+	}
+}
+
+func TestMoneyMulRate(t *testing.T) {
+	tests := []struct {
+		amount   string
+		rate     string
+		expected string
+	}{
+		{"1.45", "10", "0.15"},
+		{"100.00", "8.52", "8.52"},
+		{"10.00", "8.875", "0.89"},
+		{"-1.45", "10", "-0.15"},
+		{"9999999999999.99", "25", "2500000000000.00"},
+	}
+
+	for _, tt := range tests {
+		result := MustParseMoney(tt.amount).MulRate(MustParseRate(tt.rate))
+		if result.String() != tt.expected {
+			t.Errorf("%s x %s%% = %s, expected %s", tt.amount, tt.rate, result, tt.expected)
+		}
+	}
+}
+
+func TestMoneyTaxIncluded(t *testing.T) {
+	tests := []struct {
+		gross    string
+		rate     string
+		expected string
+	}{
+		{"120.00", "20", "20.00"},
+		{"10.00", "20", "1.67"},
+		{"19.99", "19", "3.19"},
+		{"0.01", "20", "0.00"},
+		{"100.00", "0", "0.00"},
+		{"-10.00", "20", "-1.67"},
+	}
+
+	for _, tt := range tests {
+		result := MustParseMoney(tt.gross).TaxIncluded(MustParseRate(tt.rate))
+		if result.String() != tt.expected {
+			t.Errorf("tax in %s at %s%% = %s, expected %s", tt.gross, tt.rate, result, tt.expected)
+		}
+	}
+}
+
+func TestMoneyAllocate(t *testing.T) {
+	tests := []struct {
+		amount   string
+		weights  []int64
+		expected []string
+	}{
+		{"10.00", []int64{1, 1, 1}, []string{"3.34", "3.33", "3.33"}},
+		{"1.00", []int64{50000, 50000}, []string{"0.50", "0.50"}},
+		{"0.05", []int64{62500, 18750, 18750}, []string{"0.03", "0.01", "0.01"}},
+		{"-10.00", []int64{1, 2}, []string{"-3.33", "-6.67"}},
+		{"5.00", []int64{0, 3}, []string{"0.00", "5.00"}},
+		{"5.00", []int64{0, 0}, []string{"5.00", "0.00"}},
+	}
+
+	for _, tt := range tests {
+		parts := MustParseMoney(tt.amount).Allocate(tt.weights)
+		var got []string
+		for _, part := range parts {
+			got = append(got, part.String())
+		}
+		if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+			t.Errorf("allocate %s by %v = %v, expected %v", tt.amount, tt.weights, got, tt.expected)
+		}
+	}
+}
+
+func TestMoneyProrate(t *testing.T) {
+	tests := []struct {
+		amount      string
+		part, whole int64
+		expected    string
+	}{
+		{"10.00", 1, 3, "3.33"},
+		{"10.00", 2, 3, "6.67"},
+		{"10.00", 3, 3, "10.00"},
+		{"0.05", 1, 2, "0.03"},
+		{"-0.05", 1, 2, "-0.03"},
+		{"7.00", 0, 4, "0.00"},
+	}
+
+	for _, tt := range tests {
+		if got := MustParseMoney(tt.amount).Prorate(tt.part, tt.whole).String(); got != tt.expected {
+			t.Errorf("prorate %s by %d/%d = %s, expected %s", tt.amount, tt.part, tt.whole, got, tt.expected)
+		}
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	var item struct {
+		Price Money `json:"price"`
+		Rate  Rate  `json:"rate"`
+	}
+
+	if err := json.Unmarshal([]byte(`{"price": 0.1, "rate": 8.875}`), &item); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if item.Price.Units != 10 || item.Rate != 88750 {
+		t.Errorf("Unexpected decode result %+v", item)
+	}
+
+	if err := json.Unmarshal([]byte(`{"price": "25000.00", "rate": "20"}`), &item); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	out, err := json.Marshal(item)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(out) != `{"price":"25000.00","rate":"20.00"}` {
+		t.Errorf("Unexpected encoding %s", out)
+	}
+
+	if err := json.Unmarshal([]byte(`{"price": true}`), &item); err == nil {
+		t.Error("Expected error decoding non-numeric price")
+	}
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"sync"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+// CalculateTaxBatch calculates each request on its own, at most parallelism
+// at a time, and returns the responses and errors in request order. A failed
+// request leaves its response nil and does not stop the others.
+func (s *TaxService) CalculateTaxBatch(reqs []models.TaxRequest, parallelism int) ([]*models.TaxResponse, []error) {
+	responses := make([]*models.TaxResponse, len(reqs))
+	errs := make([]error, len(reqs))
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	if parallelism > len(reqs) {
+		parallelism = len(reqs)
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < parallelism; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				responses[i], errs[i] = s.CalculateTax(&reqs[i])
+			}
+		}()
+	}
+	for i := range reqs {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return responses, errs
+}
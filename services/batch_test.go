@@ -0,0 +1,41 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+func TestCalculateTaxBatch(t *testing.T) {
+	service := NewTaxService(DefaultRateProvider())
+	var reqs []models.TaxRequest
+	for i := 0; i < 50; i++ {
+		req := *newLedgerRequest("100.00")
+		if i%10 == 3 {
+			req.Address.Country = ""
+		}
+		reqs = append(reqs, req)
+	}
+
+	for _, parallelism := range []int{0, 1, 4, 100} {
+		responses, errs := service.CalculateTaxBatch(reqs, parallelism)
+		if len(responses) != len(reqs) || len(errs) != len(reqs) {
+			t.Fatalf("parallelism %d: expected %d results, got %d and %d", parallelism, len(reqs), len(responses), len(errs))
+		}
+		for i := range reqs {
+			if i%10 == 3 {
+				if errs[i] == nil || responses[i] != nil {
+					t.Errorf("parallelism %d: expected request %d to fail, got %v", parallelism, i, responses[i])
+				}
+				continue
+			}
+			if errs[i] != nil || responses[i].TotalTax.String() != "8.25" {
+				t.Errorf("parallelism %d: request %d: got %v, %v", parallelism, i, responses[i], errs[i])
+			}
+		}
+	}
+
+	if responses, errs := service.CalculateTaxBatch(nil, 4); len(responses) != 0 || len(errs) != 0 {
+		t.Errorf("expected no results for an empty batch, got %v %v", responses, errs)
+	}
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Match levels reported for a US rate lookup, most specific first
+const (
+	MatchZip4    = "zip4"    // ZIP+4 range in the boundary file
+	MatchZip5    = "zip5"    // whole ZIP code
+	MatchCity    = "city"    // city name in the rate table
+	MatchState   = "state"   // no local match, state rate plus average local estimate
+	MatchDefault = "default" // state not in the rate table
+)
+
+// matchRank ranks match levels from 1 for MatchZip4, the most specific, up
+// to MatchDefault; an empty level ranks 0
+func matchRank(level string) int {
+	for i, l := range []string{MatchZip4, MatchZip5, MatchCity, MatchState, MatchDefault} {
+		if l == level {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// boundaryColumns is the required header of a boundary file
+var boundaryColumns = []string{"state", "zip5", "zip4_low", "zip4_high", "jurisdictions"}
+
+// BoundaryIndex maps ZIP codes and ZIP+4 ranges to the local jurisdictions
+// that cover them. It is built once from a boundary CSV and is safe for
+// concurrent lookups.
+type BoundaryIndex struct {
+	zips map[string]*zipBoundaries
+}
+
+// zipBoundaries holds every boundary record for one ZIP5
+type zipBoundaries struct {
+	state  string
+	whole  []string       // jurisdictions covering the whole ZIP, if listed
+	ranges []zip4Boundary // narrowest range first
+}
+
+// zip4Boundary is a ZIP+4 add-on range and the jurisdictions covering it
+type zip4Boundary struct {
+	low, high     int
+	jurisdictions []string
+}
+
+// ParseBoundaryCSV reads a boundary file with the columns
+//
+//	state,zip5,zip4_low,zip4_high,jurisdictions
+//
+// where jurisdictions is a ';'-separated list of local jurisdiction codes from
+// the rate table. Rows with empty zip4_low and zip4_high cover the whole ZIP.
+func ParseBoundaryCSV(r io.Reader) (*BoundaryIndex, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid boundary file: %w", err)
+	}
+	if len(header) != len(boundaryColumns) {
+		return nil, fmt.Errorf("invalid boundary file: expected columns %s", strings.Join(boundaryColumns, ","))
+	}
+	for i, column := range boundaryColumns {
+		if strings.ToLower(strings.TrimSpace(header[i])) != column {
+			return nil, fmt.Errorf("invalid boundary file: expected columns %s", strings.Join(boundaryColumns, ","))
+		}
+	}
+
+	index := &BoundaryIndex{zips: make(map[string]*zipBoundaries)}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid boundary file: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		if err := index.add(record); err != nil {
+			return nil, fmt.Errorf("invalid boundary file line %d: %w", line, err)
+		}
+	}
+
+	for _, zip := range index.zips {
+		sort.SliceStable(zip.ranges, func(i, j int) bool {
+			return zip.ranges[i].high-zip.ranges[i].low < zip.ranges[j].high-zip.ranges[j].low
+		})
+	}
+	return index, nil
+}
+
+// LoadBoundaryFile loads a boundary index from the CSV file at path
+func LoadBoundaryFile(path string) (*BoundaryIndex, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseBoundaryCSV(f)
+}
+
+// add indexes one boundary record
+func (b *BoundaryIndex) add(record []string) error {
+	state := strings.ToUpper(strings.TrimSpace(record[0]))
+	zip5 := strings.TrimSpace(record[1])
+	if state == "" {
+		return fmt.Errorf("state is required")
+	}
+	if len(zip5) != 5 || !isNumeric(zip5) {
+		return fmt.Errorf("zip5 %q is not a five digit ZIP code", zip5)
+	}
+
+	var codes []string
+	for _, code := range strings.Split(record[4], ";") {
+		if code = strings.TrimSpace(code); code != "" {
+			codes = append(codes, code)
+		}
+	}
+
+	zip, ok := b.zips[zip5]
+	if !ok {
+		zip = &zipBoundaries{state: state}
+		b.zips[zip5] = zip
+	}
+	if zip.state != state {
+		return fmt.Errorf("zip %s is listed in both %s and %s", zip5, zip.state, state)
+	}
+
+	lowText, highText := strings.TrimSpace(record[2]), strings.TrimSpace(record[3])
+	if lowText == "" && highText == "" {
+		if zip.whole != nil {
+			return fmt.Errorf("zip %s has more than one whole-ZIP record", zip5)
+		}
+		zip.whole = append([]string{}, codes...)
+		return nil
+	}
+
+	low, err := parseZip4(lowText)
+	if err != nil {
+		return err
+	}
+	high, err := parseZip4(highText)
+	if err != nil {
+		return err
+	}
+	if low > high {
+		return fmt.Errorf("zip4 range %s-%s is reversed", lowText, highText)
+	}
+	zip.ranges = append(zip.ranges, zip4Boundary{low: low, high: high, jurisdictions: codes})
+	return nil
+}
+
+// Lookup returns the jurisdiction codes for a ZIP or ZIP+4 code in the state
+// and the level they were matched at. The narrowest ZIP+4 range containing
+// the add-on wins; otherwise the whole-ZIP record is used. ok is false when
+// the index has nothing for the ZIP.
+func (b *BoundaryIndex) Lookup(state, zipCode string) (codes []string, level string, ok bool) {
+	zip5, zip4 := splitZip(zipCode)
+	zip, found := b.zips[zip5]
+	if !found || zip.state != strings.ToUpper(strings.TrimSpace(state)) {
+		return nil, "", false
+	}
+
+	if addOn, err := parseZip4(zip4); err == nil {
+		for _, r := range zip.ranges {
+			if addOn >= r.low && addOn <= r.high {
+				return r.jurisdictions, MatchZip4, true
+			}
+		}
+	}
+	if zip.whole != nil {
+		return zip.whole, MatchZip5, true
+	}
+	return nil, "", false
+}
+
+// jurisdictionCodes returns every jurisdiction code referenced by the index
+func (b *BoundaryIndex) jurisdictionCodes() map[string]bool {
+	codes := make(map[string]bool)
+	for _, zip := range b.zips {
+		for _, code := range zip.whole {
+			codes[code] = true
+		}
+		for _, r := range zip.ranges {
+			for _, code := range r.jurisdictions {
+				codes[code] = true
+			}
+		}
+	}
+	return codes
+}
+
+// splitZip splits "12345-6789" or "123456789" into ZIP5 and add-on
+func splitZip(zipCode string) (string, string) {
+	zipCode = strings.ReplaceAll(strings.TrimSpace(zipCode), "-", "")
+	if len(zipCode) <= 5 {
+		return zipCode, ""
+	}
+	return zipCode[:5], zipCode[5:]
+}
+
+// parseZip4 parses a four digit ZIP+4 add-on
+func parseZip4(text string) (int, error) {
+	if len(text) != 4 || !isNumeric(text) {
+		return 0, fmt.Errorf("zip4 %q is not a four digit add-on", text)
+	}
+	return strconv.Atoi(text)
+}
+
+func isNumeric(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
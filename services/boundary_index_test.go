@@ -0,0 +1,101 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+const testBoundaries = `state,zip5,zip4_low,zip4_high,jurisdictions
+CO,80202,,,CO-DEN;CO-RTD;CO-SCFD
+CO,80202,1000,1999,CO-RTD
+CO,80202,1500,1599,CO-SCFD
+NY,12345,0001,0999,NYC;NY-MCTD
+`
+
+func TestBoundaryIndex_Lookup(t *testing.T) {
+	index, err := ParseBoundaryCSV(strings.NewReader(testBoundaries))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		state string
+		zip   string
+		codes string
+		level string
+		ok    bool
+	}{
+		{"CO", "80202", "CO-DEN;CO-RTD;CO-SCFD", MatchZip5, true},
+		{"CO", "80202-1234", "CO-RTD", MatchZip4, true},
+		{"CO", "802021550", "CO-SCFD", MatchZip4, true},
+		{"CO", "80202-2000", "CO-DEN;CO-RTD;CO-SCFD", MatchZip5, true},
+		{"NY", "12345-0500", "NYC;NY-MCTD", MatchZip4, true},
+		{"NY", "12345-5000", "", "", false},
+		{"NY", "80202", "", "", false},
+		{"CO", "80203", "", "", false},
+	}
+
+	for _, tt := range tests {
+		codes, level, ok := index.Lookup(tt.state, tt.zip)
+		if ok != tt.ok || level != tt.level || strings.Join(codes, ";") != tt.codes {
+			t.Errorf("Lookup(%s, %s) = %v, %q, %v; expected %s, %q, %v",
+				tt.state, tt.zip, codes, level, ok, tt.codes, tt.level, tt.ok)
+		}
+	}
+}
+
+func TestParseBoundaryCSV_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+	}{
+		{"wrong header", "zip,state\n"},
+		{"bad zip5", "state,zip5,zip4_low,zip4_high,jurisdictions\nCO,8020,,,CO-DEN\n"},
+		{"reversed range", "state,zip5,zip4_low,zip4_high,jurisdictions\nCO,80202,1999,1000,CO-DEN\n"},
+		{"zip in two states", "state,zip5,zip4_low,zip4_high,jurisdictions\nCO,80202,,,CO-DEN\nNY,80202,,,NYC\n"},
+	}
+
+	for _, tt := range tests {
+		if _, err := ParseBoundaryCSV(strings.NewReader(tt.csv)); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func TestTableRateProvider_UseBoundaries(t *testing.T) {
+	index, err := ParseBoundaryCSV(strings.NewReader(testBoundaries))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	provider := DefaultRateProvider()
+	if err := provider.UseBoundaries(index); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	lookup, err := provider.JurisdictionsFor(&models.Address{State: "CO", ZipCode: "80202-1234"}, testDate)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if lookup.MatchLevel != MatchZip4 || len(lookup.Layers) != 2 || lookup.Layers[1].Jurisdiction.Code != "CO-RTD" {
+		t.Errorf("Expected CO and CO-RTD at zip4, got %+v", lookup)
+	}
+
+	// ZIPs missing from the boundary file still resolve from the rate table
+	lookup, err = provider.JurisdictionsFor(&models.Address{State: "IL", ZipCode: "60601"}, testDate)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if lookup.MatchLevel != MatchZip5 || combinedRate(lookup).String() != "10.25" {
+		t.Errorf("Expected Chicago rate at zip5, got %s at %s", combinedRate(lookup), lookup.MatchLevel)
+	}
+
+	unknown, err := ParseBoundaryCSV(strings.NewReader("state,zip5,zip4_low,zip4_high,jurisdictions\nCO,80202,,,CO-NOPE\n"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := DefaultRateProvider().UseBoundaries(unknown); err == nil {
+		t.Error("Expected an error for a jurisdiction missing from the rate table")
+	}
+}
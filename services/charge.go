@@ -0,0 +1,135 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+// chargeTypes are the charge types a request may bill
+var chargeTypes = map[string]bool{
+	"shipping":  true,
+	"handling":  true,
+	"gift_wrap": true,
+	"fee":       true,
+}
+
+// followsGoods is reported for charges taxed like the goods they ship with
+// when the engine has no rule of its own
+var followsGoods = models.ItemTaxability{
+	Rule:        "follows-goods",
+	Treatment:   TreatmentFollowsGoods,
+	Description: "Taxed in proportion to the taxable goods on the order",
+}
+
+// chargedGoods is an item's taxable base and rates, used to tax the charges
+// that follow the goods
+type chargedGoods struct {
+	amount models.Money
+	rates  []AppliedRate
+}
+
+// taxCharge taxes one charge. A charge that follows the goods is split across
+// the items in proportion to their taxable bases and each share is taxed at
+// its item's rates, so shipping on exempt goods is exempt and shipping on a
+// mixed order is taxed on the taxable share only. Otherwise the engine taxes
+// the charge as a product of the charge's type and filter removes the rates
+// the buyer does not pay.
+func taxCharge(engine TaxEngine, req *models.TaxRequest, charge *models.Charge,
+	currency string, goods []chargedGoods, filter rateFilter, summary *taxSummary) (*models.ChargeTaxDetail, error) {
+	taxability := &models.ItemTaxability{}
+	*taxability = followsGoods
+	if taxer, ok := engine.(chargeTaxer); ok {
+		rule, err := taxer.ChargeTaxability(req, charge)
+		if err != nil {
+			return nil, err
+		}
+		if rule != nil {
+			taxability = rule
+		}
+	}
+
+	amount := charge.Amount.In(currency)
+	var shares []chargedGoods
+	var sourcing string
+	if taxability.Treatment == TreatmentFollowsGoods {
+		weights := make([]int64, len(goods))
+		for i, item := range goods {
+			weights[i] = item.amount.Units
+		}
+		for i, share := range amount.Allocate(weights) {
+			shares = append(shares, chargedGoods{amount: share, rates: goods[i].rates})
+		}
+	} else {
+		item := &models.Item{
+			ID:          charge.ID,
+			Name:        charge.Description,
+			Price:       amount,
+			Quantity:    1,
+			TaxCategory: charge.Type,
+		}
+		rates, err := engine.TaxRates(req, item)
+		if err != nil {
+			return nil, err
+		}
+		if reporter, ok := engine.(sourcingReporter); ok {
+			sourcing = reporter.Sourcing(req, item)
+		}
+		if rates, err = filter(rates); err != nil {
+			return nil, err
+		}
+		shares = []chargedGoods{{amount: amount, rates: rates}}
+	}
+
+	detail := &models.ChargeTaxDetail{
+		ChargeID:      charge.ID,
+		Type:          charge.Type,
+		Description:   charge.Description,
+		Amount:        amount,
+		Subtotal:      models.NewMoney(0, currency),
+		TaxableAmount: models.NewMoney(0, currency),
+		TaxAmount:     models.NewMoney(0, currency),
+		Taxes:         []models.Tax{},
+		Taxability:    taxability,
+		Sourcing:      sourcing,
+	}
+	taxIndex := make(map[AppliedRate]int)
+	for _, share := range shares {
+		net, amounts := taxAmounts(share.amount, share.rates, req.PricesIncludeTax)
+		detail.Subtotal = detail.Subtotal.Add(net)
+		if len(share.rates) > 0 {
+			detail.TaxableAmount = detail.TaxableAmount.Add(net)
+		}
+		for j, applied := range share.rates {
+			i, ok := taxIndex[applied]
+			if !ok {
+				i = len(detail.Taxes)
+				taxIndex[applied] = i
+				detail.Taxes = append(detail.Taxes, models.Tax{
+					Name:          applied.Name,
+					Rate:          applied.Rate,
+					Jurisdiction:  applied.Jurisdiction.Code,
+					TaxableAmount: models.NewMoney(0, currency),
+					TaxAmount:     models.NewMoney(0, currency),
+				})
+			}
+			detail.Taxes[i].TaxableAmount = detail.Taxes[i].TaxableAmount.Add(net)
+			detail.Taxes[i].TaxAmount = detail.Taxes[i].TaxAmount.Add(amounts[j])
+			detail.TaxAmount = detail.TaxAmount.Add(amounts[j])
+			summary.add(applied, net, amounts[j])
+		}
+	}
+	detail.TotalAmount = detail.Subtotal.Add(detail.TaxAmount)
+	return detail, nil
+}
+
+// validateCharge checks a charge has a known type and a non-negative amount
+func validateCharge(charge models.Charge) error {
+	if !chargeTypes[charge.Type] {
+		return fmt.Errorf("type %q is not one of shipping, handling, gift_wrap or fee", charge.Type)
+	}
+	if charge.Amount.IsNegative() {
+		return fmt.Errorf("amount must not be negative")
+	}
+	return nil
+}
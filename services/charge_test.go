@@ -0,0 +1,130 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+func TestCalculateTax_Charges(t *testing.T) {
+	service := NewTaxService(DefaultRateProvider())
+
+	newYork := models.Address{Country: "US", State: "NY", ZipCode: "10001"}
+	mixed := []models.Item{
+		{ID: "shirt", Price: models.MustParseMoney("50.00"), Quantity: 1, TaxCategory: "clothing"},
+		{ID: "lamp", Price: models.MustParseMoney("50.00"), Quantity: 1},
+	}
+
+	tests := []struct {
+		name     string
+		address  models.Address
+		items    []models.Item
+		charge   models.Charge
+		taxable  string
+		tax      string
+		rule     string
+		orderTax string
+	}{
+		{
+			name:     "NY shipping follows mixed goods",
+			address:  newYork,
+			items:    mixed,
+			charge:   models.Charge{ID: "ship", Type: "shipping", Amount: models.MustParseMoney("10.00")},
+			taxable:  "5.00",
+			tax:      "0.45",
+			rule:     "US-shipping",
+			orderTax: "4.89",
+		},
+		{
+			name:     "NY shipping on exempt goods",
+			address:  newYork,
+			items:    mixed[:1],
+			charge:   models.Charge{ID: "ship", Type: "shipping", Amount: models.MustParseMoney("10.00")},
+			taxable:  "0.00",
+			tax:      "0.00",
+			rule:     "US-shipping",
+			orderTax: "0.00",
+		},
+		{
+			name:     "CA separately stated shipping",
+			address:  models.Address{Country: "US", State: "CA", ZipCode: "90001"},
+			items:    mixed[1:],
+			charge:   models.Charge{ID: "ship", Type: "shipping", Amount: models.MustParseMoney("10.00")},
+			taxable:  "0.00",
+			tax:      "0.00",
+			rule:     "CA-shipping",
+			orderTax: "4.88",
+		},
+		{
+			name:     "NY gift wrap",
+			address:  newYork,
+			items:    mixed[:1],
+			charge:   models.Charge{ID: "wrap", Type: "gift_wrap", Amount: models.MustParseMoney("4.00")},
+			taxable:  "4.00",
+			tax:      "0.36",
+			rule:     "US-gift-wrap",
+			orderTax: "0.36",
+		},
+		{
+			name:    "UK delivery follows zero and standard rated goods",
+			address: models.Address{Country: "GB", PostalCode: "SW1A 1AA"},
+			items: []models.Item{
+				{ID: "book", Price: models.MustParseMoney("30.00"), Quantity: 1, TaxCategory: "books"},
+				{ID: "pen", Price: models.MustParseMoney("10.00"), Quantity: 1},
+			},
+			charge:   models.Charge{ID: "ship", Type: "shipping", Amount: models.MustParseMoney("4.00")},
+			taxable:  "4.00",
+			tax:      "0.20",
+			rule:     "follows-goods",
+			orderTax: "2.20",
+		},
+	}
+
+	for _, tt := range tests {
+		req := &models.TaxRequest{
+			Address:         tt.address,
+			Items:           tt.items,
+			Charges:         []models.Charge{tt.charge},
+			TransactionDate: &testDate,
+		}
+		resp, err := service.CalculateTax(req)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", tt.name, err)
+		}
+		if len(resp.Charges) != 1 {
+			t.Fatalf("%s: expected 1 charge, got %d", tt.name, len(resp.Charges))
+		}
+		charge := resp.Charges[0]
+		if charge.TaxableAmount.String() != tt.taxable || charge.TaxAmount.String() != tt.tax {
+			t.Errorf("%s: expected taxable %s and tax %s, got %s and %s",
+				tt.name, tt.taxable, tt.tax, charge.TaxableAmount, charge.TaxAmount)
+		}
+		if charge.Taxability == nil || charge.Taxability.Rule != tt.rule {
+			t.Errorf("%s: expected rule %s, got %+v", tt.name, tt.rule, charge.Taxability)
+		}
+		if resp.TotalTax.String() != tt.orderTax {
+			t.Errorf("%s: expected order tax %s, got %s", tt.name, tt.orderTax, resp.TotalTax)
+		}
+		if resp.GrandTotal != resp.Subtotal.Add(resp.ChargeTotal).Add(resp.TotalTax) {
+			t.Errorf("%s: grand total should equal subtotal + charges + tax", tt.name)
+		}
+	}
+}
+
+func TestCalculateTax_InvalidCharge(t *testing.T) {
+	service := NewTaxService(DefaultRateProvider())
+
+	for _, charge := range []models.Charge{
+		{ID: "x", Type: "insurance", Amount: models.MustParseMoney("1.00")},
+		{ID: "x", Type: "shipping", Amount: models.MustParseMoney("-1.00")},
+	} {
+		req := &models.TaxRequest{
+			Address: models.Address{Country: "US", State: "NY", ZipCode: "10001"},
+			Items:   []models.Item{{ID: "a", Price: models.MustParseMoney("10.00"), Quantity: 1}},
+			Charges: []models.Charge{charge},
+		}
+		if _, err := service.CalculateTax(req); err == nil {
+			t.Errorf("Expected error for charge %+v", charge)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+// Discount levels reported on each line
+const (
+	DiscountLevelItem  = "item"
+	DiscountLevelOrder = "order"
+)
+
+// discountedLine is an item's amount before and after discounts
+type discountedLine struct {
+	original  models.Money
+	discount  models.Money
+	discounts []models.AppliedDiscount
+	base      models.Money // original - discount, the amount tax is charged on
+}
+
+// take records part of a discount against the line
+func (l *discountedLine) take(d models.Discount, level string, amount models.Money) {
+	if amount.IsZero() {
+		return
+	}
+	l.discount = l.discount.Add(amount)
+	l.base = l.base.Sub(amount)
+	l.discounts = append(l.discounts, models.AppliedDiscount{
+		Code:        d.Code,
+		Description: d.Description,
+		Level:       level,
+		Amount:      amount,
+	})
+}
+
+// applyDiscounts works out every line's taxable base. Item discounts come off
+// their own line; each order discount is then prorated across the lines in
+// proportion to their discounted amounts, so the shares always add up to the
+// discount. No discount can take a line or the order below zero.
+func applyDiscounts(req *models.TaxRequest, currency string) ([]discountedLine, error) {
+	lines := make([]discountedLine, len(req.Items))
+	for i, item := range req.Items {
+		original, err := item.Price.In(currency).MulQuantity(item.Quantity)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+		lines[i] = discountedLine{original: original, discount: models.NewMoney(0, currency), base: original}
+		for _, d := range item.Discounts {
+			lines[i].take(d, DiscountLevelItem, discountAmount(d, original, lines[i].base))
+		}
+	}
+
+	for _, d := range req.Discounts {
+		total := models.NewMoney(0, currency)
+		weights := make([]int64, len(lines))
+		for i, line := range lines {
+			total = total.Add(line.base)
+			weights[i] = line.base.Units
+		}
+		shares := discountAmount(d, total, total).Allocate(weights)
+		for i := range lines {
+			lines[i].take(d, DiscountLevelOrder, shares[i])
+		}
+	}
+	return lines, nil
+}
+
+// discountAmount returns the discount on an amount, capped at what is left
+// of it after earlier discounts
+func discountAmount(d models.Discount, amount, remaining models.Money) models.Money {
+	off := models.NewMoney(0, amount.Currency)
+	if d.Amount != nil {
+		off = d.Amount.In(amount.Currency)
+	} else if d.Percent != nil {
+		off = amount.MulRate(*d.Percent)
+	}
+	if off.Units > remaining.Units {
+		return remaining
+	}
+	return off
+}
+
+// validateDiscount checks a discount sets exactly one of amount and percent
+// to a sensible value
+func validateDiscount(d models.Discount) error {
+	switch {
+	case d.Amount != nil && d.Percent != nil:
+		return fmt.Errorf("set either amount or percent, not both")
+	case d.Amount != nil:
+		if d.Amount.IsNegative() {
+			return fmt.Errorf("amount must not be negative")
+		}
+	case d.Percent != nil:
+		if *d.Percent < 0 || *d.Percent > models.RateScale {
+			return fmt.Errorf("percent must be between 0 and 100")
+		}
+	default:
+		return fmt.Errorf("amount or percent is required")
+	}
+	return nil
+}
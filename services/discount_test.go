@@ -0,0 +1,120 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+func moneyPtr(s string) *models.Money {
+	m := models.MustParseMoney(s)
+	return &m
+}
+
+func ratePtr(s string) *models.Rate {
+	r := models.MustParseRate(s)
+	return &r
+}
+
+func TestCalculateTax_Discounts(t *testing.T) {
+	service := NewTaxService(DefaultRateProvider())
+
+	req := &models.TaxRequest{
+		Address: models.Address{Country: "DE", PostalCode: "10115"},
+		Items: []models.Item{
+			{ID: "a", Price: models.MustParseMoney("100.00"), Quantity: 1,
+				Discounts: []models.Discount{{Code: "TENOFF", Percent: ratePtr("10")}}},
+			{ID: "b", Price: models.MustParseMoney("50.00"), Quantity: 2,
+				Discounts: []models.Discount{{Code: "FIVE", Amount: moneyPtr("5.00")}}},
+		},
+		Discounts: []models.Discount{
+			{Code: "WELCOME20", Amount: moneyPtr("20.00")},
+			{Code: "SALE", Percent: ratePtr("10")},
+		},
+		TransactionDate: &testDate,
+	}
+
+	resp, err := service.CalculateTax(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []struct {
+		original, discount, base, tax string
+		shares                        string
+	}{
+		{"100.00", "27.76", "72.24", "13.73", "TENOFF:item:10.00,WELCOME20:order:9.73,SALE:order:8.03"},
+		{"100.00", "23.74", "76.26", "14.49", "FIVE:item:5.00,WELCOME20:order:10.27,SALE:order:8.47"},
+	}
+	for i, want := range expected {
+		item := resp.Items[i]
+		if item.OriginalAmount.String() != want.original || item.DiscountAmount.String() != want.discount ||
+			item.Subtotal.String() != want.base || item.TaxAmount.String() != want.tax {
+			t.Errorf("Item %d: expected %+v, got original %s discount %s base %s tax %s",
+				i, want, item.OriginalAmount, item.DiscountAmount, item.Subtotal, item.TaxAmount)
+		}
+		var shares []string
+		for _, d := range item.Discounts {
+			shares = append(shares, d.Code+":"+d.Level+":"+d.Amount.String())
+		}
+		if strings.Join(shares, ",") != want.shares {
+			t.Errorf("Item %d: expected discounts %s, got %s", i, want.shares, strings.Join(shares, ","))
+		}
+	}
+
+	if resp.TotalDiscount.String() != "51.50" || resp.Subtotal.String() != "148.50" || resp.TotalTax.String() != "28.22" {
+		t.Errorf("Expected discount 51.50, subtotal 148.50 and tax 28.22, got %s, %s and %s",
+			resp.TotalDiscount, resp.Subtotal, resp.TotalTax)
+	}
+}
+
+func TestCalculateTax_DiscountCappedAtLine(t *testing.T) {
+	service := NewTaxService(DefaultRateProvider())
+
+	req := &models.TaxRequest{
+		Address: models.Address{Country: "US", State: "NY", ZipCode: "10001"},
+		Items: []models.Item{
+			{ID: "a", Price: models.MustParseMoney("30.00"), Quantity: 1,
+				Discounts: []models.Discount{{Amount: moneyPtr("50.00")}}},
+			{ID: "b", Price: models.MustParseMoney("10.00"), Quantity: 1},
+		},
+		Discounts: []models.Discount{{Amount: moneyPtr("25.00")}},
+	}
+
+	resp, err := service.CalculateTax(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if resp.Items[0].Subtotal.String() != "0.00" || resp.Items[0].DiscountAmount.String() != "30.00" {
+		t.Errorf("Expected line a discounted to zero, got %s off", resp.Items[0].DiscountAmount)
+	}
+	if resp.Items[1].Subtotal.String() != "0.00" || resp.TotalTax.String() != "0.00" {
+		t.Errorf("Expected order discount capped at the remaining 10.00, got subtotal %s", resp.Items[1].Subtotal)
+	}
+}
+
+func TestCalculateTax_InvalidDiscount(t *testing.T) {
+	service := NewTaxService(DefaultRateProvider())
+
+	tests := []struct {
+		name     string
+		discount models.Discount
+	}{
+		{"empty", models.Discount{Code: "NONE"}},
+		{"both", models.Discount{Amount: moneyPtr("1.00"), Percent: ratePtr("5")}},
+		{"negative", models.Discount{Amount: moneyPtr("-1.00")}},
+		{"over 100%", models.Discount{Percent: ratePtr("150")}},
+	}
+
+	for _, tt := range tests {
+		req := &models.TaxRequest{
+			Address:   models.Address{Country: "US", State: "NY", ZipCode: "10001"},
+			Items:     []models.Item{{ID: "a", Price: models.MustParseMoney("10.00"), Quantity: 1}},
+			Discounts: []models.Discount{tt.discount},
+		}
+		if _, err := service.CalculateTax(req); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+// EffectivePeriod bounds the days a rate table entry applies. Both ends are
+// inclusive and a missing end leaves the period open, so a rate change is
+// recorded by closing the old entry with valid_to and opening the new one
+// with valid_from the next day.
+type EffectivePeriod struct {
+	ValidFrom *models.Date `json:"valid_from,omitempty"`
+	ValidTo   *models.Date `json:"valid_to,omitempty"`
+}
+
+// Covers reports whether the period includes date
+func (p EffectivePeriod) Covers(date models.Date) bool {
+	if p.ValidFrom != nil && date.Before(*p.ValidFrom) {
+		return false
+	}
+	if p.ValidTo != nil && date.After(*p.ValidTo) {
+		return false
+	}
+	return true
+}
+
+// String describes the period, e.g. "2023-01-01 to 2024-12-31"
+func (p EffectivePeriod) String() string {
+	from, to := "the beginning", "today"
+	if p.ValidFrom != nil {
+		from = p.ValidFrom.String()
+	}
+	if p.ValidTo != nil {
+		to = p.ValidTo.String()
+	}
+	return from + " to " + to
+}
+
+// validate checks the period does not end before it starts
+func (p EffectivePeriod) validate() error {
+	if p.ValidFrom != nil && p.ValidTo != nil && p.ValidTo.Before(*p.ValidFrom) {
+		return fmt.Errorf("valid_to %s is before valid_from %s", p.ValidTo, p.ValidFrom)
+	}
+	return nil
+}
+
+// overlaps reports whether two periods share at least one day
+func (p EffectivePeriod) overlaps(o EffectivePeriod) bool {
+	if p.ValidTo != nil && o.ValidFrom != nil && p.ValidTo.Before(*o.ValidFrom) {
+		return false
+	}
+	if o.ValidTo != nil && p.ValidFrom != nil && o.ValidTo.Before(*p.ValidFrom) {
+		return false
+	}
+	return true
+}
+
+// period returns the entry's effective period; rate table entries embed
+// EffectivePeriod and so satisfy dated
+func (p EffectivePeriod) period() EffectivePeriod {
+	return p
+}
+
+// dated is a rate table entry with an effective period
+type dated interface {
+	period() EffectivePeriod
+}
+
+// validatePeriods checks an entry's period is well formed and does not
+// overlap the periods of earlier entries for the same rate
+func validatePeriods[T dated](entry T, earlier []T) error {
+	p := entry.period()
+	if err := p.validate(); err != nil {
+		return err
+	}
+	for _, other := range earlier {
+		if other.period().overlaps(p) {
+			return fmt.Errorf("listed more than once for %s", p)
+		}
+	}
+	return nil
+}
+
+// transactionDate returns the day the request is taxed on: its
+// transaction_date, or today in UTC when none is given
+func transactionDate(req *models.TaxRequest) models.Date {
+	if req.TransactionDate != nil {
+		return *req.TransactionDate
+	}
+	return models.DateOf(time.Now().UTC())
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+func TestEffectivePeriod_Covers(t *testing.T) {
+	from := models.MustParseDate("2023-01-01")
+	to := models.MustParseDate("2023-12-31")
+	period := EffectivePeriod{ValidFrom: &from, ValidTo: &to}
+
+	tests := []struct {
+		date   string
+		covers bool
+	}{
+		{"2022-12-31", false},
+		{"2023-01-01", true},
+		{"2023-12-31", true},
+		{"2024-01-01", false},
+	}
+	for _, tt := range tests {
+		if got := period.Covers(models.MustParseDate(tt.date)); got != tt.covers {
+			t.Errorf("Covers(%s) = %v, expected %v", tt.date, got, tt.covers)
+		}
+	}
+
+	if !(EffectivePeriod{}).Covers(models.MustParseDate("1999-01-01")) {
+		t.Error("Expected an open period to cover every date")
+	}
+	if !(EffectivePeriod{ValidFrom: &to}).overlaps(period) {
+		t.Error("Expected periods sharing 2023-12-31 to overlap")
+	}
+	if (EffectivePeriod{ValidTo: &from}).overlaps(EffectivePeriod{ValidFrom: &to}) {
+		t.Error("Expected disjoint periods not to overlap")
+	}
+}
+
+func TestParseRateTable_OverlappingPeriods(t *testing.T) {
+	table := `{"version": "test", "states": [
+		{"code": "CO", "name": "Colorado", "rate": "2.9", "valid_to": "2023-12-31"},
+		{"code": "CO", "name": "Colorado", "rate": "3.0", "valid_from": "2023-12-31"}]}`
+	if _, err := ParseRateTable(strings.NewReader(table)); err == nil {
+		t.Error("Expected error for overlapping state periods")
+	}
+
+	table = `{"version": "test", "states": [
+		{"code": "CO", "name": "Colorado", "rate": "2.9", "valid_from": "2024-01-01", "valid_to": "2023-01-01"}]}`
+	if _, err := ParseRateTable(strings.NewReader(table)); err == nil {
+		t.Error("Expected error for a period ending before it starts")
+	}
+}
+
+func TestCalculateTax_HistoricalRates(t *testing.T) {
+	service := NewTaxService(DefaultRateProvider())
+
+	tests := []struct {
+		name     string
+		address  models.Address
+		category string
+		date     string
+		tax      string
+	}{
+		{"Denver before 2023", models.Address{Country: "US", State: "CO", ZipCode: "80202"}, "", "2022-06-30", "8.31"},
+		{"Denver from 2023", models.Address{Country: "US", State: "CO", ZipCode: "80202"}, "", "2023-01-01", "8.81"},
+		{"Alabama groceries before cut", models.Address{Country: "US", State: "AL", ZipCode: "35203"}, "groceries", "2024-08-31", "9.13"},
+		{"Alabama groceries at 3%", models.Address{Country: "US", State: "AL", ZipCode: "35203"}, "groceries", "2025-08-31", "8.13"},
+		{"Alabama groceries at 2%", models.Address{Country: "US", State: "AL", ZipCode: "35203"}, "groceries", "2025-09-01", "7.13"},
+		{"Illinois groceries after repeal", models.Address{Country: "US", State: "IL", ZipCode: "60601"}, "groceries", "2026-01-01", "0.00"},
+		{"UK VAT before 2011", models.Address{Country: "GB", PostalCode: "SW1A 1AA"}, "", "2010-12-31", "17.50"},
+		{"UK VAT from 2011", models.Address{Country: "GB", PostalCode: "SW1A 1AA"}, "", "2011-01-04", "20.00"},
+		{"Nova Scotia HST at 15%", models.Address{Country: "CA", State: "NS", PostalCode: "B3H 1A1"}, "", "2025-03-31", "15.00"},
+		{"Nova Scotia HST at 14%", models.Address{Country: "CA", State: "NS", PostalCode: "B3H 1A1"}, "", "2025-04-01", "14.00"},
+		{"Estonia VAT at 22%", models.Address{Country: "EE", PostalCode: "10111"}, "", "2025-06-30", "22.00"},
+		{"Estonia VAT at 24%", models.Address{Country: "EE", PostalCode: "10111"}, "", "2025-07-01", "24.00"},
+	}
+
+	for _, tt := range tests {
+		date := models.MustParseDate(tt.date)
+		req := &models.TaxRequest{
+			Address:         tt.address,
+			Items:           []models.Item{{ID: "item1", Price: models.MustParseMoney("100.00"), Quantity: 1, TaxCategory: tt.category}},
+			TransactionDate: &date,
+		}
+		resp, err := service.CalculateTax(req)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", tt.name, err)
+		}
+		if resp.TotalTax.String() != tt.tax {
+			t.Errorf("%s: expected tax %s, got %s", tt.name, tt.tax, resp.TotalTax)
+		}
+		if resp.TransactionDate != date {
+			t.Errorf("%s: expected transaction date %s, got %s", tt.name, date, resp.TransactionDate)
+		}
+	}
+}
+
+func TestCalculateTax_DefaultsToToday(t *testing.T) {
+	service := NewTaxService(DefaultRateProvider())
+	service.now = func() time.Time { return time.Date(2010, time.June, 1, 12, 0, 0, 0, time.UTC) }
+
+	req := &models.TaxRequest{
+		Address: models.Address{Country: "GB", PostalCode: "SW1A 1AA"},
+		Items:   []models.Item{{ID: "item1", Price: models.MustParseMoney("100.00"), Quantity: 1}},
+	}
+	resp, err := service.CalculateTax(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if resp.TransactionDate.String() != "2010-06-01" || resp.TotalTax.String() != "17.50" {
+		t.Errorf("Expected 17.50 on 2010-06-01, got %s on %s", resp.TotalTax, resp.TransactionDate)
+	}
+	if req.TransactionDate != nil {
+		t.Error("Expected the caller's request to be left unchanged")
+	}
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+// TaxEngine calculates tax for addresses in a single country
+type TaxEngine interface {
+	// Currency returns the ISO 4217 code the engine calculates in
+	Currency() string
+	// ValidateAddress checks the address carries what the engine needs
+	ValidateAddress(address *models.Address) error
+	// Jurisdiction describes the taxing jurisdiction for the request
+	Jurisdiction(req *models.TaxRequest) string
+	// TaxRates returns the taxes that apply to one of the request's items
+	TaxRates(req *models.TaxRequest, item *models.Item) ([]AppliedRate, error)
+}
+
+// requestValidator is implemented by engines that need more of the request
+// than the address, e.g. the seller's registration
+type requestValidator interface {
+	ValidateRequest(req *models.TaxRequest) error
+}
+
+// matchLevelReporter is implemented by engines that resolve rates from address
+// data of varying precision
+type matchLevelReporter interface {
+	MatchLevel(req *models.TaxRequest) string
+}
+
+// taxabilityReporter is implemented by engines that exempt or reduce tax on
+// some product categories
+type taxabilityReporter interface {
+	Taxability(req *models.TaxRequest, item *models.Item) *models.ItemTaxability
+}
+
+// sourcingReporter is implemented by engines that choose between the
+// destination's and the ship from address's rates
+type sourcingReporter interface {
+	Sourcing(req *models.TaxRequest, item *models.Item) string
+}
+
+// chargeTaxer is implemented by engines whose jurisdictions have their own
+// rules for taxing charges such as shipping; charges sent to other engines are
+// taxed like the goods they are billed with. A nil result also means the
+// charge follows the goods.
+type chargeTaxer interface {
+	ChargeTaxability(req *models.TaxRequest, charge *models.Charge) (*models.ItemTaxability, error)
+}
+
+// currencyChooser is implemented by engines covering countries with their own
+// currencies; the request is calculated in the currency returned instead of
+// Currency
+type currencyChooser interface {
+	CurrencyFor(req *models.TaxRequest) string
+}
+
+// nexusTracked is implemented by engines whose rules depend on the sellers'
+// settings and sales held by the service's nexus tracker
+type nexusTracked interface {
+	SetNexusTracker(tracker *NexusTracker)
+}
+
+// saleRecorder is implemented by engines that count committed sales toward a
+// threshold of their own; amount is negative to take a sale back
+type saleRecorder interface {
+	RecordSale(req *models.TaxRequest, amount models.Money)
+}
+
+// AppliedRate is a named tax an engine applies to an item, e.g. "VAT standard rate"
+type AppliedRate struct {
+	Name         string
+	Rate         models.Rate
+	Jurisdiction models.Jurisdiction // authority the tax is owed to
+	Note         string              // optional legal wording to print on the invoice
+}
+
+// JurisdictionCountry is the type of a national taxing authority
+const JurisdictionCountry = "country"
+
+// UnsupportedJurisdictionError is returned when no engine is registered for
+// the request's country
+type UnsupportedJurisdictionError struct {
+	Country string
+}
+
+func (e *UnsupportedJurisdictionError) Error() string {
+	return fmt.Sprintf("unsupported jurisdiction: tax calculation is not available for country %q", e.Country)
+}
+
+// countryAliases maps common non-ISO spellings to ISO 3166-1 alpha-2 codes
+var countryAliases = map[string]string{
+	"USA":            "US",
+	"UNITED STATES":  "US",
+	"UK":             "GB",
+	"UNITED KINGDOM": "GB",
+	"GREAT BRITAIN":  "GB",
+	"IND":            "IN",
+	"INDIA":          "IN",
+	"CAN":            "CA",
+	"CANADA":         "CA",
+	"EL":             "GR", // Greece's code in EU VAT numbers
+}
+
+// normalizeCountry returns the ISO 3166-1 alpha-2 code for a country
+func normalizeCountry(country string) string {
+	code := strings.ToUpper(strings.TrimSpace(country))
+	if alias, ok := countryAliases[code]; ok {
+		return alias
+	}
+	return code
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+//go:embed data/ca_rates.json
+var defaultCARateTable []byte
+
+// canadaPostalCodePattern matches an A1A 1A1 postal code; D, F, I, O, Q and U
+// are never used, and W and Z never start a code
+var canadaPostalCodePattern = regexp.MustCompile(`^[ABCEGHJ-NPRSTVXY][0-9][ABCEGHJ-NPRSTV-Z] ?[0-9][ABCEGHJ-NPRSTV-Z][0-9]$`)
+
+// canadaJurisdiction is the federal taxing jurisdiction
+var canadaJurisdiction = models.Jurisdiction{Code: "CA", Name: "Canada", Type: JurisdictionCountry}
+
+// CanadaRateTable is the on-disk representation of the Canadian rate table
+type CanadaRateTable struct {
+	Version   string     `json:"version"`
+	Country   string     `json:"country"`
+	Provinces []Province `json:"provinces"`
+}
+
+// Province is a Canadian province or territory and the sales taxes it levies
+type Province struct {
+	Code           string      `json:"code"`
+	Name           string      `json:"name"`
+	PostalPrefixes string      `json:"postal_prefixes"` // first letters of its postal codes
+	Taxes          []NamedRate `json:"taxes"`
+}
+
+// CAEngine calculates Canadian GST/HST plus provincial PST/RST/QST
+type CAEngine struct {
+	provinces map[string]Province // keyed by upper-case code and name
+}
+
+// NewCAEngine creates a Canadian sales tax engine from a rate table
+func NewCAEngine(table *CanadaRateTable) (*CAEngine, error) {
+	provinces := make(map[string]Province, 2*len(table.Provinces))
+	for _, province := range table.Provinces {
+		if len(province.Taxes) == 0 {
+			return nil, fmt.Errorf("province %s has no taxes", province.Code)
+		}
+		byName := make(map[string][]NamedRate, len(province.Taxes))
+		for _, tax := range province.Taxes {
+			if tax.Rate < 0 || tax.Rate >= models.RateScale {
+				return nil, fmt.Errorf("province %s %s has invalid rate %s%%", province.Code, tax.Name, tax.Rate)
+			}
+			if err := validatePeriods(tax, byName[tax.Name]); err != nil {
+				return nil, fmt.Errorf("province %s %s: %w", province.Code, tax.Name, err)
+			}
+			byName[tax.Name] = append(byName[tax.Name], tax)
+		}
+		provinces[strings.ToUpper(province.Code)] = province
+		provinces[strings.ToUpper(province.Name)] = province
+	}
+	return &CAEngine{provinces: provinces}, nil
+}
+
+// DefaultCAEngine returns a Canadian engine backed by the table bundled with the binary
+func DefaultCAEngine() *CAEngine {
+	var table CanadaRateTable
+	decoder := json.NewDecoder(bytes.NewReader(defaultCARateTable))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&table); err != nil {
+		panic(fmt.Sprintf("bundled Canadian rate table is invalid: %v", err))
+	}
+	engine, err := NewCAEngine(&table)
+	if err != nil {
+		panic(fmt.Sprintf("bundled Canadian rate table is invalid: %v", err))
+	}
+	return engine
+}
+
+// Currency returns CAD
+func (e *CAEngine) Currency() string {
+	return "CAD"
+}
+
+// ValidateAddress requires a known province and a postal code that is well
+// formed and belongs to that province
+func (e *CAEngine) ValidateAddress(address *models.Address) error {
+	if address.State == "" {
+		return fmt.Errorf("state is required (province code)")
+	}
+	province, ok := e.province(address.State)
+	if !ok {
+		return fmt.Errorf("state %q is not a Canadian province or territory", address.State)
+	}
+
+	postalCode := address.PostalCode
+	if postalCode == "" {
+		postalCode = address.ZipCode
+	}
+	postalCode = strings.ToUpper(strings.TrimSpace(postalCode))
+	if postalCode == "" {
+		return fmt.Errorf("postal_code is required")
+	}
+	if !canadaPostalCodePattern.MatchString(postalCode) {
+		return fmt.Errorf("postal_code %q is not a valid Canadian postal code", postalCode)
+	}
+	if !strings.ContainsRune(province.PostalPrefixes, rune(postalCode[0])) {
+		return fmt.Errorf("postal_code %q is not in %s", postalCode, province.Name)
+	}
+	return nil
+}
+
+// Jurisdiction returns e.g. "Ontario, Canada"
+func (e *CAEngine) Jurisdiction(req *models.TaxRequest) string {
+	address := &req.Address
+	if province, ok := e.province(address.State); ok {
+		return fmt.Sprintf("%s, Canada", province.Name)
+	}
+	return fmt.Sprintf("%s, Canada", address.State)
+}
+
+// TaxRates returns the province's federal and provincial taxes in force on
+// the transaction date by name, e.g. GST + QST in Quebec or a single HST in
+// Ontario
+func (e *CAEngine) TaxRates(req *models.TaxRequest, item *models.Item) ([]AppliedRate, error) {
+	province, ok := e.province(req.Address.State)
+	if !ok {
+		return nil, fmt.Errorf("state %q is not a Canadian province or territory", req.Address.State)
+	}
+
+	date := transactionDate(req)
+	applied := make([]AppliedRate, 0, len(province.Taxes))
+	for _, tax := range province.Taxes {
+		if !tax.Covers(date) {
+			continue
+		}
+		// GST and HST are administered federally; PST, RST and QST by the province
+		jurisdiction := canadaJurisdiction
+		if tax.Name != "GST" && tax.Name != "HST" {
+			jurisdiction = models.Jurisdiction{Code: province.Code, Name: province.Name, Type: JurisdictionState}
+		}
+		applied = append(applied, AppliedRate{Name: tax.Name, Rate: tax.Rate, Jurisdiction: jurisdiction})
+	}
+	return applied, nil
+}
+
+// province looks up a province or territory by code or name
+func (e *CAEngine) province(codeOrName string) (Province, bool) {
+	province, ok := e.provinces[strings.ToUpper(strings.TrimSpace(codeOrName))]
+	return province, ok
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+func TestCalculateTax_CanadaProvinces(t *testing.T) {
+	service := NewTaxService(DefaultRateProvider())
+
+	tests := []struct {
+		province   string
+		postalCode string
+		taxes      []string
+		amounts    []string
+	}{
+		{"ON", "M5V 2T6", []string{"HST"}, []string{"13.00"}},
+		{"Quebec", "H2X1Y4", []string{"GST", "QST"}, []string{"5.00", "9.98"}},
+		{"BC", "V6B 1A1", []string{"GST", "PST"}, []string{"5.00", "7.00"}},
+		{"AB", "T2P 1J9", []string{"GST"}, []string{"5.00"}},
+	}
+
+	for _, tt := range tests {
+		req := &models.TaxRequest{
+			Address: models.Address{State: tt.province, Country: "CA", PostalCode: tt.postalCode},
+			Items:   []models.Item{{ID: "item1", Name: "Product A", Price: models.MustParseMoney("100.00"), Quantity: 1}},
+		}
+
+		resp, err := service.CalculateTax(req)
+		if err != nil {
+			t.Errorf("Expected no error for %s, got %v", tt.province, err)
+			continue
+		}
+		if resp.Currency != "CAD" {
+			t.Errorf("Expected CAD, got %s", resp.Currency)
+		}
+
+		taxes := resp.Items[0].Taxes
+		if len(taxes) != len(tt.taxes) {
+			t.Errorf("%s: expected taxes %v, got %+v", tt.province, tt.taxes, taxes)
+			continue
+		}
+		for i := range taxes {
+			if taxes[i].Name != tt.taxes[i] || taxes[i].TaxAmount.String() != tt.amounts[i] {
+				t.Errorf("%s: expected %s %s, got %s %s", tt.province, tt.taxes[i], tt.amounts[i], taxes[i].Name, taxes[i].TaxAmount)
+			}
+		}
+	}
+}
+
+func TestCAEngine_ValidateAddress(t *testing.T) {
+	engine := DefaultCAEngine()
+
+	if err := engine.ValidateAddress(&models.Address{State: "ON", PostalCode: "k1a 0b1"}); err != nil {
+		t.Errorf("Expected valid address, got %v", err)
+	}
+
+	invalid := []models.Address{
+		{PostalCode: "K1A 0B1"},
+		{State: "ZZ", PostalCode: "K1A 0B1"},
+		{State: "ON"},
+		{State: "ON", PostalCode: "12345"},
+		{State: "ON", PostalCode: "D1A 0B1"},
+		{State: "BC", PostalCode: "K1A 0B1"}, // Ontario postal code
+	}
+	for _, address := range invalid {
+		if err := engine.ValidateAddress(&address); err == nil {
+			t.Errorf("Expected error for %+v", address)
+		}
+	}
+}
@@ -0,0 +1,345 @@
+package services
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+//go:embed data/eu_vat.json
+var defaultEUVATTable []byte
+
+// EUVATTable is the on-disk representation of the EU VAT table. Currency is
+// the euro, in which the OSS threshold is set; EuroRates gives the price of
+// one euro in each other currency a member state charges in, used to count
+// sales in that currency toward the threshold.
+type EUVATTable struct {
+	Version           string                  `json:"version"`
+	Currency          string                  `json:"currency"`
+	OSSThreshold      models.Money            `json:"oss_threshold"`
+	EuroRates         map[string]models.Money `json:"euro_rates"`
+	ReverseChargeNote string                  `json:"reverse_charge_note"`
+	Categories        []string                `json:"categories"`
+	MemberStates      []MemberState           `json:"member_states"`
+}
+
+// MemberState holds one EU member state's VAT rates, currency and VAT number
+// format. Currency defaults to the table's. A member state may be listed once
+// per effective period.
+type MemberState struct {
+	Code          string                 `json:"code"`
+	Name          string                 `json:"name"`
+	Currency      string                 `json:"currency,omitempty"`
+	VATPrefix     string                 `json:"vat_prefix"`
+	VATIDPattern  string                 `json:"vat_id_pattern"`
+	StandardRate  models.Rate            `json:"standard_rate"`
+	CategoryRates map[string]models.Rate `json:"category_rates"`
+	EffectivePeriod
+
+	vatID *regexp.Regexp
+}
+
+// EUEngine calculates EU VAT using One-Stop-Shop destination rules and the
+// B2B reverse charge. Sellers established outside the EU always charge
+// destination VAT; an EU seller, configured with its eu_establishment in the
+// nexus tracker, charges its home rate on B2C sales to other member states
+// until its tracked cross-border sales pass the OSS threshold.
+type EUEngine struct {
+	currency          string
+	ossThreshold      models.Money
+	euroRates         map[string]models.Money
+	reverseChargeNote string
+	categories        map[string]bool
+	states            map[string][]MemberState // keyed by code, one per period
+	nexus             *NexusTracker
+}
+
+// NewEUEngine creates an EU VAT engine from a VAT table. Until it is given a
+// nexus tracker every seller is treated as established outside the EU.
+func NewEUEngine(table *EUVATTable) (*EUEngine, error) {
+	engine := &EUEngine{
+		currency:          strings.ToUpper(table.Currency),
+		ossThreshold:      table.OSSThreshold,
+		euroRates:         make(map[string]models.Money, len(table.EuroRates)),
+		reverseChargeNote: table.ReverseChargeNote,
+		categories:        make(map[string]bool, len(table.Categories)),
+		states:            make(map[string][]MemberState, len(table.MemberStates)),
+	}
+	if engine.currency == "" {
+		return nil, fmt.Errorf("EU VAT table has no currency")
+	}
+	for currency, rate := range table.EuroRates {
+		if rate.Units <= 0 {
+			return nil, fmt.Errorf("EU VAT table has invalid euro rate %s for %s", rate, currency)
+		}
+		engine.euroRates[strings.ToUpper(currency)] = rate
+	}
+	for _, category := range table.Categories {
+		engine.categories[strings.ToLower(category)] = true
+	}
+
+	for _, state := range table.MemberStates {
+		pattern, err := regexp.Compile(state.VATIDPattern)
+		if err != nil {
+			return nil, fmt.Errorf("member state %s has invalid VAT ID pattern: %w", state.Code, err)
+		}
+		state.vatID = pattern
+		if state.StandardRate <= 0 || state.StandardRate >= models.RateScale {
+			return nil, fmt.Errorf("member state %s has invalid standard rate %s%%", state.Code, state.StandardRate)
+		}
+		for category, rate := range state.CategoryRates {
+			if !engine.categories[category] {
+				return nil, fmt.Errorf("member state %s rates unknown category %s", state.Code, category)
+			}
+			if rate < 0 || rate > state.StandardRate {
+				return nil, fmt.Errorf("member state %s has invalid %s rate %s%%", state.Code, category, rate)
+			}
+		}
+		state.Currency = strings.ToUpper(state.Currency)
+		if state.Currency == "" {
+			state.Currency = engine.currency
+		}
+		if _, ok := engine.euroRates[state.Currency]; !ok && state.Currency != engine.currency {
+			return nil, fmt.Errorf("member state %s charges in %s but the table has no euro rate for it", state.Code, state.Currency)
+		}
+		code := strings.ToUpper(state.Code)
+		if err := validatePeriods(state, engine.states[code]); err != nil {
+			return nil, fmt.Errorf("member state %s: %w", state.Code, err)
+		}
+		engine.states[code] = append(engine.states[code], state)
+	}
+	return engine, nil
+}
+
+// DefaultEUEngine returns an EU engine backed by the table bundled with the
+// binary
+func DefaultEUEngine() *EUEngine {
+	var table EUVATTable
+	decoder := json.NewDecoder(bytes.NewReader(defaultEUVATTable))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&table); err != nil {
+		panic(fmt.Sprintf("bundled EU VAT table is invalid: %v", err))
+	}
+	engine, err := NewEUEngine(&table)
+	if err != nil {
+		panic(fmt.Sprintf("bundled EU VAT table is invalid: %v", err))
+	}
+	return engine
+}
+
+// MemberStates returns the ISO codes of the member states the engine covers
+func (e *EUEngine) MemberStates() []string {
+	codes := make([]string, 0, len(e.states))
+	for code := range e.states {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Currency returns the currency OSS returns are filed in (EUR)
+func (e *EUEngine) Currency() string {
+	return e.currency
+}
+
+// CurrencyFor returns the currency of the member state whose VAT applies, so
+// a sale taxed in Sweden is calculated in SEK
+func (e *EUEngine) CurrencyFor(req *models.TaxRequest) string {
+	state, err := e.taxingState(req)
+	if err != nil {
+		return e.currency
+	}
+	return state.Currency
+}
+
+// SetNexusTracker sets the tracker holding the sellers' EU establishment and
+// cross-border sales
+func (e *EUEngine) SetNexusTracker(tracker *NexusTracker) {
+	e.nexus = tracker
+}
+
+// RecordSale counts a committed B2C sale by an EU seller to a buyer in another
+// member state toward the seller's OSS threshold, in euros
+func (e *EUEngine) RecordSale(req *models.TaxRequest, amount models.Money) {
+	home := e.establishment(req.SellerID)
+	if home == "" || home == normalizeCountry(req.Address.Country) || req.BuyerVATID != "" {
+		return
+	}
+	if euros, ok := e.inEuros(amount); ok {
+		e.nexus.RecordEU(req.SellerID, transactionDate(req), euros)
+	}
+}
+
+// ValidateAddress requires a member state and a postal code
+func (e *EUEngine) ValidateAddress(address *models.Address) error {
+	if _, ok := e.states[normalizeCountry(address.Country)]; !ok {
+		return fmt.Errorf("country %q is not an EU member state", address.Country)
+	}
+	if address.ZipCode == "" && address.PostalCode == "" {
+		return fmt.Errorf("postal_code is required")
+	}
+	return nil
+}
+
+// Jurisdiction returns the member state whose VAT applies, e.g. "Germany, EU"
+func (e *EUEngine) Jurisdiction(req *models.TaxRequest) string {
+	state, err := e.taxingState(req)
+	if err != nil {
+		return fmt.Sprintf("%s, EU", normalizeCountry(req.Address.Country))
+	}
+	return fmt.Sprintf("%s, EU", state.Name)
+}
+
+// TaxRates returns the VAT for an item. A valid buyer VAT number on a
+// cross-border sale triggers the reverse charge; otherwise the rate of the
+// taxing member state in force on the transaction date applies for the
+// item's category.
+func (e *EUEngine) TaxRates(req *models.TaxRequest, item *models.Item) ([]AppliedRate, error) {
+	category := strings.ToLower(strings.TrimSpace(item.TaxCategory))
+	if category != "" && category != "standard" && !e.categories[category] {
+		return nil, fmt.Errorf("tax_category %q is not a known EU VAT category", item.TaxCategory)
+	}
+
+	buyer, err := e.memberState(normalizeCountry(req.Address.Country), transactionDate(req))
+	if err != nil {
+		return nil, err
+	}
+	if req.BuyerVATID != "" {
+		if err := buyer.validateVATID(req.BuyerVATID); err != nil {
+			return nil, err
+		}
+		if buyer.Code != e.establishment(req.SellerID) {
+			return []AppliedRate{{
+				Name:         "VAT reverse charge",
+				Rate:         0,
+				Jurisdiction: buyer.jurisdiction(),
+				Note:         e.reverseChargeNote,
+			}}, nil
+		}
+	}
+
+	state, err := e.taxingState(req)
+	if err != nil {
+		return nil, err
+	}
+	rate, ok := state.CategoryRates[category]
+	if !ok || rate == state.StandardRate {
+		return []AppliedRate{{
+			Name:         fmt.Sprintf("VAT %s standard rate", state.Code),
+			Rate:         state.StandardRate,
+			Jurisdiction: state.jurisdiction(),
+		}}, nil
+	}
+	return []AppliedRate{{
+		Name:         fmt.Sprintf("VAT %s reduced rate", state.Code),
+		Rate:         rate,
+		Jurisdiction: state.jurisdiction(),
+	}}, nil
+}
+
+// taxingState returns the member state rates that apply to a B2C sale: the
+// seller's own state for an EU seller whose cross-border sales, with this
+// sale, stay within the OSS threshold this calendar year and were within it
+// the previous year, otherwise the buyer's state. The sale that takes the
+// seller over the threshold is already charged at the buyer's rate.
+func (e *EUEngine) taxingState(req *models.TaxRequest) (*MemberState, error) {
+	date := transactionDate(req)
+	if home := e.establishment(req.SellerID); home != "" {
+		current := e.nexus.EUSales(req.SellerID, date.Year())
+		previous := e.nexus.EUSales(req.SellerID, date.Year()-1)
+		if home != normalizeCountry(req.Address.Country) {
+			current.Sales = current.Sales.Add(e.saleInEuros(req, home, date))
+		}
+		if current.Sales.Units <= e.ossThreshold.Units && previous.Sales.Units <= e.ossThreshold.Units {
+			return e.memberState(home, date)
+		}
+	}
+	return e.memberState(normalizeCountry(req.Address.Country), date)
+}
+
+// saleInEuros returns the goods and charges of a sale after discounts, in
+// euros. Prices are read in the request's currency or, without one, in the
+// currency of the seller's home state, which the sale is charged in while
+// under the threshold.
+func (e *EUEngine) saleInEuros(req *models.TaxRequest, home string, date models.Date) models.Money {
+	zero := models.NewMoney(0, e.currency)
+	currency := strings.ToUpper(req.Currency)
+	if currency == "" {
+		state, err := e.memberState(home, date)
+		if err != nil {
+			return zero
+		}
+		currency = state.Currency
+	}
+	lines, err := applyDiscounts(req, currency)
+	if err != nil {
+		return zero // reported when the request is calculated
+	}
+	amount := models.NewMoney(0, currency)
+	for _, line := range lines {
+		amount = amount.Add(line.base)
+	}
+	for _, charge := range req.Charges {
+		amount = amount.Add(charge.Amount.In(currency))
+	}
+	euros, ok := e.inEuros(amount)
+	if !ok {
+		return zero
+	}
+	return euros
+}
+
+// inEuros converts an amount to euros at the table's euro rates
+func (e *EUEngine) inEuros(amount models.Money) (models.Money, bool) {
+	if amount.Currency == e.currency {
+		return amount, true
+	}
+	rate, ok := e.euroRates[amount.Currency]
+	if !ok {
+		return models.Money{}, false
+	}
+	return amount.Prorate(models.MustParseMoney("1").Units, rate.Units).In(e.currency), true
+}
+
+// establishment returns the member state the seller is established in, or ""
+// for sellers established outside the EU
+func (e *EUEngine) establishment(sellerID string) string {
+	if e.nexus == nil || sellerID == "" {
+		return ""
+	}
+	home := e.nexus.EUEstablishment(sellerID)
+	if _, ok := e.states[home]; !ok {
+		return ""
+	}
+	return home
+}
+
+// memberState returns a member state's rates in force on date
+func (e *EUEngine) memberState(code string, date models.Date) (*MemberState, error) {
+	for i, state := range e.states[code] {
+		if state.Covers(date) {
+			return &e.states[code][i], nil
+		}
+	}
+	return nil, fmt.Errorf("no VAT rates for %s on %s", code, date)
+}
+
+// jurisdiction returns the member state as a taxing jurisdiction
+func (s *MemberState) jurisdiction() models.Jurisdiction {
+	return models.Jurisdiction{Code: s.Code, Name: s.Name, Type: JurisdictionCountry}
+}
+
+// validateVATID checks a VAT number is well formed for the member state
+func (s *MemberState) validateVATID(vatID string) error {
+	id := strings.ToUpper(strings.NewReplacer(" ", "", ".", "", "-", "").Replace(vatID))
+	if !strings.HasPrefix(id, s.VATPrefix) || !s.vatID.MatchString(strings.TrimPrefix(id, s.VATPrefix)) {
+		return fmt.Errorf("buyer_vat_id %q is not a valid %s VAT number", vatID, s.Name)
+	}
+	return nil
+}
@@ -0,0 +1,289 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+func loadEUTable(t *testing.T) *EUVATTable {
+	t.Helper()
+	var table EUVATTable
+	if err := json.NewDecoder(bytes.NewReader(defaultEUVATTable)).Decode(&table); err != nil {
+		t.Fatalf("Failed to load EU VAT table: %v", err)
+	}
+	return &table
+}
+
+func TestCalculateTax_EUDestinationRate(t *testing.T) {
+	service := NewTaxService(DefaultRateProvider())
+
+	req := &models.TaxRequest{
+		Address: models.Address{City: "Berlin", Country: "DE", PostalCode: "10115"},
+		Items: []models.Item{
+			{ID: "item1", Name: "Headphones", Price: models.MustParseMoney("100.00"), Quantity: 1},
+			{ID: "item2", Name: "Novel", Price: models.MustParseMoney("20.00"), Quantity: 1, TaxCategory: "books"},
+		},
+	}
+
+	resp, err := service.CalculateTax(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if resp.Currency != "EUR" || resp.TaxJurisdiction != "Germany, EU" {
+		t.Errorf("Unexpected currency/jurisdiction %s %s", resp.Currency, resp.TaxJurisdiction)
+	}
+	if resp.Items[0].TaxAmount.String() != "19.00" {
+		t.Errorf("Expected 19%% standard VAT, got %s", resp.Items[0].TaxAmount)
+	}
+	if resp.Items[1].TaxAmount.String() != "1.40" {
+		t.Errorf("Expected 7%% reduced VAT on books, got %s", resp.Items[1].TaxAmount)
+	}
+	if len(resp.Notes) != 0 {
+		t.Errorf("Expected no notes for B2C sale, got %v", resp.Notes)
+	}
+}
+
+func TestCalculateTax_EUReverseCharge(t *testing.T) {
+	service := NewTaxService(DefaultRateProvider())
+
+	req := &models.TaxRequest{
+		Address:    models.Address{City: "Paris", Country: "FR", PostalCode: "75001"},
+		BuyerVATID: "FR 40 303265045",
+		Items:      []models.Item{{ID: "item1", Name: "Server", Price: models.MustParseMoney("5000.00"), Quantity: 1}},
+	}
+
+	resp, err := service.CalculateTax(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !resp.TotalTax.IsZero() {
+		t.Errorf("Expected zero VAT under reverse charge, got %s", resp.TotalTax)
+	}
+	if len(resp.Notes) != 1 {
+		t.Fatalf("Expected reverse charge note, got %v", resp.Notes)
+	}
+
+	req.BuyerVATID = "FR123"
+	if _, err := service.CalculateTax(req); err == nil {
+		t.Error("Expected error for malformed VAT number")
+	}
+}
+
+// newEUSellerEngine returns the bundled EU engine with an Irish seller whose
+// cross-border sales so far this year and last are as given
+func newEUSellerEngine(t *testing.T, current, previous string) *EUEngine {
+	t.Helper()
+	tracker := NewNexusTracker(DefaultNexusThresholds())
+	err := tracker.Configure(SellerNexus{SellerID: "shop", EUEstablishment: "IE", PriorEUSales: []EUSales{
+		{Year: 2025, Sales: models.MustParseMoney(current)},
+		{Year: 2024, Sales: models.MustParseMoney(previous)},
+	}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	engine, err := NewEUEngine(loadEUTable(t))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	engine.SetNexusTracker(tracker)
+	return engine
+}
+
+func TestEUEngine_OSSThreshold(t *testing.T) {
+	date := models.MustParseDate("2025-06-01")
+	req := &models.TaxRequest{
+		Address:         models.Address{Country: "FR", PostalCode: "75001"},
+		Items:           []models.Item{{ID: "item1", Name: "Lamp", Price: models.MustParseMoney("100.00"), Quantity: 1}},
+		SellerID:        "shop",
+		TransactionDate: &date,
+	}
+
+	tests := []struct {
+		name              string
+		current, previous string
+		sellerID          string
+		rate              string
+		jurisdiction      string
+	}{
+		{"under the threshold charges Irish VAT", "9000.00", "10000.00", "shop", "23", "Ireland, EU"},
+		{"the sale reaches the threshold", "9900.00", "0.00", "shop", "23", "Ireland, EU"},
+		{"the sale passes the threshold", "9900.01", "0.00", "shop", "20", "France, EU"},
+		{"over the threshold this year", "10000.01", "0.00", "shop", "20", "France, EU"},
+		{"over the threshold last year", "0.00", "10000.01", "shop", "20", "France, EU"},
+		{"sellers outside the EU charge destination VAT", "0.00", "0.00", "", "20", "France, EU"},
+	}
+	for _, tt := range tests {
+		engine := newEUSellerEngine(t, tt.current, tt.previous)
+		req.SellerID = tt.sellerID
+		rates, err := engine.TaxRates(req, &req.Items[0])
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", tt.name, err)
+		}
+		if rates[0].Rate != models.MustParseRate(tt.rate) {
+			t.Errorf("%s: expected %s%%, got %s", tt.name, tt.rate, rates[0].Rate)
+		}
+		if got := engine.Jurisdiction(req); got != tt.jurisdiction {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.jurisdiction, got)
+		}
+	}
+}
+
+func TestEUEngine_OSSThresholdCountsDiscountsAndCharges(t *testing.T) {
+	date := models.MustParseDate("2025-06-01")
+	req := &models.TaxRequest{
+		Address:         models.Address{Country: "FR", PostalCode: "75001"},
+		Items:           []models.Item{{ID: "item1", Name: "Lamp", Price: models.MustParseMoney("120.00"), Quantity: 1}},
+		Discounts:       []models.Discount{{Code: "SAVE20", Amount: moneyPtr("20.00")}},
+		SellerID:        "shop",
+		TransactionDate: &date,
+	}
+	engine := newEUSellerEngine(t, "9900.00", "0.00")
+	if got := engine.Jurisdiction(req); got != "Ireland, EU" {
+		t.Errorf("Expected the discounted sale to stay within the threshold, got %s", got)
+	}
+	req.Charges = []models.Charge{{ID: "ship", Type: "shipping", Amount: models.MustParseMoney("0.01")}}
+	if got := engine.Jurisdiction(req); got != "France, EU" {
+		t.Errorf("Expected shipping to take the sale over the threshold, got %s", got)
+	}
+}
+
+func TestEUEngine_DomesticB2BIsNotReverseCharged(t *testing.T) {
+	engine := newEUSellerEngine(t, "0.00", "0.00")
+
+	req := &models.TaxRequest{
+		Address:    models.Address{Country: "IE", PostalCode: "D02 X285"},
+		BuyerVATID: "IE1234567T",
+		Items:      []models.Item{{ID: "item1", Name: "Desk", Price: models.MustParseMoney("100.00"), Quantity: 1}},
+		SellerID:   "shop",
+	}
+	rates, err := engine.TaxRates(req, &req.Items[0])
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if rates[0].Rate != models.MustParseRate("23") || rates[0].Note != "" {
+		t.Errorf("Expected domestic VAT, got %+v", rates[0])
+	}
+}
+
+func TestCalculateTax_EUMemberStateCurrency(t *testing.T) {
+	service := NewTaxService(DefaultRateProvider())
+
+	tests := []struct {
+		country, postalCode, date string
+		currency                  string
+	}{
+		{"SE", "111 22", "2025-06-01", "SEK"},
+		{"PL", "00-001", "2025-06-01", "PLN"},
+		{"BG", "1000", "2025-12-31", "BGN"},
+		{"BG", "1000", "2026-01-01", "EUR"},
+		{"DE", "10115", "2025-06-01", "EUR"},
+	}
+	for _, tt := range tests {
+		date := models.MustParseDate(tt.date)
+		req := &models.TaxRequest{
+			Address:         models.Address{Country: tt.country, PostalCode: tt.postalCode},
+			Items:           []models.Item{{ID: "item1", Name: "Lamp", Price: models.MustParseMoney("100.00"), Quantity: 1}},
+			TransactionDate: &date,
+		}
+		resp, err := service.CalculateTax(req)
+		if err != nil {
+			t.Fatalf("%s on %s: expected no error, got %v", tt.country, tt.date, err)
+		}
+		if resp.Currency != tt.currency || resp.TotalTax.Currency != tt.currency {
+			t.Errorf("%s on %s: expected %s, got %s", tt.country, tt.date, tt.currency, resp.Currency)
+		}
+	}
+
+	// A Swedish sale cannot be charged in euros
+	req := &models.TaxRequest{
+		Address:  models.Address{Country: "SE", PostalCode: "111 22"},
+		Items:    []models.Item{{ID: "item1", Name: "Lamp", Price: models.MustParseMoney("100.00"), Quantity: 1}},
+		Currency: "EUR",
+	}
+	if _, err := service.CalculateTax(req); err == nil || !strings.Contains(err.Error(), "use SEK") {
+		t.Errorf("Expected EUR to be rejected for Sweden, got %v", err)
+	}
+}
+
+func TestNewEUEngine_CurrencyWithoutEuroRate(t *testing.T) {
+	table := loadEUTable(t)
+	delete(table.EuroRates, "SEK")
+	if _, err := NewEUEngine(table); err == nil || !strings.Contains(err.Error(), "no euro rate for it") {
+		t.Errorf("Expected an error for SEK without a euro rate, got %v", err)
+	}
+}
+
+func TestLedger_CountsEUSalesTowardOSSThreshold(t *testing.T) {
+	service := NewTaxService(DefaultRateProvider())
+	if err := service.NexusTracker().Configure(SellerNexus{SellerID: "shop", EUEstablishment: "IE"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	ledger, err := NewLedger(service, NewMemoryTransactionRepository())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	date := models.MustParseDate("2025-06-01")
+	sale := func(country, postalCode, price string) *models.TaxRequest {
+		return &models.TaxRequest{
+			Address:         models.Address{Country: country, PostalCode: postalCode},
+			Items:           []models.Item{{ID: "item1", Name: "Sofa", Price: models.MustParseMoney(price), Quantity: 1}},
+			SellerID:        "shop",
+			TransactionDate: &date,
+		}
+	}
+
+	// Domestic sales do not count
+	for code, req := range map[string]*models.TaxRequest{
+		"INV-1": sale("IE", "D02 X285", "20000.00"),
+		"INV-2": sale("FR", "75001", "9900.00"),
+	} {
+		if _, err := ledger.Create(code, req, true); err != nil {
+			t.Fatalf("%s: expected no error, got %v", code, err)
+		}
+	}
+	if sales := service.NexusTracker().EUSales("shop", 2025); sales.Sales.String() != "9900.00" {
+		t.Errorf("Expected 9900.00 of cross-border sales, got %s", sales.Sales)
+	}
+
+	// A sale that takes the total to the threshold exactly is still within it,
+	// so the seller charges Irish VAT, in euros
+	resp, err := service.CalculateTax(sale("SE", "111 22", "100.00"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if resp.Currency != "EUR" || resp.TotalTax.String() != "23.00" {
+		t.Errorf("Expected Irish VAT in EUR, got %s %s", resp.TotalTax, resp.Currency)
+	}
+
+	// After one more cross-border sale the same order passes it, so Swedish
+	// VAT is charged in SEK
+	if _, err := ledger.Create("INV-3", sale("DE", "10115", "0.01"), true); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp, err = service.CalculateTax(sale("SE", "111 22", "100.00"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if resp.Currency != "SEK" || resp.TotalTax.String() != "25.00" {
+		t.Errorf("Expected Swedish VAT in SEK, got %s %s", resp.TotalTax, resp.Currency)
+	}
+
+	// A sale in kronor counts in euros, and voiding it takes it back
+	if _, err := ledger.Create("INV-4", sale("SE", "111 22", "1110.00"), true); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if sales := service.NexusTracker().EUSales("shop", 2025); sales.Sales.String() != "10000.01" {
+		t.Errorf("Expected 1110.00 SEK to count as 100.00 EUR, got %s", sales.Sales)
+	}
+	if _, err := ledger.Void("INV-4", "order cancelled"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if sales := service.NexusTracker().EUSales("shop", 2025); sales.Sales.String() != "9900.01" {
+		t.Errorf("Expected the void to take the sale back, got %s", sales.Sales)
+	}
+}
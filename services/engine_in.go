@@ -0,0 +1,236 @@
+package services
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+//go:embed data/in_gst.json
+var defaultINGSTTable []byte
+
+// indiaPINPattern matches a six digit Indian postal index number
+var indiaPINPattern = regexp.MustCompile(`^[1-9][0-9]{5}$`)
+
+// indiaJurisdiction is the central government's taxing jurisdiction
+var indiaJurisdiction = models.Jurisdiction{Code: "IN", Name: "India", Type: JurisdictionCountry}
+
+// gstSlabs are the GST rates a slab may use
+var gstSlabs = map[models.Rate]bool{
+	0:      true,
+	50000:  true,
+	120000: true,
+	180000: true,
+	280000: true,
+}
+
+// GSTTable is the on-disk representation of the India GST table
+type GSTTable struct {
+	Version     string        `json:"version"`
+	Country     string        `json:"country"`
+	DefaultRate models.Rate   `json:"default_rate"`
+	States      []IndianState `json:"states"`
+	Slabs       []GSTSlab     `json:"slabs"`
+}
+
+// IndianState is a state or union territory; union territories without a
+// legislature levy UTGST in place of SGST
+type IndianState struct {
+	Code           string `json:"code"`
+	Name           string `json:"name"`
+	UnionTerritory bool   `json:"union_territory,omitempty"`
+}
+
+// GSTSlab assigns a GST rate and optional compensation cess to HSN/SAC codes
+// starting with HSNPrefix. A prefix may be listed once per effective period.
+type GSTSlab struct {
+	HSNPrefix   string       `json:"hsn_prefix"`
+	Description string       `json:"description,omitempty"`
+	Rate        models.Rate  `json:"rate"`
+	Cess        *models.Rate `json:"cess,omitempty"`
+	EffectivePeriod
+}
+
+// INEngine calculates India GST, splitting intra-state supplies into
+// CGST + SGST/UTGST and charging IGST on inter-state supplies
+type INEngine struct {
+	defaultRate models.Rate
+	states      map[string]IndianState // keyed by upper-case code and name
+	slabs       []GSTSlab              // longest prefix first
+}
+
+// NewINEngine creates an India GST engine from a GST table
+func NewINEngine(table *GSTTable) (*INEngine, error) {
+	if !gstSlabs[table.DefaultRate] {
+		return nil, fmt.Errorf("default rate %s%% is not a GST slab", table.DefaultRate)
+	}
+
+	states := make(map[string]IndianState, 2*len(table.States))
+	for _, state := range table.States {
+		states[strings.ToUpper(state.Code)] = state
+		states[strings.ToUpper(state.Name)] = state
+	}
+
+	slabs := make([]GSTSlab, len(table.Slabs))
+	copy(slabs, table.Slabs)
+	byPrefix := make(map[string][]GSTSlab, len(slabs))
+	for _, slab := range slabs {
+		if slab.HSNPrefix == "" {
+			return nil, fmt.Errorf("GST slab %q has no HSN prefix", slab.Description)
+		}
+		if !gstSlabs[slab.Rate] {
+			return nil, fmt.Errorf("HSN %s rate %s%% is not a GST slab", slab.HSNPrefix, slab.Rate)
+		}
+		if slab.Cess != nil && *slab.Cess < 0 {
+			return nil, fmt.Errorf("HSN %s has negative cess", slab.HSNPrefix)
+		}
+		if err := validatePeriods(slab, byPrefix[slab.HSNPrefix]); err != nil {
+			return nil, fmt.Errorf("HSN %s: %w", slab.HSNPrefix, err)
+		}
+		byPrefix[slab.HSNPrefix] = append(byPrefix[slab.HSNPrefix], slab)
+	}
+	sort.SliceStable(slabs, func(i, j int) bool {
+		return len(slabs[i].HSNPrefix) > len(slabs[j].HSNPrefix)
+	})
+
+	return &INEngine{
+		defaultRate: table.DefaultRate,
+		states:      states,
+		slabs:       slabs,
+	}, nil
+}
+
+// DefaultINEngine returns an India GST engine backed by the table bundled with the binary
+func DefaultINEngine() *INEngine {
+	var table GSTTable
+	decoder := json.NewDecoder(bytes.NewReader(defaultINGSTTable))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&table); err != nil {
+		panic(fmt.Sprintf("bundled GST table is invalid: %v", err))
+	}
+	engine, err := NewINEngine(&table)
+	if err != nil {
+		panic(fmt.Sprintf("bundled GST table is invalid: %v", err))
+	}
+	return engine
+}
+
+// Currency returns INR
+func (e *INEngine) Currency() string {
+	return "INR"
+}
+
+// ValidateAddress requires a known state or union territory and a valid PIN code
+func (e *INEngine) ValidateAddress(address *models.Address) error {
+	if address.State == "" {
+		return fmt.Errorf("state is required")
+	}
+	if _, ok := e.state(address.State); !ok {
+		return fmt.Errorf("state %q is not an Indian state or union territory", address.State)
+	}
+
+	pin := strings.ReplaceAll(address.ZipCode, " ", "")
+	if pin == "" {
+		pin = strings.ReplaceAll(address.PostalCode, " ", "")
+	}
+	if pin == "" {
+		return fmt.Errorf("zipcode is required")
+	}
+	if !indiaPINPattern.MatchString(pin) {
+		return fmt.Errorf("zipcode %q is not a valid Indian PIN code", pin)
+	}
+	return nil
+}
+
+// ValidateRequest requires the seller's state or union territory, which
+// decides between CGST + SGST and IGST
+func (e *INEngine) ValidateRequest(req *models.TaxRequest) error {
+	_, err := e.sellerState(req)
+	return err
+}
+
+// Jurisdiction returns the place of supply, e.g. "Karnataka, IN"
+func (e *INEngine) Jurisdiction(req *models.TaxRequest) string {
+	address := &req.Address
+	if state, ok := e.state(address.State); ok {
+		return fmt.Sprintf("%s, IN", state.Name)
+	}
+	return fmt.Sprintf("%s, IN", address.State)
+}
+
+// TaxRates returns the GST components for an item. The slab comes from the
+// longest HSN/SAC prefix matching the item's tax code; supplies within the
+// seller's state are split equally between CGST and SGST (UTGST in union
+// territories), other supplies attract IGST. Compensation cess is added on top.
+// Only slabs in force on the transaction date are considered.
+func (e *INEngine) TaxRates(req *models.TaxRequest, item *models.Item) ([]AppliedRate, error) {
+	seller, err := e.sellerState(req)
+	if err != nil {
+		return nil, err
+	}
+	buyer, _ := e.state(req.Address.State)
+
+	rate, cess := e.slabFor(item.TaxCode, transactionDate(req))
+
+	var applied []AppliedRate
+	if seller.Code == buyer.Code {
+		half := rate / 2
+		stateTax := "SGST"
+		if buyer.UnionTerritory {
+			stateTax = "UTGST"
+		}
+		applied = append(applied,
+			AppliedRate{Name: "CGST", Rate: half, Jurisdiction: indiaJurisdiction},
+			AppliedRate{Name: stateTax, Rate: rate - half, Jurisdiction: models.Jurisdiction{
+				Code: buyer.Code, Name: buyer.Name, Type: JurisdictionState,
+			}},
+		)
+	} else {
+		applied = append(applied, AppliedRate{Name: "IGST", Rate: rate, Jurisdiction: indiaJurisdiction})
+	}
+	if cess > 0 {
+		applied = append(applied, AppliedRate{Name: "Compensation Cess", Rate: cess, Jurisdiction: indiaJurisdiction})
+	}
+	return applied, nil
+}
+
+// slabFor returns the GST rate and cess for an HSN/SAC code on date
+func (e *INEngine) slabFor(code string, date models.Date) (models.Rate, models.Rate) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if code != "" {
+		for _, slab := range e.slabs {
+			if strings.HasPrefix(code, slab.HSNPrefix) && slab.Covers(date) {
+				var cess models.Rate
+				if slab.Cess != nil {
+					cess = *slab.Cess
+				}
+				return slab.Rate, cess
+			}
+		}
+	}
+	return e.defaultRate, 0
+}
+
+// state looks up a state or union territory by code or name
+func (e *INEngine) state(codeOrName string) (IndianState, bool) {
+	state, ok := e.states[strings.ToUpper(strings.TrimSpace(codeOrName))]
+	return state, ok
+}
+
+// sellerState returns the seller's registered state or union territory
+func (e *INEngine) sellerState(req *models.TaxRequest) (IndianState, error) {
+	if strings.TrimSpace(req.SellerState) == "" {
+		return IndianState{}, fmt.Errorf("seller_state is required for India GST")
+	}
+	seller, ok := e.state(req.SellerState)
+	if !ok {
+		return IndianState{}, fmt.Errorf("seller_state %q is not an Indian state or union territory", req.SellerState)
+	}
+	return seller, nil
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+func TestCalculateTax_IndiaIntraState(t *testing.T) {
+	service := NewTaxService(DefaultRateProvider())
+
+	req := &models.TaxRequest{
+		Address: models.Address{
+			Street:  "MG Road",
+			City:    "Bangalore",
+			State:   "Karnataka",
+			Country: "IN",
+			ZipCode: "560001",
+		},
+		SellerState: "KA",
+		Items: []models.Item{
+			{ID: "MOBILE-001", Name: "Smartphone", Price: models.MustParseMoney("25000.00"), Quantity: 1, TaxCode: "85171300"},
+			{ID: "BOOK-001", Name: "Novel", Price: models.MustParseMoney("499.00"), Quantity: 1, TaxCode: "4901"},
+		},
+	}
+
+	resp, err := service.CalculateTax(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if resp.TaxJurisdiction != "Karnataka, IN" {
+		t.Errorf("Expected jurisdiction 'Karnataka, IN', got %s", resp.TaxJurisdiction)
+	}
+
+	phone := resp.Items[0]
+	if len(phone.Taxes) != 2 || phone.Taxes[0].Name != "CGST" || phone.Taxes[1].Name != "SGST" {
+		t.Fatalf("Expected CGST + SGST, got %+v", phone.Taxes)
+	}
+	if phone.Taxes[0].TaxAmount.String() != "2250.00" || phone.Taxes[1].TaxAmount.String() != "2250.00" {
+		t.Errorf("Expected 2250.00 each, got %s and %s", phone.Taxes[0].TaxAmount, phone.Taxes[1].TaxAmount)
+	}
+	if phone.TaxRate.String() != "18.00" {
+		t.Errorf("Expected combined rate 18.00, got %s", phone.TaxRate)
+	}
+
+	if !resp.Items[1].TaxAmount.IsZero() {
+		t.Errorf("Expected books to be nil rated, got %s", resp.Items[1].TaxAmount)
+	}
+}
+
+func TestCalculateTax_IndiaInterStateWithCess(t *testing.T) {
+	service := NewTaxService(DefaultRateProvider())
+
+	req := &models.TaxRequest{
+		Address:     models.Address{State: "MH", Country: "IN", ZipCode: "400001"},
+		SellerState: "Karnataka",
+		Items: []models.Item{
+			{ID: "CAR-001", Name: "Car", Price: models.MustParseMoney("1000000.00"), Quantity: 1, TaxCode: "8703"},
+		},
+	}
+
+	resp, err := service.CalculateTax(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	taxes := resp.Items[0].Taxes
+	if len(taxes) != 2 || taxes[0].Name != "IGST" || taxes[1].Name != "Compensation Cess" {
+		t.Fatalf("Expected IGST + cess, got %+v", taxes)
+	}
+	if taxes[0].TaxAmount.String() != "280000.00" || taxes[1].TaxAmount.String() != "150000.00" {
+		t.Errorf("Unexpected component amounts %s, %s", taxes[0].TaxAmount, taxes[1].TaxAmount)
+	}
+	if resp.TotalTax.String() != "430000.00" {
+		t.Errorf("Expected total tax 430000.00, got %s", resp.TotalTax)
+	}
+}
+
+func TestCalculateTax_IndiaUnionTerritory(t *testing.T) {
+	service := NewTaxService(DefaultRateProvider())
+
+	req := &models.TaxRequest{
+		Address:     models.Address{State: "Chandigarh", Country: "IN", ZipCode: "160017"},
+		SellerState: "CH",
+		Items:       []models.Item{{ID: "svc", Name: "Consulting", Price: models.MustParseMoney("1000.00"), Quantity: 1, TaxCode: "998311"}},
+	}
+
+	resp, err := service.CalculateTax(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if resp.Items[0].Taxes[1].Name != "UTGST" {
+		t.Errorf("Expected UTGST in a union territory, got %+v", resp.Items[0].Taxes)
+	}
+}
+
+func TestCalculateTax_IndiaSellerState(t *testing.T) {
+	service := NewTaxService(DefaultRateProvider())
+
+	for _, sellerState := range []string{"", " ", "Atlantis"} {
+		req := &models.TaxRequest{
+			Address:     models.Address{State: "KA", Country: "IN", ZipCode: "560001"},
+			SellerState: sellerState,
+			Items:       []models.Item{{ID: "svc", Name: "Consulting", Price: models.MustParseMoney("1000.00"), Quantity: 1}},
+		}
+		if _, err := service.CalculateTax(req); err == nil || !strings.Contains(err.Error(), "seller_state") {
+			t.Errorf("%q: expected a seller_state error, got %v", sellerState, err)
+		}
+	}
+}
+
+func TestINEngine_ValidateAddress(t *testing.T) {
+	engine := DefaultINEngine()
+
+	if err := engine.ValidateAddress(&models.Address{State: "Karnataka", ZipCode: "560001"}); err != nil {
+		t.Errorf("Expected valid address, got %v", err)
+	}
+	if err := engine.ValidateAddress(&models.Address{State: "Atlantis", ZipCode: "560001"}); err == nil {
+		t.Error("Expected error for unknown state")
+	}
+	if err := engine.ValidateAddress(&models.Address{State: "KA", ZipCode: "056001"}); err == nil {
+		t.Error("Expected error for invalid PIN code")
+	}
+}
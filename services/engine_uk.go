@@ -0,0 +1,152 @@
+package services
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+//go:embed data/gb_vat.json
+var defaultUKVATTable []byte
+
+// ukPostcodePattern matches a full UK postcode with or without the space
+// between the outward and inward codes, plus the special GIR 0AA
+var ukPostcodePattern = regexp.MustCompile(`^(GIR ?0AA|[A-Z]{1,2}[0-9][A-Z0-9]? ?[0-9][A-Z]{2})$`)
+
+// ukJurisdiction is HMRC's taxing jurisdiction
+var ukJurisdiction = models.Jurisdiction{Code: "GB", Name: "United Kingdom", Type: JurisdictionCountry}
+
+// ukStandardBand is applied to items without a tax category
+const ukStandardBand = "standard"
+
+// VATTable is the on-disk representation of a VAT band table
+type VATTable struct {
+	Version    string            `json:"version"`
+	Country    string            `json:"country"`
+	Bands      []NamedRate       `json:"bands"`
+	Categories map[string]string `json:"categories"` // product category -> band name
+}
+
+// NamedRate is a rate table entry such as the VAT standard rate or a
+// provincial sales tax. A name may be listed once per effective period.
+type NamedRate struct {
+	Name string      `json:"name"`
+	Rate models.Rate `json:"rate"`
+	EffectivePeriod
+}
+
+// UKEngine calculates UK VAT by rate band
+type UKEngine struct {
+	bands      map[string][]NamedRate // keyed by band name, one per period
+	categories map[string]string
+}
+
+// NewUKEngine creates a UK VAT engine from a band table
+func NewUKEngine(table *VATTable) (*UKEngine, error) {
+	bands := make(map[string][]NamedRate, len(table.Bands))
+	for _, band := range table.Bands {
+		if band.Rate < 0 || band.Rate >= models.RateScale {
+			return nil, fmt.Errorf("VAT band %s has invalid rate %s%%", band.Name, band.Rate)
+		}
+		name := strings.ToLower(band.Name)
+		if err := validatePeriods(band, bands[name]); err != nil {
+			return nil, fmt.Errorf("VAT band %s: %w", band.Name, err)
+		}
+		bands[name] = append(bands[name], band)
+	}
+	if _, ok := bands[ukStandardBand]; !ok {
+		return nil, fmt.Errorf("VAT table has no %s band", ukStandardBand)
+	}
+
+	categories := make(map[string]string, len(table.Categories))
+	for category, band := range table.Categories {
+		band = strings.ToLower(band)
+		if _, ok := bands[band]; !ok {
+			return nil, fmt.Errorf("category %s refers to unknown VAT band %s", category, band)
+		}
+		categories[strings.ToLower(category)] = band
+	}
+
+	return &UKEngine{bands: bands, categories: categories}, nil
+}
+
+// DefaultUKEngine returns a UK VAT engine backed by the band table bundled with the binary
+func DefaultUKEngine() *UKEngine {
+	var table VATTable
+	decoder := json.NewDecoder(bytes.NewReader(defaultUKVATTable))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&table); err != nil {
+		panic(fmt.Sprintf("bundled UK VAT table is invalid: %v", err))
+	}
+	engine, err := NewUKEngine(&table)
+	if err != nil {
+		panic(fmt.Sprintf("bundled UK VAT table is invalid: %v", err))
+	}
+	return engine
+}
+
+// Currency returns GBP
+func (e *UKEngine) Currency() string {
+	return "GBP"
+}
+
+// ValidateAddress requires a well-formed UK postcode
+func (e *UKEngine) ValidateAddress(address *models.Address) error {
+	postcode := ukPostcode(address)
+	if postcode == "" {
+		return fmt.Errorf("postal_code is required")
+	}
+	if !ukPostcodePattern.MatchString(postcode) {
+		return fmt.Errorf("postal_code %q is not a valid UK postcode", postcode)
+	}
+	return nil
+}
+
+// Jurisdiction returns e.g. "London, UK"
+func (e *UKEngine) Jurisdiction(req *models.TaxRequest) string {
+	address := &req.Address
+	if address.City == "" {
+		return "UK"
+	}
+	return fmt.Sprintf("%s, UK", address.City)
+}
+
+// TaxRates returns the VAT band for the item's tax category at the rate in
+// force on the transaction date. The category may name a band directly
+// ("standard", "reduced", "zero") or a product category mapped to a band;
+// items without a category are standard rated.
+func (e *UKEngine) TaxRates(req *models.TaxRequest, item *models.Item) ([]AppliedRate, error) {
+	band := strings.ToLower(strings.TrimSpace(item.TaxCategory))
+	if band == "" {
+		band = ukStandardBand
+	}
+	if mapped, ok := e.categories[band]; ok {
+		band = mapped
+	}
+
+	periods, ok := e.bands[band]
+	if !ok {
+		return nil, fmt.Errorf("tax_category %q is not a known UK VAT category", item.TaxCategory)
+	}
+	date := transactionDate(req)
+	for _, period := range periods {
+		if period.Covers(date) {
+			return []AppliedRate{{Name: "VAT " + band + " rate", Rate: period.Rate, Jurisdiction: ukJurisdiction}}, nil
+		}
+	}
+	return nil, fmt.Errorf("no UK VAT %s rate on %s", band, date)
+}
+
+// ukPostcode returns the address's postcode normalised to upper case
+func ukPostcode(address *models.Address) string {
+	postcode := address.PostalCode
+	if postcode == "" {
+		postcode = address.ZipCode
+	}
+	return strings.ToUpper(strings.TrimSpace(postcode))
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+func TestUKEngine_ValidateAddress(t *testing.T) {
+	engine := DefaultUKEngine()
+
+	valid := []string{"NW1 6XE", "sw1a1aa", "M1 1AE", "EC1A 1BB", "GIR 0AA"}
+	for _, postcode := range valid {
+		if err := engine.ValidateAddress(&models.Address{PostalCode: postcode}); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", postcode, err)
+		}
+	}
+
+	invalid := []string{"", "10001", "NW1", "NW1 6X", "123 ABC"}
+	for _, postcode := range invalid {
+		if err := engine.ValidateAddress(&models.Address{PostalCode: postcode}); err == nil {
+			t.Errorf("Expected %q to be rejected", postcode)
+		}
+	}
+}
+
+func TestCalculateTax_UKVATBands(t *testing.T) {
+	service := NewTaxService(DefaultRateProvider())
+
+	req := &models.TaxRequest{
+		Address: models.Address{
+			Street:     "221B Baker Street",
+			City:       "London",
+			Country:    "UK",
+			PostalCode: "NW1 6XE",
+		},
+		Items: []models.Item{
+			{ID: "laptop", Name: "Laptop", Price: models.MustParseMoney("500.00"), Quantity: 1},
+			{ID: "book", Name: "Book", Price: models.MustParseMoney("29.99"), Quantity: 1, TaxCategory: "books"},
+			{ID: "seat", Name: "Child Car Seat", Price: models.MustParseMoney("100.00"), Quantity: 2, TaxCategory: "child_car_seats"},
+			{ID: "cable", Name: "Cable", Price: models.MustParseMoney("10.00"), Quantity: 1, TaxCategory: "standard"},
+		},
+	}
+
+	resp, err := service.CalculateTax(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if resp.Currency != "GBP" {
+		t.Errorf("Expected GBP, got %s", resp.Currency)
+	}
+	if resp.TaxJurisdiction != "London, UK" {
+		t.Errorf("Expected jurisdiction 'London, UK', got %s", resp.TaxJurisdiction)
+	}
+
+	expected := []struct {
+		name    string
+		rate    string
+		taxable string
+		tax     string
+	}{
+		{"VAT standard rate", "20.00", "510.00", "102.00"},
+		{"VAT zero rate", "0.00", "29.99", "0.00"},
+		{"VAT reduced rate", "5.00", "200.00", "10.00"},
+	}
+	if len(resp.TaxBreakdown) != len(expected) {
+		t.Fatalf("Expected %d VAT bands, got %+v", len(expected), resp.TaxBreakdown)
+	}
+	for i, want := range expected {
+		got := resp.TaxBreakdown[i]
+		if got.Name != want.name || got.Rate.String() != want.rate ||
+			got.TaxableAmount.String() != want.taxable || got.TaxAmount.String() != want.tax {
+			t.Errorf("Band %d: expected %+v, got %s %s %s %s", i, want, got.Name, got.Rate, got.TaxableAmount, got.TaxAmount)
+		}
+	}
+
+	if resp.TotalTax.String() != "112.00" {
+		t.Errorf("Expected total VAT 112.00, got %s", resp.TotalTax)
+	}
+}
+
+func TestCalculateTax_UKUnknownCategory(t *testing.T) {
+	service := NewTaxService(DefaultRateProvider())
+
+	req := &models.TaxRequest{
+		Address: models.Address{Country: "GB", PostalCode: "NW1 6XE"},
+		Items: []models.Item{
+			{ID: "item1", Name: "Product A", Price: models.MustParseMoney("10.00"), Quantity: 1, TaxCategory: "luxury"},
+		},
+	}
+
+	if _, err := service.CalculateTax(req); err == nil {
+		t.Fatal("Expected error for unknown VAT category, got nil")
+	}
+}
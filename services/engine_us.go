@@ -0,0 +1,141 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+// USEngine calculates US sales tax from a state rate table and a product
+// taxability matrix
+type USEngine struct {
+	rates      RateProvider
+	taxability *TaxabilityMatrix
+}
+
+// NewUSEngine creates a US sales tax engine backed by the given rate provider.
+// A nil taxability matrix taxes every product at the full rate.
+func NewUSEngine(rates RateProvider, taxability *TaxabilityMatrix) *USEngine {
+	return &USEngine{rates: rates, taxability: taxability}
+}
+
+// Currency returns USD
+func (e *USEngine) Currency() string {
+	return "USD"
+}
+
+// ValidateAddress requires a state and ZIP code
+func (e *USEngine) ValidateAddress(address *models.Address) error {
+	if address.State == "" {
+		return fmt.Errorf("state is required")
+	}
+	if address.ZipCode == "" && address.PostalCode == "" {
+		return fmt.Errorf("zipcode is required")
+	}
+	return nil
+}
+
+// Jurisdiction returns the state-level jurisdiction, e.g. "NY, USA"
+func (e *USEngine) Jurisdiction(req *models.TaxRequest) string {
+	return fmt.Sprintf("%s, USA", req.Address.State)
+}
+
+// TaxRates returns one sales tax per jurisdiction layer in force on the
+// transaction date at the address the sale is sourced to: state, then county,
+// city and special districts. Layers that exempt the
+// item's category are left out and reduced rates replace the state rate.
+func (e *USEngine) TaxRates(req *models.TaxRequest, item *models.Item) ([]AppliedRate, error) {
+	lookup, _, err := sourceLayers(e.rates, req, item)
+	if err != nil {
+		return nil, err
+	}
+	rule, err := e.rule(req, item)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make([]AppliedRate, 0, len(lookup.Layers))
+	for _, layer := range lookup.Layers {
+		rate := layer.Rate
+		if rule != nil {
+			var taxed bool
+			if rate, taxed = rule.layerRate(layer); !taxed {
+				continue
+			}
+		}
+		applied = append(applied, AppliedRate{
+			Name:         layer.Jurisdiction.Name + " sales tax",
+			Rate:         rate,
+			Jurisdiction: layer.Jurisdiction,
+		})
+	}
+	return applied, nil
+}
+
+// Taxability returns the product taxability rule applied to the item, or nil
+// when it is taxed in full
+func (e *USEngine) Taxability(req *models.TaxRequest, item *models.Item) *models.ItemTaxability {
+	rule, err := e.rule(req, item)
+	if err != nil || rule == nil {
+		return nil
+	}
+	return rule.applied()
+}
+
+// ChargeTaxability returns the destination state's rule for a charge type,
+// e.g. shipping that is exempt when separately stated or that follows the
+// taxability of the goods
+func (e *USEngine) ChargeTaxability(req *models.TaxRequest, charge *models.Charge) (*models.ItemTaxability, error) {
+	if e.taxability == nil {
+		return nil, nil
+	}
+	rule, err := e.taxability.Rule(req.Address.State, charge.Type, charge.Amount, transactionDate(req))
+	if err != nil || rule == nil {
+		return nil, err
+	}
+	return rule.applied(), nil
+}
+
+// Sourcing reports whether the item was taxed at its destination's or its
+// ship from address's rates
+func (e *USEngine) Sourcing(req *models.TaxRequest, item *models.Item) string {
+	_, method, err := sourceLayers(e.rates, req, item)
+	if err != nil {
+		return ""
+	}
+	return method
+}
+
+// rule looks up the taxability rule for the item in the destination state
+func (e *USEngine) rule(req *models.TaxRequest, item *models.Item) (*TaxabilityRule, error) {
+	if e.taxability == nil {
+		return nil, nil
+	}
+	return e.taxability.Rule(req.Address.State, item.TaxCategory, item.Price, transactionDate(req))
+}
+
+// MatchLevel reports how precisely the address the order was taxed at was
+// matched to local jurisdictions, e.g. "zip4" or "state": the ship from
+// address for items sourced to their origin, otherwise the destination. When
+// items were taxed at differently matched addresses the least precise level
+// is reported.
+func (e *USEngine) MatchLevel(req *models.TaxRequest) string {
+	if len(req.Items) == 0 {
+		lookup, err := e.rates.JurisdictionsFor(&req.Address, transactionDate(req))
+		if err != nil {
+			return ""
+		}
+		return lookup.MatchLevel
+	}
+	level := ""
+	for i := range req.Items {
+		lookup, _, err := sourceLayers(e.rates, req, &req.Items[i])
+		if err != nil {
+			return ""
+		}
+		if matchRank(lookup.MatchLevel) > matchRank(level) {
+			level = lookup.MatchLevel
+		}
+	}
+	return level
+}
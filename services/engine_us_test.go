@@ -0,0 +1,99 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+func TestUSEngine_TaxRate(t *testing.T) {
+	engine := NewUSEngine(DefaultRateProvider(), nil)
+
+	tests := []struct {
+		state   string
+		minRate models.Rate
+		maxRate models.Rate
+	}{
+		{"NY", models.MustParseRate("8"), models.MustParseRate("9")},
+		{"CA", models.MustParseRate("8"), models.MustParseRate("9")},
+		{"TX", models.MustParseRate("8"), models.MustParseRate("9")},
+		{"FL", models.MustParseRate("6"), models.MustParseRate("8")},
+		{"DE", 0, 0}, // No sales tax
+		{"MT", 0, 0}, // No sales tax
+		{"OR", 0, 0}, // No sales tax
+	}
+
+	for _, tt := range tests {
+		address := &models.Address{
+			State:   tt.state,
+			Country: "US",
+			ZipCode: "12345",
+		}
+
+		rates, err := engine.TaxRates(&models.TaxRequest{Address: *address}, &models.Item{})
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", tt.state, err)
+		}
+		var rate models.Rate
+		for _, applied := range rates {
+			rate += applied.Rate
+		}
+
+		if rate < tt.minRate || rate > tt.maxRate {
+			t.Errorf("Tax rate for %s (%s%%) is outside expected range [%s%%, %s%%]",
+				tt.state, rate, tt.minRate, tt.maxRate)
+		}
+	}
+}
+
+func TestUSEngine_ValidateAddress(t *testing.T) {
+	engine := NewUSEngine(DefaultRateProvider(), nil)
+
+	if err := engine.ValidateAddress(&models.Address{State: "NY", ZipCode: "10001"}); err != nil {
+		t.Errorf("Expected valid address, got %v", err)
+	}
+	if err := engine.ValidateAddress(&models.Address{ZipCode: "10001"}); err == nil {
+		t.Error("Expected error for missing state")
+	}
+	if err := engine.ValidateAddress(&models.Address{State: "NY"}); err == nil {
+		t.Error("Expected error for missing zipcode")
+	}
+}
+
+func TestCalculateTax_USJurisdictionLayers(t *testing.T) {
+	service := NewTaxService(DefaultRateProvider())
+
+	req := &models.TaxRequest{
+		Address: models.Address{City: "Chicago", State: "IL", Country: "US", ZipCode: "60601"},
+		Items:   []models.Item{{ID: "item1", Name: "Product A", Price: models.MustParseMoney("100.00"), Quantity: 2}},
+	}
+
+	resp, err := service.CalculateTax(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []struct {
+		code, kind, tax string
+	}{
+		{"IL", JurisdictionState, "12.50"},
+		{"IL-COOK", JurisdictionCounty, "3.50"},
+		{"IL-CHI", JurisdictionCity, "2.50"},
+		{"IL-RTA", JurisdictionSpecial, "2.00"},
+	}
+	if len(resp.Jurisdictions) != len(expected) {
+		t.Fatalf("Expected %d jurisdictions, got %+v", len(expected), resp.Jurisdictions)
+	}
+	for i, want := range expected {
+		got := resp.Jurisdictions[i]
+		if got.Code != want.code || got.Type != want.kind || got.TaxAmount.String() != want.tax {
+			t.Errorf("Layer %d: expected %+v, got %s %s %s", i, want, got.Code, got.Type, got.TaxAmount)
+		}
+		if got.TaxableAmount.String() != "200.00" {
+			t.Errorf("Layer %d: expected taxable 200.00, got %s", i, got.TaxableAmount)
+		}
+	}
+	if resp.TotalTax.String() != "20.50" {
+		t.Errorf("Expected total tax 20.50, got %s", resp.TotalTax)
+	}
+}
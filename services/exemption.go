@@ -0,0 +1,336 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+// exemptReasons are the reasons a certificate may give for exemption
+var exemptReasons = map[string]bool{
+	"resale":        true,
+	"nonprofit":     true,
+	"government":    true,
+	"agricultural":  true,
+	"manufacturing": true,
+	"other":         true,
+}
+
+// ErrCertificateNotFound is returned for a certificate ID that is not on file
+var ErrCertificateNotFound = errors.New("exemption certificate not found")
+
+// ErrCertificateExists is returned when creating a certificate whose ID is
+// already on file
+var ErrCertificateExists = errors.New("exemption certificate already exists")
+
+// InvalidCertificateError is returned for a certificate that is missing a
+// required field or names an unknown reason, country or jurisdiction
+type InvalidCertificateError struct {
+	Message string
+}
+
+func (e *InvalidCertificateError) Error() string {
+	return e.Message
+}
+
+// invalidCertificate returns an InvalidCertificateError with a formatted
+// message
+func invalidCertificate(format string, args ...interface{}) error {
+	return &InvalidCertificateError{Message: fmt.Sprintf(format, args...)}
+}
+
+// ExpiredCertificateError is returned when an order relies on a certificate
+// that expired before the transaction date
+type ExpiredCertificateError struct {
+	CertificateID string
+	ExpiresOn     models.Date
+	Date          models.Date
+}
+
+func (e *ExpiredCertificateError) Error() string {
+	return fmt.Sprintf("exemption certificate %s expired on %s, before the transaction date %s",
+		e.CertificateID, e.ExpiresOn, e.Date)
+}
+
+// ExemptionStore stores buyers' exemption certificates. Implementations must
+// be safe for concurrent use.
+type ExemptionStore interface {
+	// Create adds a new certificate, returning ErrCertificateExists if its ID
+	// is taken
+	Create(cert models.ExemptionCertificate) error
+	// Update replaces a certificate, returning ErrCertificateNotFound if its
+	// ID is not on file
+	Update(cert models.ExemptionCertificate) error
+	// Delete removes a certificate, returning ErrCertificateNotFound if its ID
+	// is not on file
+	Delete(id string) error
+	// Certificate returns one certificate or ErrCertificateNotFound
+	Certificate(id string) (*models.ExemptionCertificate, error)
+	// List returns every certificate ordered by ID
+	List() ([]models.ExemptionCertificate, error)
+	// CertificatesFor returns the customer's certificates ordered by ID
+	CertificatesFor(customerID string) ([]models.ExemptionCertificate, error)
+}
+
+// normalizeCertificate trims the certificate's fields, sets its country and
+// upper-cases its jurisdiction codes, then checks it names a known reason and
+// at least one jurisdiction
+func normalizeCertificate(cert *models.ExemptionCertificate) error {
+	cert.ID = strings.TrimSpace(cert.ID)
+	cert.CustomerID = strings.TrimSpace(cert.CustomerID)
+	cert.Reason = strings.ToLower(strings.TrimSpace(cert.Reason))
+	cert.Country = certificateCountry(cert.Country)
+	codes := make([]string, 0, len(cert.Jurisdictions))
+	for _, code := range cert.Jurisdictions {
+		if code = strings.ToUpper(strings.TrimSpace(code)); code != "" && !containsString(codes, code) {
+			codes = append(codes, code)
+		}
+	}
+	cert.Jurisdictions = codes
+	return validateCertificate(cert)
+}
+
+// validateCertificate checks a certificate names a reason and at least one
+// jurisdiction
+func validateCertificate(cert *models.ExemptionCertificate) error {
+	if cert.ID == "" {
+		return invalidCertificate("certificate id is required")
+	}
+	if !exemptReasons[cert.Reason] {
+		return invalidCertificate("certificate %s has invalid reason %q", cert.ID, cert.Reason)
+	}
+	if country := certificateCountry(cert.Country); len(country) != 2 || !isJurisdictionCode(country) {
+		return invalidCertificate("certificate %s has invalid country %q", cert.ID, cert.Country)
+	}
+	if len(cert.Jurisdictions) == 0 {
+		return invalidCertificate("certificate %s lists no jurisdictions", cert.ID)
+	}
+	for _, code := range cert.Jurisdictions {
+		if !isJurisdictionCode(code) {
+			return invalidCertificate("certificate %s has invalid jurisdiction %q", cert.ID, code)
+		}
+	}
+	return nil
+}
+
+// isJurisdictionCode reports whether code looks like a state, local or
+// country code such as "NY", "NY-MCTD" or "GB"
+func isJurisdictionCode(code string) bool {
+	if code == "" {
+		return false
+	}
+	for _, c := range strings.ToUpper(code) {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckCertificate reports whether the certificate exempts sales in the
+// jurisdiction on the date, by the rule orders are exempted by: the
+// certificate was issued in the address's country, US when the address has
+// none, and lists the jurisdiction's code, the country's code, or for a US
+// state or local jurisdiction the address's state.
+func CheckCertificate(cert *models.ExemptionCertificate, jurisdiction string, address *models.Address, date models.Date) models.CertificateValidity {
+	jurisdiction = strings.ToUpper(strings.TrimSpace(jurisdiction))
+	validity := models.CertificateValidity{
+		CertificateID: cert.ID,
+		Jurisdiction:  jurisdiction,
+		Date:          date,
+	}
+	layer := models.Jurisdiction{Code: jurisdiction}
+	if jurisdiction == certificateCountry(address.Country) {
+		layer.Type = JurisdictionCountry
+	}
+	switch {
+	case certificateCountry(cert.Country) != certificateCountry(address.Country):
+		validity.Reason = fmt.Sprintf("certificate was issued in %s, not %s",
+			certificateCountry(cert.Country), certificateCountry(address.Country))
+	case !certificateCovers(cert, layer, address):
+		validity.Reason = fmt.Sprintf("certificate does not cover %s", jurisdiction)
+	case cert.ExpiresOn != nil && cert.ExpiresOn.Before(date):
+		validity.Reason = fmt.Sprintf("certificate expired on %s", cert.ExpiresOn)
+	default:
+		validity.Valid = true
+	}
+	return validity
+}
+
+// SetExemptionStore replaces the store the service looks up certificates in
+func (s *TaxService) SetExemptionStore(store ExemptionStore) {
+	s.exemptions = store
+}
+
+// ExemptionStore returns the store the service looks up certificates in
+func (s *TaxService) ExemptionStore() ExemptionStore {
+	return s.exemptions
+}
+
+// Today returns the service's current date in UTC, the default transaction
+// date
+func (s *TaxService) Today() models.Date {
+	return models.DateOf(s.now().UTC())
+}
+
+// ExpiringCertificates returns the certificates that expire within the next
+// days days, including today, soonest first
+func (s *TaxService) ExpiringCertificates(days int) ([]models.ExemptionCertificate, error) {
+	if days < 0 {
+		return nil, fmt.Errorf("days must not be negative")
+	}
+	certs, err := s.exemptions.List()
+	if err != nil {
+		return nil, err
+	}
+
+	today := s.Today()
+	last := today.AddDays(days)
+	var expiring []models.ExemptionCertificate
+	for _, cert := range certs {
+		if cert.ExpiresOn != nil && !cert.ExpiresOn.Before(today) && !cert.ExpiresOn.After(last) {
+			expiring = append(expiring, cert)
+		}
+	}
+	sortCertificates(expiring, func(a, b *models.ExemptionCertificate) bool {
+		return a.ExpiresOn.Before(*b.ExpiresOn)
+	})
+	return expiring, nil
+}
+
+// rateFilter removes the rates an order does not pay, e.g. those covered by an
+// exemption certificate
+type rateFilter func(applied []AppliedRate) ([]AppliedRate, error)
+
+// exemptionSet applies a buyer's certificates to the rates on an order and
+// records which certificates were used
+type exemptionSet struct {
+	address *models.Address
+	date    models.Date
+	certs   []models.ExemptionCertificate
+	used    []models.AppliedExemption
+}
+
+// exemptionsFor collects the certificates presented with the request, named
+// by its certificate_id and on file for its customer. A certificate named by
+// ID must be unexpired on the transaction date; the customer's expired
+// certificates are left out so the tax they no longer cover is charged.
+func (s *TaxService) exemptionsFor(req *models.TaxRequest) (*exemptionSet, error) {
+	set := &exemptionSet{address: &req.Address, date: transactionDate(req)}
+	if req.Exemption != nil {
+		set.certs = append(set.certs, *req.Exemption)
+	}
+	if req.CertificateID != "" {
+		cert, err := s.exemptions.Certificate(req.CertificateID)
+		if err != nil {
+			return nil, fmt.Errorf("certificate %s: %w", req.CertificateID, err)
+		}
+		if req.CustomerID != "" && cert.CustomerID != req.CustomerID {
+			return nil, fmt.Errorf("certificate %s does not belong to customer %s", cert.ID, req.CustomerID)
+		}
+		if cert.ExpiresOn != nil && cert.ExpiresOn.Before(set.date) {
+			return nil, &ExpiredCertificateError{CertificateID: cert.ID, ExpiresOn: *cert.ExpiresOn, Date: set.date}
+		}
+		set.certs = append(set.certs, *cert)
+	} else if req.CustomerID != "" {
+		certs, err := s.exemptions.CertificatesFor(req.CustomerID)
+		if err != nil {
+			return nil, err
+		}
+		for _, cert := range certs {
+			if cert.ExpiresOn == nil || !cert.ExpiresOn.Before(set.date) {
+				set.certs = append(set.certs, cert)
+			}
+		}
+	}
+	return set, nil
+}
+
+// filter removes the rates covered by an unexpired certificate. A rate
+// covered only by an expired certificate presented with the order is an
+// error rather than being silently charged.
+func (e *exemptionSet) filter(applied []AppliedRate) ([]AppliedRate, error) {
+	if len(e.certs) == 0 {
+		return applied, nil
+	}
+
+	taxed := applied[:0:0]
+	for _, rate := range applied {
+		var expired *models.ExemptionCertificate
+		exempt := false
+		for i := range e.certs {
+			cert := &e.certs[i]
+			if !certificateCovers(cert, rate.Jurisdiction, e.address) {
+				continue
+			}
+			if cert.ExpiresOn != nil && cert.ExpiresOn.Before(e.date) {
+				expired = cert
+				continue
+			}
+			e.record(cert, rate.Jurisdiction)
+			exempt = true
+			break
+		}
+		if exempt {
+			continue
+		}
+		if expired != nil {
+			return nil, &ExpiredCertificateError{CertificateID: expired.ID, ExpiresOn: *expired.ExpiresOn, Date: e.date}
+		}
+		taxed = append(taxed, rate)
+	}
+	return taxed, nil
+}
+
+// certificateCovers reports whether a certificate exempts the jurisdiction
+// on a sale to address. Only certificates issued in the address's country
+// apply, so a California certificate does nothing in Canada; they cover the
+// jurisdiction by its own code, every layer by the country's code, or a US
+// state or local layer by the address's state.
+func certificateCovers(cert *models.ExemptionCertificate, jurisdiction models.Jurisdiction, address *models.Address) bool {
+	country := certificateCountry(address.Country)
+	if certificateCountry(cert.Country) != country {
+		return false
+	}
+	for _, code := range cert.Jurisdictions {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		switch {
+		case code == strings.ToUpper(jurisdiction.Code):
+			return true
+		case code == country:
+			return true
+		case country == "US" && jurisdiction.Type != JurisdictionCountry && code == strings.ToUpper(strings.TrimSpace(address.State)):
+			return true
+		}
+	}
+	return false
+}
+
+// certificateCountry normalizes a certificate's or address's country code,
+// defaulting to US
+func certificateCountry(country string) string {
+	if country = normalizeCountry(country); country != "" {
+		return country
+	}
+	return "US"
+}
+
+// record notes that a certificate exempted a jurisdiction
+func (e *exemptionSet) record(cert *models.ExemptionCertificate, jurisdiction models.Jurisdiction) {
+	for i := range e.used {
+		if e.used[i].CertificateID == cert.ID {
+			if !containsString(e.used[i].Jurisdictions, jurisdiction.Code) {
+				e.used[i].Jurisdictions = append(e.used[i].Jurisdictions, jurisdiction.Code)
+			}
+			return
+		}
+	}
+	e.used = append(e.used, models.AppliedExemption{
+		CertificateID: cert.ID,
+		CustomerID:    cert.CustomerID,
+		Reason:        cert.Reason,
+		Jurisdictions: []string{jurisdiction.Code},
+	})
+}
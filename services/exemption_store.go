@@ -0,0 +1,192 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+// CertificateStore is an ExemptionStore held in memory and, when opened on a
+// file, saved back to that file after every change
+type CertificateStore struct {
+	mu    sync.RWMutex
+	certs map[string]models.ExemptionCertificate
+	path  string // empty for a store that is not persisted
+}
+
+// certificateFile is the on-disk form of a certificate store
+type certificateFile struct {
+	Certificates []models.ExemptionCertificate `json:"certificates"`
+}
+
+// NewCertificateStore creates an empty store that is kept in memory only
+func NewCertificateStore() *CertificateStore {
+	return &CertificateStore{certs: make(map[string]models.ExemptionCertificate)}
+}
+
+// OpenCertificateStore loads the certificates saved at path and saves every
+// later change there. A missing file is treated as an empty store and is
+// created on the first change.
+func OpenCertificateStore(path string) (*CertificateStore, error) {
+	store := NewCertificateStore()
+	store.path = path
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var file certificateFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid certificate file: %w", err)
+	}
+	for _, cert := range file.Certificates {
+		if err := normalizeCertificate(&cert); err != nil {
+			return nil, fmt.Errorf("invalid certificate file: %w", err)
+		}
+		if _, ok := store.certs[cert.ID]; ok {
+			return nil, fmt.Errorf("invalid certificate file: certificate %s is listed more than once", cert.ID)
+		}
+		store.certs[cert.ID] = cert
+	}
+	return store, nil
+}
+
+// Create adds a new certificate
+func (s *CertificateStore) Create(cert models.ExemptionCertificate) error {
+	if err := normalizeCertificate(&cert); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.certs[cert.ID]; ok {
+		return fmt.Errorf("certificate %s: %w", cert.ID, ErrCertificateExists)
+	}
+	return s.commit(func(certs map[string]models.ExemptionCertificate) {
+		certs[cert.ID] = cert
+	})
+}
+
+// Update replaces a certificate
+func (s *CertificateStore) Update(cert models.ExemptionCertificate) error {
+	if err := normalizeCertificate(&cert); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.certs[cert.ID]; !ok {
+		return fmt.Errorf("certificate %s: %w", cert.ID, ErrCertificateNotFound)
+	}
+	return s.commit(func(certs map[string]models.ExemptionCertificate) {
+		certs[cert.ID] = cert
+	})
+}
+
+// Delete removes a certificate
+func (s *CertificateStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.certs[id]; !ok {
+		return fmt.Errorf("certificate %s: %w", id, ErrCertificateNotFound)
+	}
+	return s.commit(func(certs map[string]models.ExemptionCertificate) {
+		delete(certs, id)
+	})
+}
+
+// Certificate returns one certificate
+func (s *CertificateStore) Certificate(id string) (*models.ExemptionCertificate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	cert, ok := s.certs[id]
+	if !ok {
+		return nil, ErrCertificateNotFound
+	}
+	return &cert, nil
+}
+
+// List returns every certificate ordered by ID
+func (s *CertificateStore) List() ([]models.ExemptionCertificate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return sortedCertificates(s.certs, func(models.ExemptionCertificate) bool { return true }), nil
+}
+
+// CertificatesFor returns the customer's certificates ordered by ID
+func (s *CertificateStore) CertificatesFor(customerID string) ([]models.ExemptionCertificate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return sortedCertificates(s.certs, func(cert models.ExemptionCertificate) bool {
+		return cert.CustomerID == customerID
+	}), nil
+}
+
+// sortedCertificates returns the certificates matching keep ordered by ID
+func sortedCertificates(all map[string]models.ExemptionCertificate, keep func(models.ExemptionCertificate) bool) []models.ExemptionCertificate {
+	var certs []models.ExemptionCertificate
+	for _, cert := range all {
+		if keep(cert) {
+			certs = append(certs, cert)
+		}
+	}
+	sortCertificates(certs, func(a, b *models.ExemptionCertificate) bool { return a.ID < b.ID })
+	return certs
+}
+
+// commit applies change to a copy of the certificates, saves the copy when
+// the store is persisted and only then makes it current, so a failed write
+// leaves the store unchanged. The caller holds the write lock.
+func (s *CertificateStore) commit(change func(map[string]models.ExemptionCertificate)) error {
+	next := make(map[string]models.ExemptionCertificate, len(s.certs)+1)
+	for id, cert := range s.certs {
+		next[id] = cert
+	}
+	change(next)
+
+	if s.path != "" {
+		file := certificateFile{Certificates: sortedCertificates(next, func(models.ExemptionCertificate) bool { return true })}
+		if err := writeJSONFile(s.path, file); err != nil {
+			return fmt.Errorf("saving certificates: %w", err)
+		}
+	}
+	s.certs = next
+	return nil
+}
+
+// writeJSONFile writes v to path through a temporary file in the same
+// directory so readers never see a partly written file
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// sortCertificates sorts certificates in place by less
+func sortCertificates(certs []models.ExemptionCertificate, less func(a, b *models.ExemptionCertificate) bool) {
+	sort.SliceStable(certs, func(i, j int) bool { return less(&certs[i], &certs[j]) })
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+func TestCertificateStore(t *testing.T) {
+	store := NewCertificateStore()
+	cert := models.ExemptionCertificate{ID: " RS-1 ", CustomerID: "shop", Reason: "Resale", Jurisdictions: []string{"ny", "NY", " nj"}}
+
+	if err := store.Create(cert); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.Create(cert); !errors.Is(err, ErrCertificateExists) {
+		t.Errorf("expected certificate exists, got %v", err)
+	}
+
+	got, err := store.Certificate("RS-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Reason != "resale" || len(got.Jurisdictions) != 2 || got.Jurisdictions[0] != "NY" || got.Jurisdictions[1] != "NJ" {
+		t.Errorf("expected certificate to be normalized, got %+v", got)
+	}
+
+	cert.Jurisdictions = []string{"CT"}
+	if err := store.Update(cert); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, _ := store.Certificate("RS-1"); got.Jurisdictions[0] != "CT" {
+		t.Errorf("expected update to replace jurisdictions, got %v", got.Jurisdictions)
+	}
+	if err := store.Update(models.ExemptionCertificate{ID: "XX", Reason: "resale", Jurisdictions: []string{"NY"}}); !errors.Is(err, ErrCertificateNotFound) {
+		t.Errorf("expected certificate not found, got %v", err)
+	}
+	if err := store.Create(models.ExemptionCertificate{ID: "bad", Reason: "resale"}); err == nil {
+		t.Error("expected an error for a certificate without jurisdictions")
+	}
+
+	if err := store.Create(models.ExemptionCertificate{ID: "GV-1", CustomerID: "city", Reason: "government", Jurisdictions: []string{"NY"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if certs, _ := store.List(); len(certs) != 2 || certs[0].ID != "GV-1" || certs[1].ID != "RS-1" {
+		t.Errorf("expected certificates ordered by ID, got %+v", certs)
+	}
+	if certs, _ := store.CertificatesFor("shop"); len(certs) != 1 || certs[0].ID != "RS-1" {
+		t.Errorf("expected shop's certificate, got %+v", certs)
+	}
+
+	if err := store.Delete("RS-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := store.Certificate("RS-1"); !errors.Is(err, ErrCertificateNotFound) {
+		t.Errorf("expected deleted certificate to be gone, got %v", err)
+	}
+	if err := store.Delete("RS-1"); !errors.Is(err, ErrCertificateNotFound) {
+		t.Errorf("expected certificate not found, got %v", err)
+	}
+}
+
+func TestOpenCertificateStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "certificates.json")
+
+	store, err := OpenCertificateStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error opening a missing file: %v", err)
+	}
+	expires := models.MustParseDate("2026-12-31")
+	err = store.Create(models.ExemptionCertificate{ID: "RS-1", CustomerID: "shop", Reason: "resale", Jurisdictions: []string{"NY"}, ExpiresOn: &expires})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reopened, err := OpenCertificateStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := reopened.Certificate("RS-1")
+	if err != nil {
+		t.Fatalf("expected certificate to be saved, got %v", err)
+	}
+	if got.ExpiresOn == nil || *got.ExpiresOn != expires {
+		t.Errorf("expected expiry to be saved, got %+v", got)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"certificates": [{"id": "X", "reason": "gift", "jurisdictions": ["NY"]}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenCertificateStore(path); err == nil {
+		t.Error("expected an error for an invalid certificate file")
+	}
+}
+
+func TestCertificateStore_FailedSave(t *testing.T) {
+	store, err := OpenCertificateStore(filepath.Join(t.TempDir(), "missing", "certificates.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.Create(models.ExemptionCertificate{ID: "RS-1", Reason: "resale", Jurisdictions: []string{"NY"}}); err == nil {
+		t.Fatal("expected an error saving to a missing directory")
+	}
+	if _, err := store.Certificate("RS-1"); !errors.Is(err, ErrCertificateNotFound) {
+		t.Errorf("expected a failed save to leave the store unchanged, got %v", err)
+	}
+}
@@ -0,0 +1,301 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+func TestCalculateTax_Exemptions(t *testing.T) {
+	newYork := models.Address{Country: "US", State: "NY", ZipCode: "10001"}
+	lamp := []models.Item{{ID: "lamp", Price: models.MustParseMoney("100.00"), Quantity: 1}}
+
+	tests := []struct {
+		name      string
+		address   models.Address
+		exemption *models.ExemptionCertificate
+		tax       string
+		exempted  []string
+	}{
+		{
+			name:      "state certificate covers state and locals",
+			address:   newYork,
+			exemption: &models.ExemptionCertificate{ID: "RS-1", Reason: "resale", Jurisdictions: []string{"NY"}},
+			tax:       "0.00",
+			exempted:  []string{"NY", "NYC", "NY-MCTD"},
+		},
+		{
+			name:      "local certificate covers one layer",
+			address:   newYork,
+			exemption: &models.ExemptionCertificate{ID: "GV-1", Reason: "government", Jurisdictions: []string{"NYC"}},
+			tax:       "4.38",
+			exempted:  []string{"NYC"},
+		},
+		{
+			name:      "other state certificate does nothing",
+			address:   newYork,
+			exemption: &models.ExemptionCertificate{ID: "RS-2", Reason: "resale", Jurisdictions: []string{"NJ"}},
+			tax:       "8.88",
+		},
+		{
+			name:      "country certificate covers VAT",
+			address:   models.Address{Country: "UK", PostalCode: "SW1A 1AA"},
+			exemption: &models.ExemptionCertificate{ID: "NP-1", Reason: "nonprofit", Country: "GB", Jurisdictions: []string{"GB"}},
+			tax:       "0.00",
+			exempted:  []string{"GB"},
+		},
+		{
+			name:      "California certificate does nothing in Canada",
+			address:   models.Address{Country: "CA", State: "ON", PostalCode: "M5V 3L9"},
+			exemption: &models.ExemptionCertificate{ID: "RS-3", Reason: "resale", Jurisdictions: []string{"CA"}},
+			tax:       "13.00",
+		},
+		{
+			name:      "Delaware certificate does nothing in Germany",
+			address:   models.Address{Country: "DE", PostalCode: "10115"},
+			exemption: &models.ExemptionCertificate{ID: "RS-4", Reason: "resale", Jurisdictions: []string{"DE"}},
+			tax:       "19.00",
+		},
+		{
+			name:      "Canadian certificate covers the provincial tax",
+			address:   models.Address{Country: "CA", State: "BC", PostalCode: "V6B 1A1"},
+			exemption: &models.ExemptionCertificate{ID: "RS-5", Reason: "resale", Country: "CA", Jurisdictions: []string{"BC"}},
+			tax:       "5.00",
+			exempted:  []string{"BC"},
+		},
+		{
+			name:      "German certificate covers German VAT",
+			address:   models.Address{Country: "DE", PostalCode: "10115"},
+			exemption: &models.ExemptionCertificate{ID: "GV-2", Reason: "government", Country: "DE", Jurisdictions: []string{"DE"}},
+			tax:       "0.00",
+			exempted:  []string{"DE"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewTaxService(DefaultRateProvider())
+			req := &models.TaxRequest{Address: tt.address, Items: lamp, Exemption: tt.exemption, TransactionDate: &testDate}
+
+			resp, err := service.CalculateTax(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.TotalTax.String() != tt.tax {
+				t.Errorf("expected tax %s, got %s", tt.tax, resp.TotalTax)
+			}
+
+			var exempted []string
+			for _, used := range resp.Exemptions {
+				if used.CertificateID != tt.exemption.ID {
+					t.Errorf("expected certificate %s, got %s", tt.exemption.ID, used.CertificateID)
+				}
+				exempted = append(exempted, used.Jurisdictions...)
+			}
+			if strings.Join(exempted, ",") != strings.Join(tt.exempted, ",") {
+				t.Errorf("expected exempt jurisdictions %v, got %v", tt.exempted, exempted)
+			}
+		})
+	}
+}
+
+func TestCalculateTax_CustomerExemption(t *testing.T) {
+	store := NewCertificateStore()
+	err := store.Create(models.ExemptionCertificate{
+		ID:            "RS-100",
+		CustomerID:    "cust-1",
+		Reason:        "resale",
+		Jurisdictions: []string{"NY"},
+		ExpiresOn:     datePtr("2025-01-31"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	service := NewTaxService(DefaultRateProvider())
+	service.SetExemptionStore(store)
+
+	req := &models.TaxRequest{
+		Address:    models.Address{Country: "US", State: "NY", ZipCode: "10001"},
+		Items:      []models.Item{{ID: "lamp", Price: models.MustParseMoney("100.00"), Quantity: 1}},
+		CustomerID: "cust-1",
+	}
+
+	req.TransactionDate = datePtr("2025-01-15")
+	resp, err := service.CalculateTax(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.TotalTax.IsZero() {
+		t.Errorf("expected no tax, got %s", resp.TotalTax)
+	}
+	if len(resp.Exemptions) != 1 || resp.Exemptions[0].CustomerID != "cust-1" {
+		t.Errorf("expected customer certificate to be recorded, got %+v", resp.Exemptions)
+	}
+
+	// Once the certificate on file expires the customer is taxed again
+	req.TransactionDate = datePtr("2025-02-01")
+	resp, err = service.CalculateTax(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.TotalTax.IsZero() || len(resp.Exemptions) != 0 {
+		t.Errorf("expected an expired customer certificate to be ignored, got %s %+v", resp.TotalTax, resp.Exemptions)
+	}
+
+	req.CustomerID = "cust-2"
+	resp, err = service.CalculateTax(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.TotalTax.IsZero() || len(resp.Exemptions) != 0 {
+		t.Errorf("expected customer without certificates to be taxed, got %s %+v", resp.TotalTax, resp.Exemptions)
+	}
+}
+
+func TestCalculateTax_CertificateID(t *testing.T) {
+	service := NewTaxService(DefaultRateProvider())
+	certs := []models.ExemptionCertificate{
+		{ID: "GV-7", CustomerID: "city-hall", Reason: "government", Jurisdictions: []string{"ny"}},
+		{ID: "RS-8", CustomerID: "shop", Reason: "resale", Jurisdictions: []string{"NY"}, ExpiresOn: datePtr("2025-05-31")},
+	}
+	for _, cert := range certs {
+		if err := service.ExemptionStore().Create(cert); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	newRequest := func(certificateID string) *models.TaxRequest {
+		return &models.TaxRequest{
+			Address:         models.Address{Country: "US", State: "NY", ZipCode: "10001"},
+			Items:           []models.Item{{ID: "lamp", Price: models.MustParseMoney("100.00"), Quantity: 1}},
+			CertificateID:   certificateID,
+			TransactionDate: &testDate,
+		}
+	}
+
+	resp, err := service.CalculateTax(newRequest("GV-7"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.TotalTax.IsZero() || len(resp.Exemptions) != 1 || resp.Exemptions[0].CertificateID != "GV-7" {
+		t.Errorf("expected GV-7 to exempt the order, got %s %+v", resp.TotalTax, resp.Exemptions)
+	}
+
+	var expired *ExpiredCertificateError
+	_, err = service.CalculateTax(newRequest("RS-8"))
+	if !errors.As(err, &expired) {
+		t.Fatalf("expected an expired certificate error, got %v", err)
+	}
+	if expired.ExpiresOn.String() != "2025-05-31" || expired.Date != testDate {
+		t.Errorf("unexpected expired certificate error %+v", expired)
+	}
+
+	if _, err := service.CalculateTax(newRequest("XX-1")); !errors.Is(err, ErrCertificateNotFound) {
+		t.Errorf("expected certificate not found, got %v", err)
+	}
+
+	mismatched := newRequest("GV-7")
+	mismatched.CustomerID = "shop"
+	if _, err := service.CalculateTax(mismatched); err == nil {
+		t.Error("expected an error for another customer's certificate")
+	}
+}
+
+func TestExpiringCertificates(t *testing.T) {
+	service := NewTaxService(DefaultRateProvider())
+	service.now = func() time.Time { return time.Date(2025, time.June, 1, 12, 0, 0, 0, time.UTC) }
+	for _, cert := range []models.ExemptionCertificate{
+		{ID: "A", Reason: "resale", Jurisdictions: []string{"NY"}, ExpiresOn: datePtr("2025-06-30")},
+		{ID: "B", Reason: "resale", Jurisdictions: []string{"NY"}, ExpiresOn: datePtr("2025-06-01")},
+		{ID: "C", Reason: "resale", Jurisdictions: []string{"NY"}, ExpiresOn: datePtr("2025-05-31")},
+		{ID: "D", Reason: "resale", Jurisdictions: []string{"NY"}, ExpiresOn: datePtr("2025-07-02")},
+		{ID: "E", Reason: "resale", Jurisdictions: []string{"NY"}},
+	} {
+		if err := service.ExemptionStore().Create(cert); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	expiring, err := service.ExpiringCertificates(30)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var ids []string
+	for _, cert := range expiring {
+		ids = append(ids, cert.ID)
+	}
+	if strings.Join(ids, ",") != "B,A" {
+		t.Errorf("expected B,A to expire within 30 days, got %v", ids)
+	}
+
+	if _, err := service.ExpiringCertificates(-1); err == nil {
+		t.Error("expected an error for negative days")
+	}
+}
+
+func TestCheckCertificate(t *testing.T) {
+	cert := &models.ExemptionCertificate{ID: "RS-1", Reason: "resale", Jurisdictions: []string{"NY", "NJ"}, ExpiresOn: datePtr("2025-12-31")}
+
+	tests := []struct {
+		jurisdiction string
+		address      models.Address
+		date         string
+		valid        bool
+		reason       string
+	}{
+		{"NY", models.Address{}, "2025-06-01", true, ""},
+		{"nj", models.Address{}, "2025-12-31", true, ""},
+		{"CT", models.Address{}, "2025-06-01", false, "does not cover CT"},
+		{"NY", models.Address{}, "2026-01-01", false, "expired on 2025-12-31"},
+		{"NY-MCTD", models.Address{}, "2025-06-01", false, "does not cover NY-MCTD"},
+		{"NY-MCTD", models.Address{State: "NY", Country: "US"}, "2025-06-01", true, ""},
+		{"NYC", models.Address{State: "ny", Country: "US"}, "2025-06-01", true, ""},
+		{"CT-X", models.Address{State: "CT", Country: "US"}, "2025-06-01", false, "does not cover CT-X"},
+	}
+
+	for _, tt := range tests {
+		validity := CheckCertificate(cert, tt.jurisdiction, &tt.address, models.MustParseDate(tt.date))
+		if validity.Valid != tt.valid || !strings.Contains(validity.Reason, tt.reason) {
+			t.Errorf("%s on %s: expected valid=%v %q, got %+v", tt.jurisdiction, tt.date, tt.valid, tt.reason, validity)
+		}
+	}
+}
+
+func TestValidateCertificate(t *testing.T) {
+	tests := []struct {
+		name string
+		cert models.ExemptionCertificate
+		err  string
+	}{
+		{"valid", models.ExemptionCertificate{ID: "X", Reason: "resale", Jurisdictions: []string{"NY"}}, ""},
+		{"missing id", models.ExemptionCertificate{Reason: "resale", Jurisdictions: []string{"NY"}}, "id is required"},
+		{"unknown reason", models.ExemptionCertificate{ID: "X", Reason: "friend", Jurisdictions: []string{"NY"}}, "invalid reason"},
+		{"no jurisdictions", models.ExemptionCertificate{ID: "X", Reason: "resale"}, "no jurisdictions"},
+		{"bad country", models.ExemptionCertificate{ID: "X", Reason: "resale", Country: "America", Jurisdictions: []string{"NY"}}, "invalid country"},
+		{"bad jurisdiction", models.ExemptionCertificate{ID: "X", Reason: "resale", Jurisdictions: []string{"New York"}}, "invalid jurisdiction"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCertificate(&tt.cert)
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func datePtr(text string) *models.Date {
+	d := models.MustParseDate(text)
+	return &d
+}
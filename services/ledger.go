@@ -0,0 +1,280 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+// Transaction statuses
+const (
+	TransactionQuoted    = "quoted"    // saved but not yet a sale
+	TransactionCommitted = "committed" // a completed sale
+	TransactionVoided    = "voided"    // cancelled; kept for the audit trail
+	// TransactionCalculated is an order priced through the calculate
+	// endpoints, recorded for reporting
+	TransactionCalculated = "calculated"
+)
+
+// TransactionStateError is returned for a lifecycle action the transaction's
+// status does not allow, e.g. committing a voided transaction
+type TransactionStateError struct {
+	Code   string
+	Status string
+	Action string
+}
+
+func (e *TransactionStateError) Error() string {
+	return fmt.Sprintf("cannot %s transaction %s: it is %s", e.Action, e.Code, e.Status)
+}
+
+// Ledger records the lifecycle of transactions: a quote saved under a
+// document code, committed once it becomes a sale, adjusted when the sale
+// changes and voided when it is cancelled. Committed transactions count
+// toward the sellers' economic nexus and EU sellers' OSS threshold. Orders
+// priced outside the lifecycle are recorded as calculated transactions for
+// the reports; they do not count toward either.
+type Ledger struct {
+	tax  *TaxService
+	repo TransactionRepository
+	now  func() time.Time
+
+	mu          sync.Mutex // serializes read-modify-write of a transaction
+	calculation int        // last sequence number given to a calculation
+}
+
+// NewLedger creates a ledger that calculates with tax and stores transactions
+// in repo. The committed transactions already in repo are added to the nexus
+// tracker's running totals.
+func NewLedger(tax *TaxService, repo TransactionRepository) (*Ledger, error) {
+	txns, err := repo.List()
+	if err != nil {
+		return nil, err
+	}
+	l := &Ledger{tax: tax, repo: repo, now: time.Now}
+	for i := range txns {
+		if txns[i].Status == TransactionCommitted {
+			l.recordSale(&txns[i], 1)
+		}
+	}
+	return l, nil
+}
+
+// Create calculates the request and saves it under code, as a quote or, when
+// commit is set, as a committed sale
+func (l *Ledger) Create(code string, req *models.TaxRequest, commit bool) (*models.Transaction, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return nil, fmt.Errorf("code is required")
+	}
+	req, result, err := l.calculate(req)
+	if err != nil {
+		return nil, err
+	}
+
+	now := l.now().UTC()
+	txn := &models.Transaction{
+		Code:      code,
+		Type:      TransactionSale,
+		Status:    TransactionQuoted,
+		Version:   1,
+		Request:   *req,
+		Result:    *result,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if commit {
+		txn.Status = TransactionCommitted
+		txn.CommittedAt = &now
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.repo.Create(txn); err != nil {
+		return nil, err
+	}
+	if commit {
+		l.recordSale(txn, 1)
+	}
+	return txn, nil
+}
+
+// RecordCalculation records an order calculated outside the transaction
+// lifecycle, e.g. through the calculate endpoint, under a generated code
+// "CALC-<time>-<sequence>" so the reports can total it
+func (l *Ledger) RecordCalculation(req *models.TaxRequest, result *models.TaxResponse) (*models.Transaction, error) {
+	pinned := *req
+	pinned.TransactionDate = &result.TransactionDate
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calculation++
+	now := l.now().UTC()
+	txn := &models.Transaction{
+		Code:      fmt.Sprintf("CALC-%s-%d", now.Format("20060102T150405.000000000Z"), l.calculation),
+		Type:      TransactionSale,
+		Status:    TransactionCalculated,
+		Version:   1,
+		Request:   pinned,
+		Result:    *result,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := l.repo.Create(txn); err != nil {
+		return nil, err
+	}
+	return txn, nil
+}
+
+// Commit turns a quote into a sale
+func (l *Ledger) Commit(code string) (*models.Transaction, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	txn, err := l.repo.Get(code)
+	if err != nil {
+		return nil, err
+	}
+	if txn.Status != TransactionQuoted {
+		return nil, &TransactionStateError{Code: code, Status: txn.Status, Action: "commit"}
+	}
+
+	now := l.now().UTC()
+	txn.Status = TransactionCommitted
+	txn.CommittedAt = &now
+	txn.UpdatedAt = now
+	if err := l.repo.Update(txn); err != nil {
+		return nil, err
+	}
+	l.recordSale(txn, 1)
+	return txn, nil
+}
+
+// Adjust recalculates a quoted or committed transaction from a corrected
+// request, keeping the version it replaces
+func (l *Ledger) Adjust(code, reason string, req *models.TaxRequest) (*models.Transaction, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("reason is required")
+	}
+	req, result, err := l.calculate(req)
+	if err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	txn, err := l.repo.Get(code)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkChangeable(txn, "adjust"); err != nil {
+		return nil, err
+	}
+
+	now := l.now().UTC()
+	previous := *txn
+	txn.Adjustments = append(txn.Adjustments, models.TransactionAdjustment{
+		Version:    txn.Version,
+		Reason:     reason,
+		AdjustedAt: now,
+		Request:    txn.Request,
+		Result:     txn.Result,
+	})
+	txn.Version++
+	txn.Request = *req
+	txn.Result = *result
+	txn.UpdatedAt = now
+	if err := l.repo.Update(txn); err != nil {
+		return nil, err
+	}
+	if txn.Status == TransactionCommitted {
+		l.recordSale(&previous, -1)
+		l.recordSale(txn, 1)
+	}
+	return txn, nil
+}
+
+// Void cancels a quoted or committed transaction
+func (l *Ledger) Void(code, reason string) (*models.Transaction, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	txn, err := l.repo.Get(code)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkChangeable(txn, "void"); err != nil {
+		return nil, err
+	}
+
+	now := l.now().UTC()
+	wasCommitted := txn.Status == TransactionCommitted
+	txn.Status = TransactionVoided
+	txn.VoidedAt = &now
+	txn.VoidReason = reason
+	txn.UpdatedAt = now
+	if err := l.repo.Update(txn); err != nil {
+		return nil, err
+	}
+	if wasCommitted {
+		l.recordSale(txn, -1)
+	}
+	return txn, nil
+}
+
+// Transaction returns the transaction saved under code
+func (l *Ledger) Transaction(code string) (*models.Transaction, error) {
+	return l.repo.Get(code)
+}
+
+// checkChangeable returns a TransactionStateError when txn cannot be adjusted
+// or voided: it is voided, it is a refund, or goods have been refunded from it
+func checkChangeable(txn *models.Transaction, action string) error {
+	switch {
+	case txn.Status == TransactionVoided, txn.Status == TransactionCalculated:
+		return &TransactionStateError{Code: txn.Code, Status: txn.Status, Action: action}
+	case isRefund(txn):
+		return &TransactionStateError{Code: txn.Code, Status: "a refund", Action: action}
+	case len(txn.Refunds) > 0:
+		return &TransactionStateError{Code: txn.Code, Status: "refunded", Action: action}
+	}
+	return nil
+}
+
+// calculate runs the request through the tax service and returns it with its
+// transaction date pinned, so the saved request recalculates to the same
+// result
+func (l *Ledger) calculate(req *models.TaxRequest) (*models.TaxRequest, *models.TaxResponse, error) {
+	result, err := l.tax.CalculateTax(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	pinned := *req
+	pinned.TransactionDate = &result.TransactionDate
+	return &pinned, result, nil
+}
+
+// recordSale adds a committed transaction to, or with sign -1 removes it from,
+// the seller's nexus totals and any threshold its engine counts sales toward.
+// A refund's negative amount takes back sales but not the transaction count
+// of the sale it returns goods from.
+func (l *Ledger) recordSale(txn *models.Transaction, sign int) {
+	amount := txn.Result.Subtotal.Add(txn.Result.ChargeTotal)
+	transactions := sign
+	if sign < 0 {
+		amount = amount.Neg()
+	}
+	if isRefund(txn) {
+		transactions = 0
+	}
+	if nexus := txn.Result.Nexus; nexus != nil {
+		l.tax.nexus.Record(txn.Request.SellerID, nexus.State, txn.Result.TransactionDate, amount, transactions)
+	}
+	if recorder, ok := l.tax.engines[normalizeCountry(txn.Request.Address.Country)].(saleRecorder); ok {
+		recorder.RecordSale(&txn.Request, amount)
+	}
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+func newLedgerRequest(price string) *models.TaxRequest {
+	return &models.TaxRequest{
+		Address:  models.Address{Country: "US", State: "TX", ZipCode: "78701"},
+		Items:    []models.Item{{ID: "lamp", Price: models.MustParseMoney(price), Quantity: 1}},
+		SellerID: "acme",
+	}
+}
+
+func TestLedger_Lifecycle(t *testing.T) {
+	service := NewTaxService(DefaultRateProvider())
+	if err := service.NexusTracker().Configure(SellerNexus{SellerID: "acme", PhysicalStates: []string{"TX"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ledger, err := NewLedger(service, NewMemoryTransactionRepository())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	quote, err := ledger.Create("INV-1", newLedgerRequest("100.00"), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if quote.Status != TransactionQuoted || quote.Version != 1 || quote.Request.TransactionDate == nil {
+		t.Errorf("expected a dated version 1 quote, got %+v", quote)
+	}
+	year := quote.Result.TransactionDate.Year()
+	if sales := service.NexusTracker().Sales("acme", "TX", year); sales.Transactions != 0 {
+		t.Errorf("expected quotes not to count toward nexus, got %+v", sales)
+	}
+
+	if _, err := ledger.Create("INV-1", newLedgerRequest("100.00"), false); !errors.Is(err, ErrTransactionExists) {
+		t.Errorf("expected transaction exists, got %v", err)
+	}
+
+	committed, err := ledger.Commit("INV-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if committed.Status != TransactionCommitted || committed.CommittedAt == nil {
+		t.Errorf("expected a committed transaction, got %+v", committed)
+	}
+	if sales := service.NexusTracker().Sales("acme", "TX", year); sales.Transactions != 1 || sales.Sales.String() != "100.00" {
+		t.Errorf("expected the commit to count toward nexus, got %+v", sales)
+	}
+
+	var state *TransactionStateError
+	if _, err := ledger.Commit("INV-1"); !errors.As(err, &state) {
+		t.Errorf("expected a state error committing twice, got %v", err)
+	}
+
+	if _, err := ledger.Adjust("INV-1", "", newLedgerRequest("80.00")); err == nil {
+		t.Error("expected an error adjusting without a reason")
+	}
+	adjusted, err := ledger.Adjust("INV-1", "price correction", newLedgerRequest("80.00"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if adjusted.Version != 2 || len(adjusted.Adjustments) != 1 || adjusted.Adjustments[0].Result.Subtotal.String() != "100.00" {
+		t.Errorf("expected version 2 keeping the original, got %+v", adjusted)
+	}
+	if adjusted.Result.Subtotal.String() != "80.00" || adjusted.Status != TransactionCommitted {
+		t.Errorf("expected the adjusted result to stay committed, got %s %s", adjusted.Result.Subtotal, adjusted.Status)
+	}
+	if sales := service.NexusTracker().Sales("acme", "TX", year); sales.Transactions != 1 || sales.Sales.String() != "80.00" {
+		t.Errorf("expected the adjustment to replace the sale, got %+v", sales)
+	}
+
+	voided, err := ledger.Void("INV-1", "order cancelled")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if voided.Status != TransactionVoided || voided.VoidReason != "order cancelled" {
+		t.Errorf("expected a voided transaction, got %+v", voided)
+	}
+	if sales := service.NexusTracker().Sales("acme", "TX", year); sales.Transactions != 0 || !sales.Sales.IsZero() {
+		t.Errorf("expected the void to remove the sale, got %+v", sales)
+	}
+
+	for _, action := range []func() error{
+		func() error { _, err := ledger.Void("INV-1", ""); return err },
+		func() error { _, err := ledger.Adjust("INV-1", "again", newLedgerRequest("1.00")); return err },
+		func() error { _, err := ledger.Commit("INV-1"); return err },
+	} {
+		if err := action(); !errors.As(err, &state) {
+			t.Errorf("expected a state error on a voided transaction, got %v", err)
+		}
+	}
+
+	if _, err := ledger.Transaction("INV-2"); !errors.Is(err, ErrTransactionNotFound) {
+		t.Errorf("expected transaction not found, got %v", err)
+	}
+}
+
+func TestLedger_ReplaysCommittedSales(t *testing.T) {
+	repo := NewMemoryTransactionRepository()
+	service := NewTaxService(DefaultRateProvider())
+	service.NexusTracker().Configure(SellerNexus{SellerID: "acme"})
+	ledger, _ := NewLedger(service, repo)
+	if _, err := ledger.Create("INV-1", newLedgerRequest("100.00"), true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := ledger.Create("INV-2", newLedgerRequest("50.00"), false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	restarted := NewTaxService(DefaultRateProvider())
+	restarted.NexusTracker().Configure(SellerNexus{SellerID: "acme"})
+	if _, err := NewLedger(restarted, repo); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	txn, _ := repo.Get("INV-1")
+	sales := restarted.NexusTracker().Sales("acme", "TX", txn.Result.TransactionDate.Year())
+	if sales.Transactions != 1 || sales.Sales.String() != "100.00" {
+		t.Errorf("expected committed sales to be replayed, got %+v", sales)
+	}
+}
+
+func TestLedger_RecordCalculation(t *testing.T) {
+	repo := NewMemoryTransactionRepository()
+	service := NewTaxService(DefaultRateProvider())
+	service.NexusTracker().Configure(SellerNexus{SellerID: "acme"})
+	ledger, _ := NewLedger(service, repo)
+
+	req := newLedgerRequest("100.00")
+	result, err := service.CalculateTax(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	first, err := ledger.RecordCalculation(req, result)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := ledger.RecordCalculation(req, result)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(first.Code, "CALC-") || first.Code == second.Code {
+		t.Errorf("expected distinct CALC- codes, got %s and %s", first.Code, second.Code)
+	}
+	if first.Status != TransactionCalculated || first.Request.TransactionDate == nil || first.Result.TotalTax != result.TotalTax {
+		t.Errorf("expected a calculated transaction with its date pinned, got %+v", first)
+	}
+	if txns, _ := repo.List(); len(txns) != 2 {
+		t.Errorf("expected both calculations saved, got %d", len(txns))
+	}
+
+	// Calculations are not sales
+	if sales := service.NexusTracker().Sales("acme", "TX", result.TransactionDate.Year()); sales.Transactions != 0 {
+		t.Errorf("expected calculations not to count toward nexus, got %+v", sales)
+	}
+	restarted := NewTaxService(DefaultRateProvider())
+	restarted.NexusTracker().Configure(SellerNexus{SellerID: "acme"})
+	NewLedger(restarted, repo)
+	if sales := restarted.NexusTracker().Sales("acme", "TX", result.TransactionDate.Year()); sales.Transactions != 0 {
+		t.Errorf("expected calculations not to be replayed, got %+v", sales)
+	}
+	var stateErr *TransactionStateError
+	if _, err := ledger.Commit(first.Code); !errors.As(err, &stateErr) {
+		t.Errorf("expected a state error committing a calculation, got %v", err)
+	}
+	if _, err := ledger.Void(first.Code, "mistake"); !errors.As(err, &stateErr) {
+		t.Errorf("expected a state error voiding a calculation, got %v", err)
+	}
+}
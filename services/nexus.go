@@ -0,0 +1,409 @@
+package services
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+//go:embed data/us_nexus.json
+var defaultNexusTable []byte
+
+// Nexus bases reported for a state where a seller must collect
+const (
+	NexusPhysical   = "physical"   // office, warehouse or staff in the state
+	NexusRegistered = "registered" // registered to collect voluntarily
+	NexusEconomic   = "economic"   // sales crossed the state's threshold
+)
+
+// NoNexusReason is reported when tax is not collected because the seller has
+// no nexus in the state
+const NoNexusReason = "no_nexus"
+
+// NexusTable is the on-disk representation of the states' economic nexus
+// thresholds
+type NexusTable struct {
+	Version    string           `json:"version"`
+	Country    string           `json:"country"`
+	Thresholds []NexusThreshold `json:"thresholds"`
+}
+
+// NexusThreshold is the sales amount and, in some states, transaction count
+// in the current or previous calendar year that creates economic nexus.
+// Either is enough unless RequireBoth is set. A state may be listed once per
+// effective period.
+type NexusThreshold struct {
+	State        string       `json:"state"`
+	Sales        models.Money `json:"sales"`
+	Transactions int          `json:"transactions,omitempty"`
+	RequireBoth  bool         `json:"require_both,omitempty"`
+	EffectivePeriod
+}
+
+// crossed reports whether a year's sales and transactions meet the threshold
+func (t *NexusThreshold) crossed(sales models.Money, transactions int) bool {
+	salesMet := sales.Units >= t.Sales.Units
+	if t.Transactions == 0 {
+		return salesMet
+	}
+	transactionsMet := transactions >= t.Transactions
+	if t.RequireBoth {
+		return salesMet && transactionsMet
+	}
+	return salesMet || transactionsMet
+}
+
+// NexusThresholds looks up each state's economic nexus threshold
+type NexusThresholds struct {
+	version string
+	states  map[string][]NexusThreshold
+}
+
+// NewNexusThresholds builds the threshold lookup from a parsed table
+func NewNexusThresholds(table *NexusTable) (*NexusThresholds, error) {
+	t := &NexusThresholds{version: table.Version, states: make(map[string][]NexusThreshold)}
+	for i, threshold := range table.Thresholds {
+		state := strings.ToUpper(strings.TrimSpace(threshold.State))
+		if state == "" {
+			return nil, fmt.Errorf("nexus threshold %d has no state", i)
+		}
+		if threshold.Sales.Units <= 0 {
+			return nil, fmt.Errorf("nexus threshold for %s needs a positive sales amount", state)
+		}
+		if threshold.Transactions < 0 {
+			return nil, fmt.Errorf("nexus threshold for %s has negative transactions", state)
+		}
+		if threshold.RequireBoth && threshold.Transactions == 0 {
+			return nil, fmt.Errorf("nexus threshold for %s requires both but sets no transactions", state)
+		}
+		if err := validatePeriods(threshold, t.states[state]); err != nil {
+			return nil, fmt.Errorf("nexus threshold for %s: %w", state, err)
+		}
+		threshold.State = state
+		t.states[state] = append(t.states[state], threshold)
+	}
+	return t, nil
+}
+
+// ParseNexusTable reads a JSON nexus threshold table
+func ParseNexusTable(r io.Reader) (*NexusThresholds, error) {
+	var table NexusTable
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&table); err != nil {
+		return nil, fmt.Errorf("invalid nexus table: %w", err)
+	}
+	return NewNexusThresholds(&table)
+}
+
+// DefaultNexusThresholds returns the US economic nexus thresholds bundled
+// with the binary
+func DefaultNexusThresholds() *NexusThresholds {
+	thresholds, err := ParseNexusTable(bytes.NewReader(defaultNexusTable))
+	if err != nil {
+		panic(fmt.Sprintf("bundled nexus table is invalid: %v", err))
+	}
+	return thresholds
+}
+
+// Threshold returns the state's threshold in force on date; states without
+// sales tax have none
+func (t *NexusThresholds) Threshold(state string, date models.Date) (*NexusThreshold, bool) {
+	for i, threshold := range t.states[strings.ToUpper(state)] {
+		if threshold.Covers(date) {
+			return &t.states[strings.ToUpper(state)][i], true
+		}
+	}
+	return nil, false
+}
+
+// SellerNexus configures where a seller has nexus. CollectOnlyWithNexus
+// stops tax being charged in every other state. PriorSales seeds the tracker
+// with sales made before the service started counting. EUEstablishment is
+// the EU member state a seller is established in, if any, and PriorEUSales
+// seeds its cross-border sales toward the OSS threshold.
+type SellerNexus struct {
+	SellerID             string       `json:"seller_id"`
+	PhysicalStates       []string     `json:"physical_states,omitempty"`
+	RegisteredStates     []string     `json:"registered_states,omitempty"`
+	CollectOnlyWithNexus bool         `json:"collect_only_with_nexus,omitempty"`
+	PriorSales           []StateSales `json:"prior_sales,omitempty"`
+	EUEstablishment      string       `json:"eu_establishment,omitempty"`
+	PriorEUSales         []EUSales    `json:"prior_eu_sales,omitempty"`
+}
+
+// StateSales is a seller's sales into a state in one calendar year
+type StateSales struct {
+	State        string       `json:"state"`
+	Year         int          `json:"year"`
+	Sales        models.Money `json:"sales"`
+	Transactions int          `json:"transactions"`
+}
+
+// EUSales is an EU seller's B2C sales to buyers in other member states in
+// one calendar year, in euros
+type EUSales struct {
+	Year  int          `json:"year"`
+	Sales models.Money `json:"sales"`
+}
+
+// NexusConfig is the on-disk representation of the sellers' nexus settings
+type NexusConfig struct {
+	Sellers []SellerNexus `json:"sellers"`
+}
+
+// LoadNexusConfig reads sellers' nexus settings from the JSON file at path
+func LoadNexusConfig(path string) ([]SellerNexus, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var config NexusConfig
+	decoder := json.NewDecoder(f)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return nil, fmt.Errorf("invalid nexus config: %w", err)
+	}
+	return config.Sellers, nil
+}
+
+// salesKey identifies one seller's sales into one state in one year
+type salesKey struct {
+	seller string
+	state  string
+	year   int
+}
+
+// euSalesKey identifies one seller's EU cross-border sales in one year
+type euSalesKey struct {
+	seller string
+	year   int
+}
+
+// NexusTracker holds the sellers' nexus settings and a running total of their
+// committed sales and transactions into each state, and decides where each
+// seller has nexus. It also totals EU sellers' cross-border sales for the
+// OSS threshold. It is safe for concurrent use.
+type NexusTracker struct {
+	thresholds *NexusThresholds
+
+	mu      sync.RWMutex
+	sellers map[string]*sellerStates
+	sales   map[salesKey]*StateSales
+	euSales map[euSalesKey]models.Money
+}
+
+// sellerStates is a seller's settings with its states as sets
+type sellerStates struct {
+	physical, registered map[string]bool
+	collectOnlyWithNexus bool
+	euEstablishment      string
+}
+
+// NewNexusTracker creates a tracker with no sellers configured
+func NewNexusTracker(thresholds *NexusThresholds) *NexusTracker {
+	return &NexusTracker{
+		thresholds: thresholds,
+		sellers:    make(map[string]*sellerStates),
+		sales:      make(map[salesKey]*StateSales),
+		euSales:    make(map[euSalesKey]models.Money),
+	}
+}
+
+// Configure sets a seller's nexus settings, replacing earlier settings, and
+// adds its prior sales to the running totals
+func (t *NexusTracker) Configure(seller SellerNexus) error {
+	if seller.SellerID == "" {
+		return fmt.Errorf("seller_id is required")
+	}
+	states := &sellerStates{
+		physical:             stateSet(seller.PhysicalStates),
+		registered:           stateSet(seller.RegisteredStates),
+		collectOnlyWithNexus: seller.CollectOnlyWithNexus,
+	}
+	if seller.EUEstablishment != "" {
+		states.euEstablishment = normalizeCountry(seller.EUEstablishment)
+	}
+	for _, prior := range seller.PriorSales {
+		if prior.State == "" || prior.Year == 0 {
+			return fmt.Errorf("seller %s prior sales need a state and year", seller.SellerID)
+		}
+		if prior.Sales.IsNegative() || prior.Transactions < 0 {
+			return fmt.Errorf("seller %s prior sales in %s must not be negative", seller.SellerID, prior.State)
+		}
+	}
+	if len(seller.PriorEUSales) > 0 && states.euEstablishment == "" {
+		return fmt.Errorf("seller %s prior EU sales need an eu_establishment", seller.SellerID)
+	}
+	for _, prior := range seller.PriorEUSales {
+		if prior.Year == 0 {
+			return fmt.Errorf("seller %s prior EU sales need a year", seller.SellerID)
+		}
+		if prior.Sales.IsNegative() {
+			return fmt.Errorf("seller %s prior EU sales in %d must not be negative", seller.SellerID, prior.Year)
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sellers[seller.SellerID] = states
+	for _, prior := range seller.PriorSales {
+		t.add(salesKey{seller.SellerID, strings.ToUpper(prior.State), prior.Year}, prior.Sales, prior.Transactions)
+	}
+	for _, prior := range seller.PriorEUSales {
+		t.addEU(euSalesKey{seller.SellerID, prior.Year}, prior.Sales)
+	}
+	return nil
+}
+
+// Record adds sales into the state on date to the seller's running totals.
+// A sale adds its amount and one transaction; negative values take back a
+// voided sale or a refund.
+func (t *NexusTracker) Record(sellerID, state string, date models.Date, amount models.Money, transactions int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.add(salesKey{sellerID, strings.ToUpper(state), date.Year()}, amount, transactions)
+}
+
+// add updates a running total; the caller holds the write lock
+func (t *NexusTracker) add(key salesKey, amount models.Money, transactions int) {
+	total, ok := t.sales[key]
+	if !ok {
+		total = &StateSales{State: key.state, Year: key.year, Sales: models.NewMoney(0, "USD")}
+		t.sales[key] = total
+	}
+	total.Sales = total.Sales.Add(amount.In("USD"))
+	total.Transactions += transactions
+}
+
+// Sales returns the seller's running totals for a state and year
+func (t *NexusTracker) Sales(sellerID, state string, year int) StateSales {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if total, ok := t.sales[salesKey{sellerID, strings.ToUpper(state), year}]; ok {
+		return *total
+	}
+	return StateSales{State: strings.ToUpper(state), Year: year, Sales: models.NewMoney(0, "USD")}
+}
+
+// RecordEU adds an EU seller's cross-border B2C sale on date, in euros, to
+// its running total; a negative amount takes back a voided sale or a refund
+func (t *NexusTracker) RecordEU(sellerID string, date models.Date, amount models.Money) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.addEU(euSalesKey{sellerID, date.Year()}, amount)
+}
+
+// addEU updates an EU running total; the caller holds the write lock
+func (t *NexusTracker) addEU(key euSalesKey, amount models.Money) {
+	total, ok := t.euSales[key]
+	if !ok {
+		total = models.NewMoney(0, "EUR")
+	}
+	t.euSales[key] = total.Add(amount.In("EUR"))
+}
+
+// EUSales returns the seller's cross-border B2C sales to other member states
+// in a year, in euros
+func (t *NexusTracker) EUSales(sellerID string, year int) EUSales {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if total, ok := t.euSales[euSalesKey{sellerID, year}]; ok {
+		return EUSales{Year: year, Sales: total}
+	}
+	return EUSales{Year: year, Sales: models.NewMoney(0, "EUR")}
+}
+
+// EUEstablishment returns the member state the seller is established in, or
+// "" for sellers established outside the EU or not configured
+func (t *NexusTracker) EUEstablishment(sellerID string) string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if seller, ok := t.sellers[sellerID]; ok {
+		return seller.euEstablishment
+	}
+	return ""
+}
+
+// Status decides whether the seller has nexus in the state on date: physical
+// presence, a registration, or sales crossing the state's threshold in the
+// current or previous calendar year. ok is false for sellers that are not
+// configured, which collect everywhere.
+func (t *NexusTracker) Status(sellerID, state string, date models.Date) (status *models.NexusStatus, ok bool) {
+	state = strings.ToUpper(strings.TrimSpace(state))
+	t.mu.RLock()
+	seller, ok := t.sellers[sellerID]
+	t.mu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+
+	current := t.Sales(sellerID, state, date.Year())
+	status = &models.NexusStatus{
+		State:        state,
+		Sales:        current.Sales,
+		Transactions: current.Transactions,
+	}
+	switch {
+	case seller.physical[state]:
+		status.Basis = NexusPhysical
+	case seller.registered[state]:
+		status.Basis = NexusRegistered
+	default:
+		if threshold, ok := t.thresholds.Threshold(state, date); ok {
+			previous := t.Sales(sellerID, state, date.Year()-1)
+			if threshold.crossed(current.Sales, current.Transactions) || threshold.crossed(previous.Sales, previous.Transactions) {
+				status.Basis = NexusEconomic
+			}
+		}
+	}
+	status.HasNexus = status.Basis != ""
+	if !status.HasNexus && seller.collectOnlyWithNexus {
+		status.Reason = NoNexusReason
+	}
+	return status, true
+}
+
+// stateSet upper-cases a list of state codes into a set
+func stateSet(states []string) map[string]bool {
+	set := make(map[string]bool, len(states))
+	for _, state := range states {
+		set[strings.ToUpper(strings.TrimSpace(state))] = true
+	}
+	return set
+}
+
+// SetNexusTracker replaces the tracker holding sellers' nexus settings and
+// sales
+func (s *TaxService) SetNexusTracker(tracker *NexusTracker) {
+	s.nexus = tracker
+	for _, engine := range s.engines {
+		if tracked, ok := engine.(nexusTracked); ok {
+			tracked.SetNexusTracker(tracker)
+		}
+	}
+}
+
+// NexusTracker returns the tracker holding sellers' nexus settings and sales
+func (s *TaxService) NexusTracker() *NexusTracker {
+	return s.nexus
+}
+
+// nexusStatus returns the seller's nexus in the destination state for US
+// sales by configured sellers, or nil when every state collects
+func (s *TaxService) nexusStatus(req *models.TaxRequest) *models.NexusStatus {
+	if req.SellerID == "" || normalizeCountry(req.Address.Country) != "US" {
+		return nil
+	}
+	status, _ := s.nexus.Status(req.SellerID, req.Address.State, transactionDate(req))
+	return status
+}
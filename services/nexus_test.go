@@ -0,0 +1,187 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+func TestNexusThreshold(t *testing.T) {
+	thresholds := DefaultNexusThresholds()
+
+	tests := []struct {
+		state        string
+		date         string
+		sales        string
+		transactions int
+		crossed      bool
+	}{
+		{"TX", "2025-06-01", "499999.99", 5000, false},
+		{"TX", "2025-06-01", "500000.00", 1, true},
+		{"GA", "2025-06-01", "10.00", 200, true},
+		{"NY", "2025-06-01", "600000.00", 99, false},
+		{"NY", "2025-06-01", "600000.00", 100, true},
+		{"IL", "2025-12-31", "10.00", 200, true},
+		{"IL", "2026-01-01", "10.00", 200, false},
+	}
+
+	for _, tt := range tests {
+		threshold, ok := thresholds.Threshold(tt.state, models.MustParseDate(tt.date))
+		if !ok {
+			t.Fatalf("expected a threshold for %s", tt.state)
+		}
+		if got := threshold.crossed(models.MustParseMoney(tt.sales), tt.transactions); got != tt.crossed {
+			t.Errorf("%s on %s with %s and %d transactions: expected crossed=%v", tt.state, tt.date, tt.sales, tt.transactions, tt.crossed)
+		}
+	}
+
+	if _, ok := thresholds.Threshold("OR", testDate); ok {
+		t.Error("expected no threshold for a state without sales tax")
+	}
+}
+
+func TestParseNexusTable_Invalid(t *testing.T) {
+	tests := []string{
+		`{"thresholds": [{"sales": "100000"}]}`,
+		`{"thresholds": [{"state": "TX", "sales": "0"}]}`,
+		`{"thresholds": [{"state": "NY", "sales": "500000", "require_both": true}]}`,
+		`{"thresholds": [{"state": "TX", "sales": "500000"}, {"state": "tx", "sales": "100000"}]}`,
+		`{"thresholds": [{"state": "TX", "sales": "500000", "threshold": 1}]}`,
+	}
+	for _, tt := range tests {
+		if _, err := ParseNexusTable(strings.NewReader(tt)); err == nil {
+			t.Errorf("expected error for nexus table %s", tt)
+		}
+	}
+}
+
+func TestNexusTracker_Status(t *testing.T) {
+	tracker := NewNexusTracker(DefaultNexusThresholds())
+	err := tracker.Configure(SellerNexus{
+		SellerID:             "acme",
+		PhysicalStates:       []string{"ca"},
+		RegisteredStates:     []string{"NY"},
+		CollectOnlyWithNexus: true,
+		PriorSales: []StateSales{
+			{State: "TX", Year: 2024, Sales: models.MustParseMoney("650000.00"), Transactions: 900},
+			{State: "FL", Year: 2025, Sales: models.MustParseMoney("99999.00"), Transactions: 150},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		state  string
+		basis  string
+		reason string
+	}{
+		{"CA", NexusPhysical, ""},
+		{"NY", NexusRegistered, ""},
+		{"TX", NexusEconomic, ""},
+		{"FL", "", NoNexusReason},
+		{"WA", "", NoNexusReason},
+	}
+	for _, tt := range tests {
+		status, ok := tracker.Status("acme", tt.state, testDate)
+		if !ok {
+			t.Fatalf("expected acme to be configured")
+		}
+		if status.Basis != tt.basis || status.Reason != tt.reason || status.HasNexus != (tt.basis != "") {
+			t.Errorf("%s: expected basis %q reason %q, got %+v", tt.state, tt.basis, tt.reason, status)
+		}
+	}
+
+	// A sale that takes Florida over its threshold creates nexus for the next one
+	tracker.Record("acme", "FL", testDate, models.MustParseMoney("1.00"), 1)
+	if status, _ := tracker.Status("acme", "FL", testDate); status.Basis != NexusEconomic || status.Transactions != 151 {
+		t.Errorf("expected economic nexus in FL after crossing the threshold, got %+v", status)
+	}
+
+	if _, ok := tracker.Status("other", "FL", testDate); ok {
+		t.Error("expected unconfigured seller to have no status")
+	}
+}
+
+func TestCalculateTax_Nexus(t *testing.T) {
+	service := NewTaxService(DefaultRateProvider())
+	err := service.NexusTracker().Configure(SellerNexus{SellerID: "acme", PhysicalStates: []string{"NY"}, CollectOnlyWithNexus: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	newRequest := func(seller string, address models.Address) *models.TaxRequest {
+		return &models.TaxRequest{
+			Address:         address,
+			Items:           []models.Item{{ID: "lamp", Price: models.MustParseMoney("100.00"), Quantity: 1}},
+			Charges:         []models.Charge{{ID: "gift", Type: "gift_wrap", Amount: models.MustParseMoney("5.00")}},
+			SellerID:        seller,
+			TransactionDate: &testDate,
+		}
+	}
+	newYork := models.Address{Country: "US", State: "NY", ZipCode: "10001"}
+	texas := models.Address{Country: "US", State: "TX", ZipCode: "78701"}
+
+	resp, err := service.CalculateTax(newRequest("acme", texas))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.TotalTax.IsZero() || resp.Nexus == nil || resp.Nexus.Reason != NoNexusReason {
+		t.Errorf("expected no tax without nexus, got %s %+v", resp.TotalTax, resp.Nexus)
+	}
+
+	resp, err = service.CalculateTax(newRequest("acme", newYork))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.TotalTax.IsZero() || resp.Nexus.Basis != NexusPhysical {
+		t.Errorf("expected tax with physical nexus, got %s %+v", resp.TotalTax, resp.Nexus)
+	}
+
+	resp, err = service.CalculateTax(newRequest("", texas))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.TotalTax.IsZero() || resp.Nexus != nil {
+		t.Errorf("expected requests without a seller to be taxed, got %s %+v", resp.TotalTax, resp.Nexus)
+	}
+
+	// Quotes are not sales; the ledger records committed transactions
+	if sales := service.NexusTracker().Sales("acme", "TX", 2025); sales.Transactions != 0 {
+		t.Errorf("expected quotes not to be tracked, got %+v", sales)
+	}
+}
+
+func TestLoadNexusConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nexus.json")
+	config := `{"sellers": [{"seller_id": "acme", "physical_states": ["CA"], "collect_only_with_nexus": true,
+		"prior_sales": [{"state": "TX", "year": 2024, "sales": "10.00", "transactions": 1}],
+		"eu_establishment": "IE", "prior_eu_sales": [{"year": 2024, "sales": "2500.00"}]}]}`
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	sellers, err := LoadNexusConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sellers) != 1 || sellers[0].SellerID != "acme" || len(sellers[0].PriorSales) != 1 {
+		t.Errorf("unexpected sellers %+v", sellers)
+	}
+
+	tracker := NewNexusTracker(DefaultNexusThresholds())
+	if err := tracker.Configure(sellers[0]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tracker.EUEstablishment("acme") != "IE" || tracker.EUSales("acme", 2024).Sales.String() != "2500.00" {
+		t.Errorf("expected an Irish seller with 2500.00 of EU sales, got %q %+v",
+			tracker.EUEstablishment("acme"), tracker.EUSales("acme", 2024))
+	}
+	sellers[0].EUEstablishment = ""
+	if err := tracker.Configure(sellers[0]); err == nil {
+		t.Error("expected an error for prior EU sales without an establishment")
+	}
+}
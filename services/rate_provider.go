@@ -0,0 +1,332 @@
+package services
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+//go:embed data/us_rates.json
+var defaultRateTable []byte
+
+// Jurisdiction types used for US sales tax layers
+const (
+	JurisdictionState         = "state"
+	JurisdictionCounty        = "county"
+	JurisdictionCity          = "city"
+	JurisdictionSpecial       = "special"
+	JurisdictionLocalEstimate = "local_estimate"
+)
+
+// RateProvider supplies tax rates for an address
+type RateProvider interface {
+	// Version identifies the rate table the provider is serving
+	Version() string
+	// JurisdictionsFor returns every jurisdiction that taxes a sale delivered
+	// to the address on the given day, state first
+	JurisdictionsFor(address *models.Address, date models.Date) (*RateLookup, error)
+}
+
+// RateLookup is the jurisdiction stack resolved for an address, how
+// precisely the address was matched (one of the Match* levels) and how the
+// state sources intrastate sales (one of the Sourcing* methods, empty for
+// destination)
+type RateLookup struct {
+	Layers     []JurisdictionRate
+	MatchLevel string
+	Sourcing   string
+}
+
+// JurisdictionRate is one layer of the jurisdiction stack for an address
+type JurisdictionRate struct {
+	Jurisdiction models.Jurisdiction
+	Rate         models.Rate
+}
+
+// RateTable is the on-disk representation of a versioned rate file
+type RateTable struct {
+	Version            string              `json:"version"`
+	Country            string              `json:"country"`
+	DefaultRate        *models.Rate        `json:"default_rate,omitempty"`
+	States             []StateRate         `json:"states"`
+	LocalJurisdictions []LocalJurisdiction `json:"local_jurisdictions,omitempty"`
+}
+
+// StateRate is a state's own sales tax rate. AverageLocalRate is charged as
+// an estimate for addresses not covered by any local jurisdiction. Sourcing
+// is "origin" or "hybrid" for states that tax intrastate sales where they
+// ship from. A state may be listed once per effective period.
+type StateRate struct {
+	Code             string      `json:"code"`
+	Name             string      `json:"name"`
+	Rate             models.Rate `json:"rate"` // percentage, e.g. "4.00"
+	AverageLocalRate models.Rate `json:"average_local_rate,omitempty"`
+	Sourcing         string      `json:"sourcing,omitempty"`
+	EffectivePeriod
+}
+
+// LocalJurisdiction is a county, city or special district tax, matched to an
+// address by ZIP code or, failing that, by city name. A jurisdiction may be
+// listed once per effective period.
+type LocalJurisdiction struct {
+	Code     string      `json:"code"`
+	Name     string      `json:"name"`
+	Type     string      `json:"type"`
+	State    string      `json:"state"`
+	Rate     models.Rate `json:"rate"`
+	ZipCodes []string    `json:"zip_codes,omitempty"`
+	Cities   []string    `json:"cities,omitempty"`
+	EffectivePeriod
+}
+
+// TableRateProvider serves rates from an in-memory RateTable
+type TableRateProvider struct {
+	version     string
+	defaultRate *models.Rate
+	states      map[string][]StateRate         // keyed by code, one per period
+	locals      map[string][]LocalJurisdiction // keyed by code, one per period
+	byZip       map[string][]LocalJurisdiction // keyed by ZIP5
+	byCity      map[string][]LocalJurisdiction // keyed by "STATE|CITY"
+	boundaries  *BoundaryIndex
+}
+
+// NewTableRateProvider builds a provider from a parsed rate table
+func NewTableRateProvider(table *RateTable) (*TableRateProvider, error) {
+	if table.Version == "" {
+		return nil, fmt.Errorf("rate table version is required")
+	}
+
+	p := &TableRateProvider{
+		version:     table.Version,
+		defaultRate: table.DefaultRate,
+		states:      make(map[string][]StateRate, len(table.States)),
+		locals:      make(map[string][]LocalJurisdiction, len(table.LocalJurisdictions)),
+		byZip:       make(map[string][]LocalJurisdiction),
+		byCity:      make(map[string][]LocalJurisdiction),
+	}
+
+	for i, state := range table.States {
+		code := strings.ToUpper(strings.TrimSpace(state.Code))
+		if code == "" {
+			return nil, fmt.Errorf("state %d has no code", i)
+		}
+		if !validRate(state.Rate) || !validRate(state.AverageLocalRate) {
+			return nil, fmt.Errorf("state %s has invalid rate", code)
+		}
+		if !validSourcing(state.Sourcing) {
+			return nil, fmt.Errorf("state %s has invalid sourcing %q", code, state.Sourcing)
+		}
+		if state.Sourcing == SourcingDestination {
+			state.Sourcing = ""
+		}
+		if err := validatePeriods(state, p.states[code]); err != nil {
+			return nil, fmt.Errorf("state %s: %w", code, err)
+		}
+		state.Code = code
+		p.states[code] = append(p.states[code], state)
+	}
+
+	for i, local := range table.LocalJurisdictions {
+		local.State = strings.ToUpper(strings.TrimSpace(local.State))
+		if local.Code == "" {
+			return nil, fmt.Errorf("local jurisdiction %d has no code", i)
+		}
+		if _, ok := p.states[local.State]; !ok {
+			return nil, fmt.Errorf("local jurisdiction %s is in unknown state %q", local.Code, local.State)
+		}
+		switch local.Type {
+		case JurisdictionCounty, JurisdictionCity, JurisdictionSpecial:
+		default:
+			return nil, fmt.Errorf("local jurisdiction %s has invalid type %q", local.Code, local.Type)
+		}
+		if !validRate(local.Rate) {
+			return nil, fmt.Errorf("local jurisdiction %s has invalid rate %s%%", local.Code, local.Rate)
+		}
+		if err := validatePeriods(local, p.locals[local.Code]); err != nil {
+			return nil, fmt.Errorf("local jurisdiction %s: %w", local.Code, err)
+		}
+		p.locals[local.Code] = append(p.locals[local.Code], local)
+		for _, zip := range local.ZipCodes {
+			p.byZip[zip] = append(p.byZip[zip], local)
+		}
+		for _, city := range local.Cities {
+			key := cityKey(local.State, city)
+			p.byCity[key] = append(p.byCity[key], local)
+		}
+	}
+
+	return p, nil
+}
+
+// ParseRateTable reads a JSON rate table and builds a provider from it
+func ParseRateTable(r io.Reader) (*TableRateProvider, error) {
+	var table RateTable
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&table); err != nil {
+		return nil, fmt.Errorf("invalid rate table: %w", err)
+	}
+	return NewTableRateProvider(&table)
+}
+
+// LoadRateTable loads a rate table from the JSON file at path
+func LoadRateTable(path string) (*TableRateProvider, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseRateTable(f)
+}
+
+// DefaultRateProvider returns a provider backed by the rate table bundled with the binary
+func DefaultRateProvider() *TableRateProvider {
+	provider, err := ParseRateTable(bytes.NewReader(defaultRateTable))
+	if err != nil {
+		panic(fmt.Sprintf("bundled rate table is invalid: %v", err))
+	}
+	return provider
+}
+
+// Version returns the version of the loaded rate table
+func (p *TableRateProvider) Version() string {
+	return p.version
+}
+
+// UseBoundaries makes the provider resolve local jurisdictions from a ZIP and
+// ZIP+4 boundary index before falling back to the rate table's own ZIP and
+// city lists. Every jurisdiction the index references must be in the table.
+func (p *TableRateProvider) UseBoundaries(index *BoundaryIndex) error {
+	for code := range index.jurisdictionCodes() {
+		if _, ok := p.locals[code]; !ok {
+			return fmt.Errorf("boundary file references jurisdiction %s missing from rate table %s", code, p.version)
+		}
+	}
+	p.boundaries = index
+	return nil
+}
+
+// JurisdictionsFor returns the state layer followed by the county, city and
+// special district layers in force at the address on date, using the most
+// specific match available: a ZIP+4 range or whole ZIP in the boundary index,
+// then the rate table's ZIP codes, then its city names. When nothing matches,
+// the state's average local rate is charged as a single estimated layer.
+// States missing from the table fall back to the table's default rate.
+func (p *TableRateProvider) JurisdictionsFor(address *models.Address, date models.Date) (*RateLookup, error) {
+	code := strings.ToUpper(strings.TrimSpace(address.State))
+	periods, ok := p.states[code]
+	if !ok {
+		if p.defaultRate == nil {
+			return nil, fmt.Errorf("no tax rate for state %s", address.State)
+		}
+		return &RateLookup{
+			Layers: []JurisdictionRate{{
+				Jurisdiction: models.Jurisdiction{Code: code, Name: address.State, Type: JurisdictionState},
+				Rate:         *p.defaultRate,
+			}},
+			MatchLevel: MatchDefault,
+		}, nil
+	}
+
+	state, ok := stateOn(periods, date)
+	if !ok {
+		return nil, fmt.Errorf("no tax rate for state %s on %s", code, date)
+	}
+
+	lookup := &RateLookup{
+		Layers: []JurisdictionRate{{
+			Jurisdiction: models.Jurisdiction{Code: state.Code, Name: state.Name, Type: JurisdictionState},
+			Rate:         state.Rate,
+		}},
+		Sourcing: state.Sourcing,
+	}
+
+	locals, level := p.localJurisdictions(code, address, date)
+	for _, local := range locals {
+		lookup.Layers = append(lookup.Layers, JurisdictionRate{
+			Jurisdiction: models.Jurisdiction{Code: local.Code, Name: local.Name, Type: local.Type},
+			Rate:         local.Rate,
+		})
+	}
+	lookup.MatchLevel = level
+	if level == MatchState && state.AverageLocalRate > 0 {
+		lookup.Layers = append(lookup.Layers, JurisdictionRate{
+			Jurisdiction: models.Jurisdiction{
+				Code: state.Code + "-LOCAL",
+				Name: state.Name + " average local rate",
+				Type: JurisdictionLocalEstimate,
+			},
+			Rate: state.AverageLocalRate,
+		})
+	}
+	return lookup, nil
+}
+
+// localJurisdictions returns the local layers in the state in force at the
+// address on date and the level they were matched at
+func (p *TableRateProvider) localJurisdictions(state string, address *models.Address, date models.Date) ([]LocalJurisdiction, string) {
+	zip := address.ZipCode
+	if zip == "" {
+		zip = address.PostalCode
+	}
+
+	if p.boundaries != nil {
+		if codes, level, ok := p.boundaries.Lookup(state, zip); ok {
+			var locals []LocalJurisdiction
+			for _, code := range codes {
+				for _, local := range p.locals[code] {
+					if local.Covers(date) {
+						locals = append(locals, local)
+					}
+				}
+			}
+			return locals, level
+		}
+	}
+
+	zip5, _ := splitZip(zip)
+	var matches []LocalJurisdiction
+	for _, local := range p.byZip[zip5] {
+		if local.State == state && local.Covers(date) {
+			matches = append(matches, local)
+		}
+	}
+	if len(matches) > 0 {
+		return matches, MatchZip5
+	}
+	for _, local := range p.byCity[cityKey(state, address.City)] {
+		if local.Covers(date) {
+			matches = append(matches, local)
+		}
+	}
+	if len(matches) > 0 {
+		return matches, MatchCity
+	}
+	return nil, MatchState
+}
+
+// stateOn returns the state's rate entry in force on date
+func stateOn(periods []StateRate, date models.Date) (StateRate, bool) {
+	for _, state := range periods {
+		if state.Covers(date) {
+			return state, true
+		}
+	}
+	return StateRate{}, false
+}
+
+func cityKey(state, city string) string {
+	return state + "|" + strings.ToUpper(strings.TrimSpace(city))
+}
+
+// validRate reports whether a rate is between 0 and 100%
+func validRate(rate models.Rate) bool {
+	return rate >= 0 && rate < models.RateScale
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+// testDate is the transaction date rate lookups are tested on
+var testDate = models.MustParseDate("2025-06-01")
+
+// combinedRate sums the rates of every layer
+func combinedRate(lookup *RateLookup) models.Rate {
+	var total models.Rate
+	for _, layer := range lookup.Layers {
+		total += layer.Rate
+	}
+	return total
+}
+
+func TestDefaultRateProvider(t *testing.T) {
+	provider := DefaultRateProvider()
+
+	if provider.Version() == "" {
+		t.Error("Expected bundled rate table to have a version")
+	}
+
+	// A ZIP without local data gets the state rate plus the average local estimate
+	layers, err := provider.JurisdictionsFor(&models.Address{State: "ny", ZipCode: "12345"}, testDate)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(layers.Layers) != 2 || layers.Layers[1].Jurisdiction.Type != JurisdictionLocalEstimate {
+		t.Fatalf("Expected state and local estimate layers, got %+v", layers)
+	}
+	if layers.MatchLevel != MatchState {
+		t.Errorf("Expected match level %s, got %s", MatchState, layers.MatchLevel)
+	}
+	if combinedRate(layers) != models.MustParseRate("8.52") {
+		t.Errorf("Expected NY combined rate 8.52%%, got %s%%", combinedRate(layers))
+	}
+
+	layers, err = provider.JurisdictionsFor(&models.Address{State: "ZZ"}, testDate)
+	if err != nil {
+		t.Fatalf("Expected default rate for unknown state, got %v", err)
+	}
+	if combinedRate(layers) != models.MustParseRate("7") {
+		t.Errorf("Expected default rate 7.00%%, got %s%%", combinedRate(layers))
+	}
+}
+
+func TestJurisdictionsFor_LocalLayers(t *testing.T) {
+	provider := DefaultRateProvider()
+
+	tests := []struct {
+		name    string
+		address models.Address
+		codes   []string
+		rate    string
+		level   string
+	}{
+		{"NYC by ZIP", models.Address{State: "NY", ZipCode: "10001"}, []string{"NY", "NYC", "NY-MCTD"}, "8.875", MatchZip5},
+		{"ZIP+4 uses ZIP5", models.Address{State: "NY", ZipCode: "10001-2062"}, []string{"NY", "NYC", "NY-MCTD"}, "8.875", MatchZip5},
+		{"Chicago by ZIP", models.Address{State: "IL", ZipCode: "60601"}, []string{"IL", "IL-COOK", "IL-CHI", "IL-RTA"}, "10.25", MatchZip5},
+		{"Denver by city", models.Address{State: "CO", City: "denver", ZipCode: "80299"}, []string{"CO", "CO-DEN", "CO-RTD", "CO-SCFD"}, "8.81", MatchCity},
+		{"No sales tax", models.Address{State: "OR", ZipCode: "97201"}, []string{"OR"}, "0.00", MatchState},
+	}
+
+	for _, tt := range tests {
+		layers, err := provider.JurisdictionsFor(&tt.address, testDate)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+			continue
+		}
+		var codes []string
+		for _, layer := range layers.Layers {
+			codes = append(codes, layer.Jurisdiction.Code)
+		}
+		if layers.MatchLevel != tt.level {
+			t.Errorf("%s: expected match level %s, got %s", tt.name, tt.level, layers.MatchLevel)
+		}
+		if strings.Join(codes, ",") != strings.Join(tt.codes, ",") {
+			t.Errorf("%s: expected layers %v, got %v", tt.name, tt.codes, codes)
+		}
+		if combinedRate(layers).String() != tt.rate {
+			t.Errorf("%s: expected combined rate %s, got %s", tt.name, tt.rate, combinedRate(layers))
+		}
+	}
+}
+
+func TestParseRateTable(t *testing.T) {
+	table := `{
+		"version": "test-1",
+		"country": "US",
+		"states": [{"code": "NY", "name": "New York", "rate": "10"}]
+	}`
+
+	provider, err := ParseRateTable(strings.NewReader(table))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	service := NewTaxService(provider)
+	resp, err := service.CalculateTax(&models.TaxRequest{
+		Address: models.Address{State: "NY", Country: "US", ZipCode: "10001"},
+		Items:   []models.Item{{ID: "item1", Name: "Product A", Price: models.MustParseMoney("100.00"), Quantity: 1}},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if resp.TotalTax.String() != "10.00" {
+		t.Errorf("Expected tax 10.00 from loaded table, got %s", resp.TotalTax)
+	}
+
+	// Without a default rate, unknown states are an error rather than a guess
+	if _, err := provider.JurisdictionsFor(&models.Address{State: "CA"}, testDate); err == nil {
+		t.Error("Expected error for state missing from table without default rate")
+	}
+}
+
+func TestParseRateTable_Invalid(t *testing.T) {
+	tests := []string{
+		`{"states": []}`,
+		`{"version": "1", "states": [{"code": "NY", "rate": "150"}]}`,
+		`{"version": "1", "states": [{"code": "NY", "rate": "10"}, {"code": "ny", "rate": "20"}]}`,
+		`{"version": "1", "states": [{"code": "NY", "rate": "4"}], "local_jurisdictions": [{"code": "X", "type": "city", "state": "CA", "rate": "1"}]}`,
+		`{"version": "1", "states": [{"code": "NY", "rate": "4"}], "local_jurisdictions": [{"code": "X", "type": "borough", "state": "NY", "rate": "1"}]}`,
+		`{"version": "1", "states": [{"code": "TX", "rate": "6.25", "sourcing": "seller"}]}`,
+		`not json`,
+	}
+
+	for _, tt := range tests {
+		if _, err := ParseRateTable(strings.NewReader(tt)); err == nil {
+			t.Errorf("Expected error for rate table %s", tt)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+// Transaction types
+const (
+	TransactionSale   = "sale"
+	TransactionRefund = "refund"
+)
+
+// Refund returns goods and charges from a committed sale. Each returned line
+// refunds its share of the sale's amounts and taxes, so the rates in force on
+// the sale's date are used whatever has changed since, and refunding every
+// unit returns exactly what was charged. The refund is saved as a committed
+// transaction of its own with negative amounts and reduces the seller's
+// nexus sales in the state.
+func (l *Ledger) Refund(code string, req *models.RefundRequest) (*models.Transaction, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	sale, err := l.repo.Get(code)
+	if err != nil {
+		return nil, err
+	}
+	if isRefund(sale) {
+		return nil, &TransactionStateError{Code: code, Status: "a refund", Action: "refund"}
+	}
+	if sale.Status != TransactionCommitted {
+		return nil, &TransactionStateError{Code: code, Status: sale.Status, Action: "refund"}
+	}
+
+	returns, charges, err := refundLines(sale, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(returns) == 0 && len(charges) == 0 {
+		return nil, &TransactionStateError{Code: code, Status: "fully refunded", Action: "refund"}
+	}
+
+	refundCode := strings.TrimSpace(req.Code)
+	if refundCode == "" {
+		refundCode = fmt.Sprintf("%s-R%d", code, len(sale.Refunds)+1)
+	}
+	if _, err := l.repo.Get(refundCode); err == nil {
+		return nil, fmt.Errorf("transaction %s: %w", refundCode, ErrTransactionExists)
+	}
+	request, result := refundResult(sale, returns, charges)
+
+	now := l.now().UTC()
+	refund := &models.Transaction{
+		Code:         refundCode,
+		Type:         TransactionRefund,
+		Status:       TransactionCommitted,
+		Version:      1,
+		Request:      *request,
+		Result:       *result,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		CommittedAt:  &now,
+		RefundOf:     code,
+		RefundReason: req.Reason,
+	}
+
+	// The sale is marked first so a failed write can never leave a refund
+	// whose goods could be refunded again. Copy before changing: the
+	// repository may share these with its own copy.
+	original := *sale
+	returned := make(map[string]int)
+	for id, quantity := range sale.Returned {
+		returned[id] = quantity
+	}
+	for id, quantity := range returns {
+		returned[id] += quantity
+	}
+	sale.Returned = returned
+	sale.RefundedCharges = append(append([]string{}, sale.RefundedCharges...), charges...)
+	sale.Refunds = append(append([]string{}, sale.Refunds...), refundCode)
+	sale.UpdatedAt = now
+	if err := l.repo.Update(sale); err != nil {
+		return nil, err
+	}
+	if err := l.repo.Create(refund); err != nil {
+		if undo := l.repo.Update(&original); undo != nil {
+			return nil, fmt.Errorf("%w; restoring transaction %s: %v", err, code, undo)
+		}
+		return nil, err
+	}
+	l.recordSale(refund, 1)
+	return refund, nil
+}
+
+// refundLines checks a refund request against the sale and returns the
+// quantity returned of each item and the charges refunded. An empty request
+// returns everything not refunded yet.
+func refundLines(sale *models.Transaction, req *models.RefundRequest) (map[string]int, []string, error) {
+	sold := make(map[string]int)
+	for _, item := range sale.Result.Items {
+		if _, ok := sold[item.ItemID]; ok {
+			return nil, nil, fmt.Errorf("transaction %s has more than one item %q", sale.Code, item.ItemID)
+		}
+		sold[item.ItemID] = item.Quantity
+	}
+
+	returns := make(map[string]int)
+	var charges []string
+	if len(req.Items) == 0 && len(req.Charges) == 0 {
+		for id, quantity := range sold {
+			if left := quantity - sale.Returned[id]; left > 0 {
+				returns[id] = left
+			}
+		}
+		for _, charge := range sale.Result.Charges {
+			if !containsString(sale.RefundedCharges, charge.ChargeID) {
+				charges = append(charges, charge.ChargeID)
+			}
+		}
+		return returns, charges, nil
+	}
+
+	for i, item := range req.Items {
+		quantity, ok := sold[item.ItemID]
+		if !ok {
+			return nil, nil, fmt.Errorf("item %d: transaction %s has no item %q", i, sale.Code, item.ItemID)
+		}
+		if item.Quantity <= 0 {
+			return nil, nil, fmt.Errorf("item %d has invalid quantity", i)
+		}
+		returns[item.ItemID] += item.Quantity
+		if left := quantity - sale.Returned[item.ItemID]; returns[item.ItemID] > left {
+			return nil, nil, fmt.Errorf("item %q: cannot return %d, %d of %d left to refund",
+				item.ItemID, returns[item.ItemID], left, quantity)
+		}
+	}
+	for i, id := range req.Charges {
+		found := false
+		for _, charge := range sale.Result.Charges {
+			found = found || charge.ChargeID == id
+		}
+		if !found {
+			return nil, nil, fmt.Errorf("charge %d: transaction %s has no charge %q", i, sale.Code, id)
+		}
+		if containsString(sale.RefundedCharges, id) || containsString(charges, id) {
+			return nil, nil, fmt.Errorf("charge %q is already refunded", id)
+		}
+		charges = append(charges, id)
+	}
+	return returns, charges, nil
+}
+
+// refundResult builds the request and negative result of a refund. A line
+// returning q of its Q units after r were returned earlier refunds each
+// amount's share for units r to r+q, so the shares of successive partial
+// refunds add up to the sale exactly.
+func refundResult(sale *models.Transaction, returns map[string]int, charges []string) (*models.TaxRequest, *models.TaxResponse) {
+	original := &sale.Result
+	currency := original.Currency
+	zero := models.NewMoney(0, currency)
+
+	request := sale.Request
+	request.Items = nil
+	request.Charges = nil
+	request.TransactionDate = &original.TransactionDate
+
+	result := &models.TaxResponse{
+		Address:          original.Address,
+		Currency:         currency,
+		TotalDiscount:    zero,
+		Subtotal:         zero,
+		ChargeTotal:      zero,
+		TotalTax:         zero,
+		TaxJurisdiction:  original.TaxJurisdiction,
+		TransactionDate:  original.TransactionDate,
+		PricesIncludeTax: original.PricesIncludeTax,
+		RateMatchLevel:   original.RateMatchLevel,
+		Exemptions:       original.Exemptions,
+		Nexus:            original.Nexus,
+		Notes:            original.Notes,
+	}
+	summary := newTaxSummary(currency)
+
+	for i, item := range original.Items {
+		quantity, ok := returns[item.ItemID]
+		if !ok {
+			continue
+		}
+		before := sale.Returned[item.ItemID]
+		share := func(m models.Money) models.Money {
+			return m.Prorate(int64(before+quantity), int64(item.Quantity)).
+				Sub(m.Prorate(int64(before), int64(item.Quantity))).Neg()
+		}
+
+		detail := item
+		detail.Quantity = -quantity
+		detail.OriginalAmount = share(item.OriginalAmount)
+		detail.DiscountAmount = share(item.DiscountAmount)
+		detail.Subtotal = share(item.Subtotal)
+		detail.TaxAmount = zero
+		detail.Discounts = nil
+		for _, discount := range item.Discounts {
+			discount.Amount = share(discount.Amount)
+			detail.Discounts = append(detail.Discounts, discount)
+		}
+		detail.Taxes = make([]models.Tax, 0, len(item.Taxes))
+		for _, tax := range item.Taxes {
+			tax.TaxableAmount = share(tax.TaxableAmount)
+			tax.TaxAmount = share(tax.TaxAmount)
+			detail.Taxes = append(detail.Taxes, tax)
+			detail.TaxAmount = detail.TaxAmount.Add(tax.TaxAmount)
+			summary.add(refundRate(original, tax), tax.TaxableAmount, tax.TaxAmount)
+		}
+		detail.TotalAmount = detail.Subtotal.Add(detail.TaxAmount)
+		result.Items = append(result.Items, detail)
+		result.Subtotal = result.Subtotal.Add(detail.Subtotal)
+		result.TotalDiscount = result.TotalDiscount.Add(detail.DiscountAmount)
+		result.TotalTax = result.TotalTax.Add(detail.TaxAmount)
+
+		if i < len(sale.Request.Items) {
+			returned := sale.Request.Items[i]
+			returned.Quantity = -quantity
+			request.Items = append(request.Items, returned)
+		}
+	}
+
+	for i, charge := range original.Charges {
+		if !containsString(charges, charge.ChargeID) {
+			continue
+		}
+		detail := charge
+		detail.Amount = charge.Amount.Neg()
+		detail.Subtotal = charge.Subtotal.Neg()
+		detail.TaxableAmount = charge.TaxableAmount.Neg()
+		detail.TaxAmount = charge.TaxAmount.Neg()
+		detail.TotalAmount = charge.TotalAmount.Neg()
+		detail.Taxes = make([]models.Tax, 0, len(charge.Taxes))
+		for _, tax := range charge.Taxes {
+			tax.TaxableAmount = tax.TaxableAmount.Neg()
+			tax.TaxAmount = tax.TaxAmount.Neg()
+			detail.Taxes = append(detail.Taxes, tax)
+			summary.add(refundRate(original, tax), tax.TaxableAmount, tax.TaxAmount)
+		}
+		result.Charges = append(result.Charges, detail)
+		result.ChargeTotal = result.ChargeTotal.Add(detail.Subtotal)
+		result.TotalTax = result.TotalTax.Add(detail.TaxAmount)
+
+		if i < len(sale.Request.Charges) {
+			returned := sale.Request.Charges[i]
+			returned.Amount = returned.Amount.Neg()
+			request.Charges = append(request.Charges, returned)
+		}
+	}
+
+	result.GrandTotal = result.Subtotal.Add(result.ChargeTotal).Add(result.TotalTax)
+	result.TaxBreakdown = summary.breakdown.entries
+	result.Jurisdictions = summary.jurisdictions.entries
+	return &request, result
+}
+
+// refundRate rebuilds the applied rate a sale's tax line was charged at, with
+// the jurisdiction looked up in the sale's jurisdiction summary
+func refundRate(original *models.TaxResponse, tax models.Tax) AppliedRate {
+	applied := AppliedRate{Name: tax.Name, Rate: tax.Rate, Jurisdiction: models.Jurisdiction{Code: tax.Jurisdiction}}
+	for _, entry := range original.Jurisdictions {
+		if entry.Code == tax.Jurisdiction && entry.Rate == tax.Rate {
+			applied.Jurisdiction = entry.Jurisdiction
+			break
+		}
+	}
+	return applied
+}
+
+// isRefund reports whether txn is a refund; transactions saved without a
+// type are sales
+func isRefund(txn *models.Transaction) bool {
+	return txn.Type == TransactionRefund
+}
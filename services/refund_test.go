@@ -0,0 +1,202 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+func newRefundLedger(t *testing.T) (*TaxService, *Ledger, *models.Transaction) {
+	t.Helper()
+	service := NewTaxService(DefaultRateProvider())
+	if err := service.NexusTracker().Configure(SellerNexus{SellerID: "acme", PhysicalStates: []string{"TX"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ledger, err := NewLedger(service, NewMemoryTransactionRepository())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req := newLedgerRequest("10.00")
+	req.Items[0].Quantity = 3
+	req.Items = append(req.Items, models.Item{ID: "shade", Price: models.MustParseMoney("4.99"), Quantity: 1})
+	req.Charges = []models.Charge{{ID: "ship", Type: "shipping", Amount: models.MustParseMoney("5.00")}}
+	sale, err := ledger.Create("INV-1", req, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return service, ledger, sale
+}
+
+func TestLedger_PartialRefundsAddUpToTheSale(t *testing.T) {
+	service, ledger, sale := newRefundLedger(t)
+	year := sale.Result.TransactionDate.Year()
+
+	first, err := ledger.Refund("INV-1", &models.RefundRequest{Reason: "damaged", Items: []models.RefundItem{{ItemID: "lamp", Quantity: 1}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.Code != "INV-1-R1" || first.Type != TransactionRefund || first.Status != TransactionCommitted || first.RefundOf != "INV-1" {
+		t.Errorf("expected committed refund INV-1-R1 of INV-1, got %+v", first)
+	}
+	if first.Result.TransactionDate != sale.Result.TransactionDate {
+		t.Errorf("expected the sale's date %s, got %s", sale.Result.TransactionDate, first.Result.TransactionDate)
+	}
+	if len(first.Result.Items) != 1 || first.Result.Items[0].Quantity != -1 || first.Result.Subtotal.String() != "-10.00" {
+		t.Errorf("expected one lamp returned, got %+v", first.Result.Items)
+	}
+	if len(first.Request.Items) != 1 || first.Request.Items[0].Quantity != -1 {
+		t.Errorf("expected the refund request to return one lamp, got %+v", first.Request.Items)
+	}
+	if !first.Result.TotalTax.IsNegative() || len(first.Result.Jurisdictions) == 0 {
+		t.Errorf("expected negative tax by jurisdiction, got %s %+v", first.Result.TotalTax, first.Result.Jurisdictions)
+	}
+	if sales := service.NexusTracker().Sales("acme", "TX", year); sales.Transactions != 1 || sales.Sales.String() != "29.99" {
+		t.Errorf("expected the refund to reduce sales but not transactions, got %+v", sales)
+	}
+
+	if _, err := ledger.Refund("INV-1", &models.RefundRequest{Items: []models.RefundItem{{ItemID: "lamp", Quantity: 3}}}); err == nil {
+		t.Error("expected an error returning more than is left")
+	}
+	second, err := ledger.Refund("INV-1", &models.RefundRequest{Items: []models.RefundItem{{ItemID: "lamp", Quantity: 1}}, Charges: []string{"ship"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if second.Result.ChargeTotal.String() != "-5.00" {
+		t.Errorf("expected the shipping refunded, got %s", second.Result.ChargeTotal)
+	}
+	if _, err := ledger.Refund("INV-1", &models.RefundRequest{Charges: []string{"ship"}}); err == nil {
+		t.Error("expected an error refunding a charge twice")
+	}
+	rest, err := ledger.Refund("INV-1", &models.RefundRequest{Code: "RMA-9"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rest.Code != "RMA-9" || len(rest.Result.Items) != 2 {
+		t.Errorf("expected the rest refunded as RMA-9, got %+v", rest)
+	}
+
+	var state *TransactionStateError
+	if _, err := ledger.Refund("INV-1", &models.RefundRequest{}); !errors.As(err, &state) {
+		t.Errorf("expected a state error refunding a fully refunded sale, got %v", err)
+	}
+
+	// Every amount refunded adds up to the sale exactly
+	refunds := []*models.Transaction{first, second, rest}
+	total := sale.Result.GrandTotal
+	taxes := make(map[string]models.Money)
+	for _, entry := range sale.Result.Jurisdictions {
+		taxes[entry.Code] = taxes[entry.Code].Add(entry.TaxAmount)
+	}
+	for _, refund := range refunds {
+		total = total.Add(refund.Result.GrandTotal)
+		for _, entry := range refund.Result.Jurisdictions {
+			taxes[entry.Code] = taxes[entry.Code].Add(entry.TaxAmount)
+		}
+	}
+	if !total.IsZero() {
+		t.Errorf("expected the refunds to cancel the sale, %s left", total)
+	}
+	for code, tax := range taxes {
+		if !tax.IsZero() {
+			t.Errorf("expected %s tax to be refunded in full, %s left", code, tax)
+		}
+	}
+	if sales := service.NexusTracker().Sales("acme", "TX", year); sales.Transactions != 1 || !sales.Sales.IsZero() {
+		t.Errorf("expected no sales left, got %+v", sales)
+	}
+
+	updated, _ := ledger.Transaction("INV-1")
+	if len(updated.Refunds) != 3 || updated.Returned["lamp"] != 3 || updated.Returned["shade"] != 1 {
+		t.Errorf("expected the sale to record its refunds, got %+v", updated)
+	}
+}
+
+func TestLedger_RefundStateErrors(t *testing.T) {
+	_, ledger, _ := newRefundLedger(t)
+	if _, err := ledger.Create("Q-1", newLedgerRequest("10.00"), false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	refund, err := ledger.Refund("INV-1", &models.RefundRequest{Items: []models.RefundItem{{ItemID: "shade", Quantity: 1}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var state *TransactionStateError
+	for name, action := range map[string]func() error{
+		"refund a quote":         func() error { _, err := ledger.Refund("Q-1", &models.RefundRequest{}); return err },
+		"refund a refund":        func() error { _, err := ledger.Refund(refund.Code, &models.RefundRequest{}); return err },
+		"void a refund":          func() error { _, err := ledger.Void(refund.Code, ""); return err },
+		"void a refunded sale":   func() error { _, err := ledger.Void("INV-1", ""); return err },
+		"adjust a refunded sale": func() error { _, err := ledger.Adjust("INV-1", "fix", newLedgerRequest("1.00")); return err },
+	} {
+		if err := action(); !errors.As(err, &state) {
+			t.Errorf("%s: expected a state error, got %v", name, err)
+		}
+	}
+
+	if _, err := ledger.Refund("INV-1", &models.RefundRequest{Items: []models.RefundItem{{ItemID: "desk", Quantity: 1}}}); err == nil {
+		t.Error("expected an error returning an item not on the sale")
+	}
+	if _, err := ledger.Refund("INV-1", &models.RefundRequest{Items: []models.RefundItem{{ItemID: "lamp", Quantity: 0}}}); err == nil {
+		t.Error("expected an error returning no units")
+	}
+	if _, err := ledger.Refund("INV-9", &models.RefundRequest{}); !errors.Is(err, ErrTransactionNotFound) {
+		t.Errorf("expected transaction not found, got %v", err)
+	}
+}
+
+// failingRepository fails the writes it is told to
+type failingRepository struct {
+	*MemoryTransactionRepository
+	failCreate, failUpdate bool
+}
+
+func (r *failingRepository) Create(txn *models.Transaction) error {
+	if r.failCreate {
+		return errors.New("disk full")
+	}
+	return r.MemoryTransactionRepository.Create(txn)
+}
+
+func (r *failingRepository) Update(txn *models.Transaction) error {
+	if r.failUpdate {
+		return errors.New("disk full")
+	}
+	return r.MemoryTransactionRepository.Update(txn)
+}
+
+func TestLedger_RefundWriteFailures(t *testing.T) {
+	repo := &failingRepository{MemoryTransactionRepository: NewMemoryTransactionRepository()}
+	ledger, err := NewLedger(NewTaxService(DefaultRateProvider()), repo)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := ledger.Create("INV-1", newLedgerRequest("10.00"), true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := ledger.Create("INV-1-R1", newLedgerRequest("10.00"), false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := ledger.Refund("INV-1", &models.RefundRequest{}); !errors.Is(err, ErrTransactionExists) {
+		t.Errorf("expected the taken refund code to be rejected, got %v", err)
+	}
+
+	for name, fail := range map[string]*bool{"update": &repo.failUpdate, "create": &repo.failCreate} {
+		*fail = true
+		if _, err := ledger.Refund("INV-1", &models.RefundRequest{Code: "RMA-1"}); err == nil {
+			t.Errorf("%s: expected the write error", name)
+		}
+		*fail = false
+		if _, err := repo.Get("RMA-1"); !errors.Is(err, ErrTransactionNotFound) {
+			t.Errorf("%s: expected no refund to be saved, got %v", name, err)
+		}
+		if sale, _ := repo.Get("INV-1"); len(sale.Refunds) != 0 || len(sale.Returned) != 0 {
+			t.Errorf("%s: expected the sale unchanged, got %+v", name, sale)
+		}
+	}
+
+	if _, err := ledger.Refund("INV-1", &models.RefundRequest{Code: "RMA-1"}); err != nil {
+		t.Errorf("expected the refund to succeed once writes do, got %v", err)
+	}
+}
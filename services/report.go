@@ -0,0 +1,280 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+// Liability report periods
+const (
+	PeriodMonth   = "month"
+	PeriodQuarter = "quarter"
+)
+
+// Report sources. Calculated orders and committed sales are never reported
+// together, since an order priced at checkout is usually committed as well.
+const (
+	SourceCalculated = TransactionCalculated // orders priced through the calculate endpoints
+	SourceCommitted  = TransactionCommitted  // sales committed in the transaction ledger, net of refunds
+)
+
+// liabilityColumns is the header of a liability report CSV
+var liabilityColumns = []string{
+	"period", "period_start", "period_end", "jurisdiction_code", "jurisdiction_name", "jurisdiction_type",
+	"currency", "transactions", "gross_sales", "exempt_sales", "taxable_sales", "tax_collected",
+}
+
+// LiabilityQuery selects the transactions a liability report covers and how
+// they are grouped
+type LiabilityQuery struct {
+	Period   string       // PeriodMonth (the default) or PeriodQuarter
+	From     *models.Date // first day reported, inclusive
+	To       *models.Date // last day reported, inclusive
+	SellerID string       // only this seller's transactions, when set
+	Source   string       // SourceCalculated (the default) or SourceCommitted
+}
+
+// LiabilityReport totals the recorded calculations or, from SourceCommitted,
+// the committed sales and refunds in the ledger by jurisdiction and period.
+// A sale is reported on its transaction date and a refund on the day it was
+// issued. Transactions the seller had no nexus for are left out since
+// nothing was collected on them.
+func (l *Ledger) LiabilityReport(query LiabilityQuery) (*models.LiabilityReport, error) {
+	var err error
+	if query.Source, err = reportSource(query.Source); err != nil {
+		return nil, err
+	}
+	if query.Period == "" {
+		query.Period = PeriodMonth
+	}
+	if query.Period != PeriodMonth && query.Period != PeriodQuarter {
+		return nil, fmt.Errorf("period %q is not one of month or quarter", query.Period)
+	}
+	if query.From != nil && query.To != nil && query.To.Before(*query.From) {
+		return nil, fmt.Errorf("to %s is before from %s", query.To, query.From)
+	}
+
+	txns, err := l.repo.List()
+	if err != nil {
+		return nil, err
+	}
+	builder := newLiabilityBuilder(query.Period)
+	for i := range txns {
+		txn := &txns[i]
+		if txn.Status != query.Source || (query.SellerID != "" && txn.Request.SellerID != query.SellerID) {
+			continue
+		}
+		if nexus := txn.Result.Nexus; nexus != nil && nexus.Reason == NoNexusReason {
+			continue
+		}
+		date := reportDate(txn)
+		if (query.From != nil && date.Before(*query.From)) || (query.To != nil && date.After(*query.To)) {
+			continue
+		}
+		builder.add(txn, date)
+	}
+
+	return &models.LiabilityReport{
+		Period: query.Period,
+		Source: query.Source,
+		From:   query.From,
+		To:     query.To,
+		Rows:   builder.sorted(),
+	}, nil
+}
+
+// WriteLiabilityCSV writes a liability report as CSV with a header row
+func WriteLiabilityCSV(w io.Writer, report *models.LiabilityReport) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(liabilityColumns); err != nil {
+		return err
+	}
+	for _, row := range report.Rows {
+		record := []string{
+			row.Period, row.PeriodStart.String(), row.PeriodEnd.String(), row.Code, row.Name, row.Type,
+			row.Currency, strconv.Itoa(row.Transactions), row.GrossSales.String(), row.ExemptSales.String(),
+			row.TaxableSales.String(), row.TaxCollected.String(),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// reportSource checks a report source, defaulting to SourceCalculated. The
+// source is the status of the transactions reported.
+func reportSource(source string) (string, error) {
+	switch source {
+	case "":
+		return SourceCalculated, nil
+	case SourceCalculated, SourceCommitted:
+		return source, nil
+	}
+	return "", fmt.Errorf("source %q is not one of calculated or committed", source)
+}
+
+// reportDate is the day a transaction is reported on
+func reportDate(txn *models.Transaction) models.Date {
+	if isRefund(txn) && txn.CommittedAt != nil {
+		return models.DateOf(txn.CommittedAt.UTC())
+	}
+	return txn.Result.TransactionDate
+}
+
+// liabilityKey groups a report's rows
+type liabilityKey struct {
+	period   string
+	code     string
+	kind     string // jurisdiction type
+	currency string
+}
+
+// liabilityBuilder accumulates report rows
+type liabilityBuilder struct {
+	period string
+	rows   map[liabilityKey]*models.LiabilityRow
+}
+
+func newLiabilityBuilder(period string) *liabilityBuilder {
+	return &liabilityBuilder{period: period, rows: make(map[liabilityKey]*models.LiabilityRow)}
+}
+
+// add reports a transaction on date. Every line of the transaction counts
+// toward the gross sales of each jurisdiction the transaction was taxed in,
+// and toward its taxable sales when the jurisdiction taxed the line.
+func (b *liabilityBuilder) add(txn *models.Transaction, date models.Date) {
+	period, start, end := reportPeriod(b.period, date)
+	currency := txn.Result.Currency
+	for _, jurisdiction := range reportJurisdictions(txn) {
+		key := liabilityKey{period: period, code: jurisdiction.Code, kind: jurisdiction.Type, currency: currency}
+		row, ok := b.rows[key]
+		if !ok {
+			zero := models.NewMoney(0, currency)
+			row = &models.LiabilityRow{
+				Jurisdiction: jurisdiction,
+				Period:       period,
+				PeriodStart:  start,
+				PeriodEnd:    end,
+				Currency:     currency,
+				GrossSales:   zero,
+				ExemptSales:  zero,
+				TaxableSales: zero,
+				TaxCollected: zero,
+			}
+			b.rows[key] = row
+		}
+		if row.Name == "" {
+			row.Name = jurisdiction.Name
+		}
+		row.Transactions++
+
+		for _, item := range txn.Result.Items {
+			addLiabilityLine(row, jurisdiction.Code, item.Subtotal, item.Taxes)
+		}
+		for _, charge := range txn.Result.Charges {
+			addLiabilityLine(row, jurisdiction.Code, charge.Subtotal, charge.Taxes)
+		}
+	}
+}
+
+// sorted returns the rows by period, currency and jurisdiction code
+func (b *liabilityBuilder) sorted() []models.LiabilityRow {
+	rows := make([]models.LiabilityRow, 0, len(b.rows))
+	for _, row := range b.rows {
+		rows = append(rows, *row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Period != rows[j].Period {
+			return rows[i].Period < rows[j].Period
+		}
+		if rows[i].Currency != rows[j].Currency {
+			return rows[i].Currency < rows[j].Currency
+		}
+		return rows[i].Code < rows[j].Code
+	})
+	return rows
+}
+
+// reportPeriod returns the label, first and last day of the period containing
+// date
+func reportPeriod(period string, date models.Date) (string, models.Date, models.Date) {
+	year, month := date.Year(), date.Month()
+	if period == PeriodQuarter {
+		quarter := (int(month)-1)/3 + 1
+		start := models.NewDate(year, time.Month(quarter*3-2), 1)
+		end := models.NewDate(year, time.Month(quarter*3+1), 1).AddDays(-1)
+		return fmt.Sprintf("%d-Q%d", year, quarter), start, end
+	}
+	start := models.NewDate(year, month, 1)
+	end := models.NewDate(year, month+1, 1).AddDays(-1)
+	return fmt.Sprintf("%d-%02d", year, int(month)), start, end
+}
+
+// reportJurisdictions returns the jurisdictions a transaction was taxed in.
+// A transaction with no tax at all is reported under its destination state,
+// or its country outside the US, as wholly exempt; the jurisdiction's name is
+// left for a taxed transaction to fill in.
+func reportJurisdictions(txn *models.Transaction) []models.Jurisdiction {
+	var jurisdictions []models.Jurisdiction
+	seen := make(map[models.Jurisdiction]bool)
+	for _, entry := range txn.Result.Jurisdictions {
+		if !seen[entry.Jurisdiction] {
+			seen[entry.Jurisdiction] = true
+			jurisdictions = append(jurisdictions, entry.Jurisdiction)
+		}
+	}
+	if len(jurisdictions) > 0 {
+		return jurisdictions
+	}
+
+	address := txn.Result.Address
+	country := normalizeCountry(address.Country)
+	if state := strings.ToUpper(strings.TrimSpace(address.State)); country == "US" && state != "" {
+		return []models.Jurisdiction{{Code: state, Type: JurisdictionState}}
+	}
+	return []models.Jurisdiction{{Code: country, Type: JurisdictionCountry}}
+}
+
+// addLiabilityLine adds a line's sales, and the tax the jurisdiction with
+// code charged on it, to row
+func addLiabilityLine(row *models.LiabilityRow, code string, subtotal models.Money, taxes []models.Tax) {
+	taxable, tax := jurisdictionTax(code, row.Currency, taxes)
+	row.GrossSales = row.GrossSales.Add(subtotal)
+	row.TaxableSales = row.TaxableSales.Add(taxable)
+	row.ExemptSales = row.ExemptSales.Add(subtotal.Sub(taxable))
+	row.TaxCollected = row.TaxCollected.Add(tax)
+}
+
+// jurisdictionTax returns the part of a line the jurisdiction with code taxed
+// and the tax it charged. A line taxed at several of the jurisdiction's rates
+// is taxable once, at the largest of the rates' bases.
+func jurisdictionTax(code, currency string, taxes []models.Tax) (taxable, tax models.Money) {
+	taxable, tax = models.NewMoney(0, currency), models.NewMoney(0, currency)
+	for _, t := range taxes {
+		if t.Jurisdiction != code {
+			continue
+		}
+		if absUnits(t.TaxableAmount) > absUnits(taxable) {
+			taxable = t.TaxableAmount
+		}
+		tax = tax.Add(t.TaxAmount)
+	}
+	return taxable, tax
+}
+
+// absUnits returns the absolute value of an amount in minor units
+func absUnits(m models.Money) int64 {
+	if m.Units < 0 {
+		return -m.Units
+	}
+	return m.Units
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+func newReportLedger(t *testing.T) *Ledger {
+	t.Helper()
+	service := NewTaxService(DefaultRateProvider())
+	ledger, err := NewLedger(service, NewMemoryTransactionRepository())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sale := func(code, date string, commit bool, items ...models.Item) {
+		req := newLedgerRequest("0.00")
+		req.Items = items
+		req.TransactionDate = datePtr(date)
+		if _, err := ledger.Create(code, req, commit); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	lamp := func(price string) models.Item {
+		return models.Item{ID: "lamp", Price: models.MustParseMoney(price), Quantity: 1}
+	}
+	bread := models.Item{ID: "bread", Price: models.MustParseMoney("20.00"), Quantity: 1, TaxCategory: "groceries"}
+
+	sale("INV-1", "2025-05-10", true, lamp("100.00"), bread)
+	sale("INV-2", "2025-06-03", true, lamp("50.00"))
+	sale("Q-1", "2025-06-04", false, lamp("75.00"))
+	sale("INV-3", "2025-06-05", true, lamp("40.00"))
+	if _, err := ledger.Void("INV-3", "cancelled"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ledger.now = func() time.Time { return time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC) }
+	if _, err := ledger.Refund("INV-1", &models.RefundRequest{Items: []models.RefundItem{{ItemID: "lamp", Quantity: 1}}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return ledger
+}
+
+func findRow(rows []models.LiabilityRow, period, code string) *models.LiabilityRow {
+	for i := range rows {
+		if rows[i].Period == period && rows[i].Code == code {
+			return &rows[i]
+		}
+	}
+	return nil
+}
+
+func TestLedger_LiabilityReport(t *testing.T) {
+	ledger := newReportLedger(t)
+
+	tests := []struct {
+		period, row, code           string
+		transactions                int
+		gross, exempt, taxable, tax string
+	}{
+		{PeriodMonth, "2025-05", "TX", 1, "120.00", "20.00", "100.00", "6.25"},
+		{PeriodMonth, "2025-05", "TX-AUS", 1, "120.00", "20.00", "100.00", "1.00"},
+		// INV-2 less the refund of INV-1's lamp
+		{PeriodMonth, "2025-06", "TX", 2, "-50.00", "0.00", "-50.00", "-3.12"},
+		{PeriodQuarter, "2025-Q2", "TX", 3, "70.00", "20.00", "50.00", "3.13"},
+	}
+	for _, tt := range tests {
+		report, err := ledger.LiabilityReport(LiabilityQuery{Period: tt.period, Source: SourceCommitted})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		row := findRow(report.Rows, tt.row, tt.code)
+		if row == nil {
+			t.Errorf("%s %s: no row in %+v", tt.row, tt.code, report.Rows)
+			continue
+		}
+		got := []string{row.GrossSales.String(), row.ExemptSales.String(), row.TaxableSales.String(), row.TaxCollected.String()}
+		expected := []string{tt.gross, tt.exempt, tt.taxable, tt.tax}
+		if strings.Join(got, ",") != strings.Join(expected, ",") || row.Transactions != tt.transactions {
+			t.Errorf("%s %s: got %v in %d transactions, expected %v in %d", tt.row, tt.code, got, row.Transactions, expected, tt.transactions)
+		}
+	}
+
+	report, _ := ledger.LiabilityReport(LiabilityQuery{Period: PeriodQuarter, Source: SourceCommitted})
+	if row := findRow(report.Rows, "2025-Q2", "TX"); row == nil || row.Name != "Texas" || row.Type != JurisdictionState ||
+		row.PeriodStart.String() != "2025-04-01" || row.PeriodEnd.String() != "2025-06-30" {
+		t.Errorf("expected Texas for April to June, got %+v", row)
+	}
+
+	report, _ = ledger.LiabilityReport(LiabilityQuery{From: datePtr("2025-06-01"), To: datePtr("2025-06-10"), Source: SourceCommitted})
+	if row := findRow(report.Rows, "2025-06", "TX"); row == nil || row.GrossSales.String() != "50.00" || len(report.Rows) != 3 {
+		t.Errorf("expected only INV-2 from June 1 to 10, got %+v", report.Rows)
+	}
+	report, _ = ledger.LiabilityReport(LiabilityQuery{SellerID: "other", Source: SourceCommitted})
+	if len(report.Rows) != 0 {
+		t.Errorf("expected no rows for another seller, got %+v", report.Rows)
+	}
+
+	if _, err := ledger.LiabilityReport(LiabilityQuery{Period: "year"}); err == nil {
+		t.Error("expected an error for an unknown period")
+	}
+	if _, err := ledger.LiabilityReport(LiabilityQuery{From: datePtr("2025-06-10"), To: datePtr("2025-06-01")}); err == nil {
+		t.Error("expected an error for a reversed range")
+	}
+	if _, err := ledger.LiabilityReport(LiabilityQuery{Source: "quoted"}); err == nil {
+		t.Error("expected an error for an unknown source")
+	}
+}
+
+func TestLedger_LiabilityReportCalculated(t *testing.T) {
+	ledger := newReportLedger(t)
+	calculate := func(date, price string) {
+		req := newLedgerRequest(price)
+		req.TransactionDate = datePtr(date)
+		result, err := ledger.tax.CalculateTax(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := ledger.RecordCalculation(req, result); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	calculate("2025-06-01", "200.00")
+	calculate("2025-06-20", "10.00")
+
+	// The committed sales, quote and refund are left out
+	report, err := ledger.LiabilityReport(LiabilityQuery{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Source != SourceCalculated || len(report.Rows) != 3 {
+		t.Fatalf("expected the calculations in three jurisdictions, got %+v", report)
+	}
+	if row := findRow(report.Rows, "2025-06", "TX"); row == nil || row.Transactions != 2 ||
+		row.GrossSales.String() != "210.00" || row.TaxCollected.String() != "13.13" {
+		t.Errorf("expected both calculations in June, got %+v", row)
+	}
+
+	report, _ = ledger.LiabilityReport(LiabilityQuery{Source: SourceCommitted})
+	if row := findRow(report.Rows, "2025-06", "TX"); row == nil || row.GrossSales.String() != "-50.00" {
+		t.Errorf("expected the committed sales without the calculations, got %+v", row)
+	}
+}
+
+func TestLedger_LiabilityReportUntaxedOrder(t *testing.T) {
+	ledger, _ := NewLedger(NewTaxService(DefaultRateProvider()), NewMemoryTransactionRepository())
+	req := newLedgerRequest("0.00")
+	req.Items = []models.Item{{ID: "bread", Price: models.MustParseMoney("10.00"), Quantity: 1, TaxCategory: "groceries"}}
+	req.TransactionDate = datePtr("2025-05-10")
+	if _, err := ledger.Create("INV-1", req, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	report, _ := ledger.LiabilityReport(LiabilityQuery{Source: SourceCommitted})
+	if len(report.Rows) != 1 || report.Rows[0].Code != "TX" || report.Rows[0].ExemptSales.String() != "10.00" {
+		t.Errorf("expected the order as exempt Texas sales, got %+v", report.Rows)
+	}
+}
+
+func TestWriteLiabilityCSV(t *testing.T) {
+	report := &models.LiabilityReport{Period: PeriodMonth, Rows: []models.LiabilityRow{{
+		Jurisdiction: models.Jurisdiction{Code: "TX", Name: "Texas", Type: JurisdictionState},
+		Period:       "2025-05",
+		PeriodStart:  models.MustParseDate("2025-05-01"),
+		PeriodEnd:    models.MustParseDate("2025-05-31"),
+		Currency:     "USD",
+		Transactions: 2,
+		GrossSales:   models.MustParseMoney("120.00"),
+		ExemptSales:  models.MustParseMoney("20.00"),
+		TaxableSales: models.MustParseMoney("100.00"),
+		TaxCollected: models.MustParseMoney("6.25"),
+	}}}
+
+	var buf bytes.Buffer
+	if err := WriteLiabilityCSV(&buf, report); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "period,period_start,period_end,jurisdiction_code,jurisdiction_name,jurisdiction_type,currency,transactions,gross_sales,exempt_sales,taxable_sales,tax_collected\n" +
+		"2025-05,2025-05-01,2025-05-31,TX,Texas,state,USD,2,120.00,20.00,100.00,6.25\n"
+	if buf.String() != expected {
+		t.Errorf("got\n%s\nexpected\n%s", buf.String(), expected)
+	}
+}
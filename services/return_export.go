@@ -0,0 +1,226 @@
+package services
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+// returnExportColumns is the header of every return export
+var returnExportColumns = []string{"line", "description", "location_code", "location_name", "amount"}
+
+// ReturnExporter writes a state return laid out like the lines of the
+// state's return form, so the figures can be keyed in or uploaded
+type ReturnExporter interface {
+	Export(w io.Writer, ret *models.StateReturn) error
+}
+
+// ReturnExporters picks the exporter for a state, falling back to a generic
+// layout for states without one of their own
+type ReturnExporters struct {
+	exporters map[string]ReturnExporter
+	fallback  ReturnExporter
+}
+
+// NewReturnExporters creates a registry that uses fallback for states with
+// no exporter registered
+func NewReturnExporters(fallback ReturnExporter) *ReturnExporters {
+	return &ReturnExporters{exporters: make(map[string]ReturnExporter), fallback: fallback}
+}
+
+// DefaultReturnExporters returns the generic layout and the built-in Texas
+// and California forms
+func DefaultReturnExporters() *ReturnExporters {
+	e := NewReturnExporters(GenericReturnExporter{})
+	e.Register("TX", texasReturnForm)
+	e.Register("CA", californiaReturnForm)
+	return e
+}
+
+// Register routes returns for a state code to exporter
+func (e *ReturnExporters) Register(state string, exporter ReturnExporter) {
+	e.exporters[strings.ToUpper(state)] = exporter
+}
+
+// For returns the exporter for a state code
+func (e *ReturnExporters) For(state string) ReturnExporter {
+	if exporter, ok := e.exporters[strings.ToUpper(state)]; ok {
+		return exporter
+	}
+	return e.fallback
+}
+
+// GenericReturnExporter lists every figure of a return: for the state and
+// each local jurisdiction, gross sales, each deduction by reason, total
+// deductions, taxable sales and tax due
+type GenericReturnExporter struct{}
+
+// Export writes the return as CSV
+func (GenericReturnExporter) Export(w io.Writer, ret *models.StateReturn) error {
+	writer := newReturnWriter(w)
+	for i := range ret.Locations {
+		location := &ret.Locations[i]
+		code, name := location.Code, location.Name
+		writer.line("gross_sales", "Gross sales", code, name, location.GrossSales)
+		for _, deduction := range location.Deductions {
+			writer.line("deduction_"+deduction.Reason, "Deduction: "+strings.ReplaceAll(deduction.Reason, "_", " "),
+				code, name, deduction.Amount)
+		}
+		writer.line("total_deductions", "Total deductions", code, name, location.TotalDeductions)
+		writer.line("taxable_sales", "Taxable sales", code, name, location.TaxableSales)
+		writer.line("tax_due", "Tax due", code, name, location.TaxDue)
+	}
+	return writer.flush()
+}
+
+// ReturnForm lays out a state's return as lines computed from the return's
+// figures: summary lines for the state and schedule lines repeated for each
+// local jurisdiction
+type ReturnForm struct {
+	Summary  []ReturnLine
+	Schedule []ReturnLine
+	// LocationCodes maps jurisdiction codes to the codes the state's form
+	// uses; jurisdictions not listed keep their own code
+	LocationCodes map[string]string
+}
+
+// ReturnLine is one line of a return form
+type ReturnLine struct {
+	Line        string
+	Description string
+	Amount      func(location *models.ReturnLocation) models.Money
+}
+
+// Export writes the summary lines for the state, then the schedule lines for
+// each local jurisdiction, as CSV
+func (f *ReturnForm) Export(w io.Writer, ret *models.StateReturn) error {
+	writer := newReturnWriter(w)
+	state := &models.ReturnLocation{Jurisdiction: models.Jurisdiction{Code: ret.State}}
+	var locals []*models.ReturnLocation
+	for i := range ret.Locations {
+		if ret.Locations[i].Code == ret.State {
+			state = &ret.Locations[i]
+		} else {
+			locals = append(locals, &ret.Locations[i])
+		}
+	}
+
+	for _, line := range f.Summary {
+		writer.line(line.Line, line.Description, "", "", line.Amount(state))
+	}
+	for _, location := range locals {
+		code := location.Code
+		if mapped, ok := f.LocationCodes[code]; ok {
+			code = mapped
+		}
+		for _, line := range f.Schedule {
+			writer.line(line.Line, line.Description, code, location.Name, line.Amount(location))
+		}
+	}
+	return writer.flush()
+}
+
+// Amounts for return form lines
+var (
+	grossSales      = func(l *models.ReturnLocation) models.Money { return l.GrossSales }
+	totalDeductions = func(l *models.ReturnLocation) models.Money { return l.TotalDeductions }
+	taxableSales    = func(l *models.ReturnLocation) models.Money { return l.TaxableSales }
+	taxDue          = func(l *models.ReturnLocation) models.Money { return l.TaxDue }
+	nothing         = func(*models.ReturnLocation) models.Money { return models.NewMoney(0, "USD") }
+)
+
+// deductions returns a line amount adding up the deductions for reasons
+func deductions(reasons ...string) func(*models.ReturnLocation) models.Money {
+	return func(l *models.ReturnLocation) models.Money {
+		total := models.NewMoney(0, l.GrossSales.Currency)
+		for _, deduction := range l.Deductions {
+			if containsString(reasons, deduction.Reason) {
+				total = total.Add(deduction.Amount)
+			}
+		}
+		return total
+	}
+}
+
+// otherDeductions returns a line amount adding up the deductions for every
+// reason except reasons
+func otherDeductions(reasons ...string) func(*models.ReturnLocation) models.Money {
+	listed := deductions(reasons...)
+	return func(l *models.ReturnLocation) models.Money {
+		return l.TotalDeductions.Sub(listed(l))
+	}
+}
+
+// texasReturnForm follows the Texas Sales and Use Tax Return: total sales,
+// taxable sales and taxable purchases, with local taxable sales reported by
+// the Comptroller's local jurisdiction code
+var texasReturnForm = &ReturnForm{
+	Summary: []ReturnLine{
+		{"total_sales", "Total Texas sales", grossSales},
+		{"taxable_sales", "Taxable sales", taxableSales},
+		{"taxable_purchases", "Taxable purchases", nothing},
+		{"state_tax_due", "State tax due", taxDue},
+	},
+	Schedule: []ReturnLine{
+		{"local_taxable_sales", "Local taxable sales", taxableSales},
+		{"local_tax_due", "Local tax due", taxDue},
+	},
+	LocationCodes: map[string]string{
+		"TX-AUS":  "2227013",
+		"TX-CMTA": "3227998",
+	},
+}
+
+// californiaReturnForm follows the California sales and use tax return:
+// gross sales less itemized deductions, with district taxes scheduled by
+// location
+var californiaReturnForm = &ReturnForm{
+	Summary: []ReturnLine{
+		{"gross_sales", "Total (gross) sales", grossSales},
+		{"purchases_subject_to_use_tax", "Purchases subject to use tax", nothing},
+		{"resale", "Sales to other retailers for purposes of resale", deductions("resale")},
+		{"food", "Nontaxable sales of food products", deductions("exempt_groceries")},
+		{"government", "Sales to the United States Government", deductions("government")},
+		{"prescription_medicine", "Nontaxable sales of prescription medicine", deductions("exempt_prescription_drugs")},
+		{"returns", "Returned taxable merchandise", deductions(DeductionReturns)},
+		{"other_deductions", "Other deductions", otherDeductions(
+			"resale", "exempt_groceries", "government", "exempt_prescription_drugs", DeductionReturns)},
+		{"total_deductions", "Total deductions", totalDeductions},
+		{"taxable_sales", "Total taxable amount", taxableSales},
+		{"state_tax_due", "State tax due", taxDue},
+	},
+	Schedule: []ReturnLine{
+		{"district_taxable_sales", "District taxable sales", taxableSales},
+		{"district_tax_due", "District tax due", taxDue},
+	},
+}
+
+// returnWriter writes the rows of a return export
+type returnWriter struct {
+	csv *csv.Writer
+	err error
+}
+
+func newReturnWriter(w io.Writer) *returnWriter {
+	writer := &returnWriter{csv: csv.NewWriter(w)}
+	writer.err = writer.csv.Write(returnExportColumns)
+	return writer
+}
+
+// line writes one row; the first error is kept for flush
+func (w *returnWriter) line(line, description, code, name string, amount models.Money) {
+	if w.err == nil {
+		w.err = w.csv.Write([]string{line, description, code, name, amount.String()})
+	}
+}
+
+// flush flushes the rows and returns the first error
+func (w *returnWriter) flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	w.csv.Flush()
+	return w.csv.Error()
+}
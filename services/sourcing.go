@@ -0,0 +1,98 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+// Sourcing methods decide which address's local rates tax a sale
+const (
+	// SourcingDestination taxes at the rates where the buyer receives the goods
+	SourcingDestination = "destination"
+	// SourcingOrigin taxes intrastate sales at the rates where they ship from
+	SourcingOrigin = "origin"
+	// SourcingHybrid taxes intrastate sales at the origin's state, county and
+	// city rates and the destination's special district rates, as California
+	// does
+	SourcingHybrid = "hybrid"
+)
+
+// validSourcing reports whether a rate table may give a state the sourcing
+// method; empty means destination
+func validSourcing(method string) bool {
+	switch method {
+	case "", SourcingDestination, SourcingOrigin, SourcingHybrid:
+		return true
+	}
+	return false
+}
+
+// shipFrom returns the address an item ships from: its own ship_from, else
+// the request's, else nil when the origin is unknown
+func shipFrom(req *models.TaxRequest, item *models.Item) *models.Address {
+	if item.ShipFrom != nil {
+		return item.ShipFrom
+	}
+	return req.ShipFrom
+}
+
+// intrastate reports whether a sale ships within one US state
+func intrastate(from, to *models.Address) bool {
+	return normalizeCountry(from.Country) == normalizeCountry(to.Country) &&
+		strings.EqualFold(strings.TrimSpace(from.State), strings.TrimSpace(to.State))
+}
+
+// sourceLayers resolves the jurisdiction stack for an item. Sales into a
+// state that sources by destination, interstate sales and sales with no ship
+// from address use the destination's layers; intrastate sales into an origin
+// or hybrid state use the ship from address's layers, keeping the
+// destination's special districts when hybrid. The sourcing method applied is
+// returned with the layers.
+func sourceLayers(rates RateProvider, req *models.TaxRequest, item *models.Item) (*RateLookup, string, error) {
+	date := transactionDate(req)
+	destination, err := rates.JurisdictionsFor(&req.Address, date)
+	if err != nil {
+		return nil, "", err
+	}
+
+	from := shipFrom(req, item)
+	method := destination.Sourcing
+	if method == "" || from == nil || !intrastate(from, &req.Address) {
+		return destination, SourcingDestination, nil
+	}
+
+	origin, err := rates.JurisdictionsFor(from, date)
+	if err != nil {
+		return nil, "", fmt.Errorf("ship_from: %w", err)
+	}
+	if method == SourcingOrigin {
+		return origin, method, nil
+	}
+
+	hybrid := &RateLookup{MatchLevel: origin.MatchLevel, Sourcing: method}
+	for _, layer := range origin.Layers {
+		if layer.Jurisdiction.Type != JurisdictionSpecial {
+			hybrid.Layers = append(hybrid.Layers, layer)
+		}
+	}
+	for _, layer := range destination.Layers {
+		if layer.Jurisdiction.Type == JurisdictionSpecial {
+			hybrid.Layers = append(hybrid.Layers, layer)
+		}
+	}
+	return hybrid, method, nil
+}
+
+// validateShipFrom checks a ship from address names a country and a ZIP or
+// postal code
+func validateShipFrom(address *models.Address) error {
+	if address.Country == "" {
+		return fmt.Errorf("ship_from country is required")
+	}
+	if address.ZipCode == "" && address.PostalCode == "" {
+		return fmt.Errorf("ship_from zipcode or postal_code is required")
+	}
+	return nil
+}
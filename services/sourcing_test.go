@@ -0,0 +1,130 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+// sourcingTable has an origin state, a hybrid state and a destination state,
+// each with two local areas
+const sourcingTable = `{
+	"version": "test",
+	"states": [
+		{"code": "TX", "name": "Texas", "rate": "6.25", "sourcing": "origin"},
+		{"code": "CA", "name": "California", "rate": "7.25", "sourcing": "hybrid"},
+		{"code": "NY", "name": "New York", "rate": "4"}
+	],
+	"local_jurisdictions": [
+		{"code": "TX-AUS", "name": "City of Austin", "type": "city", "state": "TX", "rate": "1", "zip_codes": ["78701"]},
+		{"code": "TX-HOU", "name": "City of Houston", "type": "city", "state": "TX", "rate": "1.5", "zip_codes": ["77002"]},
+		{"code": "CA-LAC", "name": "Los Angeles County", "type": "county", "state": "CA", "rate": "2.25", "zip_codes": ["90012"]},
+		{"code": "CA-LAD", "name": "Los Angeles District", "type": "special", "state": "CA", "rate": "0.25", "zip_codes": ["90012"]},
+		{"code": "CA-SFC", "name": "San Francisco County", "type": "county", "state": "CA", "rate": "1.25", "zip_codes": ["94103"]},
+		{"code": "CA-SFD", "name": "San Francisco District", "type": "special", "state": "CA", "rate": "0.5", "zip_codes": ["94103"]},
+		{"code": "NYC", "name": "New York City", "type": "city", "state": "NY", "rate": "4.5", "zip_codes": ["10001"]},
+		{"code": "NY-BUF", "name": "Erie County", "type": "county", "state": "NY", "rate": "4.75", "zip_codes": ["14202"]}
+	]
+}`
+
+func TestSourceLayers(t *testing.T) {
+	provider, err := ParseRateTable(strings.NewReader(sourcingTable))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	austin := &models.Address{Country: "US", State: "TX", ZipCode: "78701"}
+	houston := &models.Address{Country: "US", State: "TX", ZipCode: "77002"}
+	losAngeles := &models.Address{Country: "US", State: "CA", ZipCode: "90012"}
+	sanFrancisco := &models.Address{Country: "US", State: "CA", ZipCode: "94103"}
+	newYork := &models.Address{Country: "US", State: "NY", ZipCode: "10001"}
+	buffalo := &models.Address{Country: "US", State: "NY", ZipCode: "14202"}
+
+	tests := []struct {
+		name     string
+		to       *models.Address
+		from     *models.Address
+		itemFrom *models.Address
+		method   string
+		codes    string
+	}{
+		{"no ship from", houston, nil, nil, SourcingDestination, "TX,TX-HOU"},
+		{"origin state intrastate", houston, austin, nil, SourcingOrigin, "TX,TX-AUS"},
+		{"origin state interstate", houston, losAngeles, nil, SourcingDestination, "TX,TX-HOU"},
+		{"item overrides request", houston, losAngeles, austin, SourcingOrigin, "TX,TX-AUS"},
+		{"hybrid keeps destination districts", sanFrancisco, losAngeles, nil, SourcingHybrid, "CA,CA-LAC,CA-SFD"},
+		{"destination state intrastate", newYork, buffalo, nil, SourcingDestination, "NY,NYC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &models.TaxRequest{Address: *tt.to, ShipFrom: tt.from, TransactionDate: &testDate}
+			item := &models.Item{ID: "item1", ShipFrom: tt.itemFrom}
+
+			lookup, method, err := sourceLayers(provider, req, item)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if method != tt.method {
+				t.Errorf("expected sourcing %s, got %s", tt.method, method)
+			}
+			var codes []string
+			for _, layer := range lookup.Layers {
+				codes = append(codes, layer.Jurisdiction.Code)
+			}
+			if strings.Join(codes, ",") != tt.codes {
+				t.Errorf("expected layers %s, got %s", tt.codes, strings.Join(codes, ","))
+			}
+		})
+	}
+}
+
+func TestCalculateTax_ShipFrom(t *testing.T) {
+	service := NewTaxService(DefaultRateProvider())
+
+	req := &models.TaxRequest{
+		Address:  models.Address{Country: "US", State: "TX", City: "Dallas", ZipCode: "75201"},
+		ShipFrom: &models.Address{Country: "US", State: "TX", City: "Austin", ZipCode: "78701"},
+		Items: []models.Item{
+			{ID: "austin", Price: models.MustParseMoney("100.00"), Quantity: 1},
+			{ID: "oregon", Price: models.MustParseMoney("100.00"), Quantity: 1,
+				ShipFrom: &models.Address{Country: "US", State: "OR", ZipCode: "97201"}},
+		},
+		TransactionDate: &testDate,
+	}
+
+	resp, err := service.CalculateTax(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Austin's 6.25% state, 1% city and 1% transit rates replace Dallas's
+	// estimated local rate for the intrastate sale
+	if got := resp.Items[0]; got.Sourcing != SourcingOrigin || got.TaxAmount.String() != "8.25" {
+		t.Errorf("expected origin sourcing with tax 8.25, got %s %s", got.Sourcing, got.TaxAmount)
+	}
+	if got := resp.Items[1]; got.Sourcing != SourcingDestination || got.TaxAmount.String() != "8.20" {
+		t.Errorf("expected destination sourcing with tax 8.20, got %s %s", got.Sourcing, got.TaxAmount)
+	}
+
+	// The match level is that of the address each item was taxed at, the
+	// least precise when they differ
+	if resp.RateMatchLevel != MatchState {
+		t.Errorf("expected match level %s for Dallas's estimate, got %s", MatchState, resp.RateMatchLevel)
+	}
+	origin := *req
+	origin.Items = origin.Items[:1]
+	resp, err = service.CalculateTax(&origin)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.RateMatchLevel != MatchZip5 {
+		t.Errorf("expected match level %s for Austin, got %s", MatchZip5, resp.RateMatchLevel)
+	}
+
+	req.ShipFrom = &models.Address{Country: "US", State: "TX"}
+	if _, err := service.CalculateTax(req); err == nil {
+		t.Error("expected an error for a ship_from address without a ZIP code")
+	}
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+// Deduction reasons that are not taken from a certificate or product category
+const (
+	DeductionReturns = "returns" // taxable goods refunded to the buyer
+	DeductionOther   = "other"
+)
+
+// ReturnQuery selects the transactions a state return covers
+type ReturnQuery struct {
+	State    string // the US state's postal code
+	Period   string // a month such as "2025-06" or a quarter such as "2025-Q2"
+	SellerID string // only this seller's transactions, when set
+	Source   string // SourceCalculated (the default) or SourceCommitted
+}
+
+// StateReturn gathers the figures for a US state's return for the query's
+// period from the recorded calculations or, from SourceCommitted, the
+// committed transactions shipped to the state. Lines a jurisdiction did not
+// tax are deducted by the reason they were not taxed: the exemption
+// certificate's reason, "exempt_" and the product category, or "nontaxable_"
+// and the charge type. Refunds issued in the period deduct the taxable goods
+// returned as "returns" and take exempt goods back out of gross sales and
+// their deduction.
+func (l *Ledger) StateReturn(query ReturnQuery) (*models.StateReturn, error) {
+	state := strings.ToUpper(strings.TrimSpace(query.State))
+	if len(state) != 2 || !isJurisdictionCode(state) {
+		return nil, fmt.Errorf("state %q is not a state code", state)
+	}
+	label, start, end, err := parsePeriod(query.Period)
+	if err != nil {
+		return nil, err
+	}
+	source, err := reportSource(query.Source)
+	if err != nil {
+		return nil, err
+	}
+	txns, err := l.repo.List()
+	if err != nil {
+		return nil, err
+	}
+
+	builder := &returnBuilder{
+		ret:       &models.StateReturn{State: state, Period: label, Source: source, PeriodStart: start, PeriodEnd: end, Currency: "USD"},
+		locations: make(map[string]*returnLocation),
+	}
+	for i := range txns {
+		txn := &txns[i]
+		address := txn.Result.Address
+		if txn.Status != source || (query.SellerID != "" && txn.Request.SellerID != query.SellerID) ||
+			normalizeCountry(address.Country) != "US" || strings.ToUpper(strings.TrimSpace(address.State)) != state {
+			continue
+		}
+		if nexus := txn.Result.Nexus; nexus != nil && nexus.Reason == NoNexusReason {
+			continue
+		}
+		if date := reportDate(txn); date.Before(start) || date.After(end) {
+			continue
+		}
+		builder.add(txn)
+	}
+	return builder.build(), nil
+}
+
+// parsePeriod parses a month "2025-06" or quarter "2025-Q2" and returns it
+// with its first and last day
+func parsePeriod(period string) (string, models.Date, models.Date, error) {
+	period = strings.ToUpper(strings.TrimSpace(period))
+	invalid := fmt.Errorf("period %q is not a month like 2025-06 or a quarter like 2025-Q2", period)
+	parts := strings.Split(period, "-")
+	if len(parts) != 2 || len(parts[0]) != 4 {
+		return "", models.Date{}, models.Date{}, invalid
+	}
+	year, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return "", models.Date{}, models.Date{}, invalid
+	}
+	kind, month := PeriodMonth, parts[1]
+	if strings.HasPrefix(month, "Q") {
+		kind, month = PeriodQuarter, month[1:]
+	}
+	n, err := strconv.Atoi(month)
+	if err != nil || n < 1 || (kind == PeriodMonth && (n > 12 || len(month) != 2)) || (kind == PeriodQuarter && n > 4) {
+		return "", models.Date{}, models.Date{}, invalid
+	}
+	if kind == PeriodQuarter {
+		n = n*3 - 2
+	}
+	label, start, end := reportPeriod(kind, models.NewDate(year, time.Month(n), 1))
+	return label, start, end, nil
+}
+
+// returnLocation accumulates one jurisdiction's figures
+type returnLocation struct {
+	models.ReturnLocation
+	deductions map[string]models.Money
+}
+
+// returnBuilder accumulates a state return
+type returnBuilder struct {
+	ret       *models.StateReturn
+	locations map[string]*returnLocation
+}
+
+// add adds a transaction's lines to each jurisdiction it was taxed in
+func (b *returnBuilder) add(txn *models.Transaction) {
+	refund := isRefund(txn)
+	for _, jurisdiction := range reportJurisdictions(txn) {
+		location := b.location(jurisdiction)
+		location.Transactions++
+		for _, item := range txn.Result.Items {
+			reason := exemptionReason(txn, jurisdiction)
+			if reason == "" && item.Taxability != nil && item.Taxability.Treatment == TreatmentExempt {
+				reason = "exempt_" + firstNonEmpty(item.TaxCategory, "product")
+			}
+			location.addLine(jurisdiction.Code, item.Subtotal, item.Taxes, firstNonEmpty(reason, DeductionOther), refund)
+		}
+		for _, charge := range txn.Result.Charges {
+			reason := firstNonEmpty(exemptionReason(txn, jurisdiction), "nontaxable_"+charge.Type)
+			location.addLine(jurisdiction.Code, charge.Subtotal, charge.Taxes, reason, refund)
+		}
+	}
+}
+
+// location returns the accumulator for a jurisdiction, creating it on first
+// use
+func (b *returnBuilder) location(jurisdiction models.Jurisdiction) *returnLocation {
+	location, ok := b.locations[jurisdiction.Code]
+	if !ok {
+		zero := models.NewMoney(0, b.ret.Currency)
+		location = &returnLocation{
+			ReturnLocation: models.ReturnLocation{
+				Jurisdiction:    jurisdiction,
+				GrossSales:      zero,
+				TotalDeductions: zero,
+				TaxableSales:    zero,
+				TaxDue:          zero,
+			},
+			deductions: make(map[string]models.Money),
+		}
+		b.locations[jurisdiction.Code] = location
+	}
+	if location.Name == "" {
+		location.Name = jurisdiction.Name
+	}
+	return location
+}
+
+// addLine adds one line of a sale or refund. The part the jurisdiction did
+// not tax is deducted for reason.
+func (l *returnLocation) addLine(code string, subtotal models.Money, taxes []models.Tax, reason string, refund bool) {
+	taxable, tax := jurisdictionTax(code, subtotal.Currency, taxes)
+	exempt := subtotal.Sub(taxable)
+	l.TaxableSales = l.TaxableSales.Add(taxable)
+	l.TaxDue = l.TaxDue.Add(tax)
+	l.deduct(reason, exempt)
+	if refund {
+		// Refunded taxable goods stay in gross sales and are deducted as
+		// returns; refunded exempt goods come back out of gross sales
+		l.GrossSales = l.GrossSales.Add(exempt)
+		l.deduct(DeductionReturns, taxable.Neg())
+		return
+	}
+	l.GrossSales = l.GrossSales.Add(subtotal)
+}
+
+// deduct adds amount to the deduction for reason
+func (l *returnLocation) deduct(reason string, amount models.Money) {
+	if amount.IsZero() {
+		return
+	}
+	l.deductions[reason] = l.deductions[reason].Add(amount)
+	l.TotalDeductions = l.TotalDeductions.Add(amount)
+}
+
+// build returns the return with the state first and the local jurisdictions
+// by code, each with its deductions by reason
+func (b *returnBuilder) build() *models.StateReturn {
+	for _, location := range b.locations {
+		reasons := make([]string, 0, len(location.deductions))
+		for reason := range location.deductions {
+			reasons = append(reasons, reason)
+		}
+		sort.Strings(reasons)
+		location.Deductions = []models.ReturnDeduction{}
+		for _, reason := range reasons {
+			if amount := location.deductions[reason]; !amount.IsZero() {
+				location.Deductions = append(location.Deductions, models.ReturnDeduction{Reason: reason, Amount: amount})
+			}
+		}
+		b.ret.Locations = append(b.ret.Locations, location.ReturnLocation)
+	}
+
+	state := b.ret.State
+	sort.Slice(b.ret.Locations, func(i, j int) bool {
+		a, c := b.ret.Locations[i], b.ret.Locations[j]
+		if (a.Code == state) != (c.Code == state) {
+			return a.Code == state
+		}
+		return a.Code < c.Code
+	})
+	if b.ret.Locations == nil {
+		b.ret.Locations = []models.ReturnLocation{}
+	}
+	return b.ret
+}
+
+// exemptionReason returns the reason of the certificate that removed the
+// jurisdiction's tax from the transaction, or "" if none did
+func exemptionReason(txn *models.Transaction, jurisdiction models.Jurisdiction) string {
+	for _, exemption := range txn.Result.Exemptions {
+		for _, code := range exemption.Jurisdictions {
+			if strings.EqualFold(code, jurisdiction.Code) || strings.EqualFold(code, txn.Result.Address.State) ||
+				strings.EqualFold(code, normalizeCountry(txn.Result.Address.Country)) {
+				return exemption.Reason
+			}
+		}
+	}
+	return ""
+}
+
+// firstNonEmpty returns the first of values that is not empty
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
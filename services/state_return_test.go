@@ -0,0 +1,188 @@
+package services
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// newReturnLedger records a quarter of sales in Texas and California: taxable
+// and exempt goods, shipping, a sale for resale and a refund
+func newReturnLedger(t *testing.T) *Ledger {
+	t.Helper()
+	ledger, err := NewLedger(NewTaxService(DefaultRateProvider()), NewMemoryTransactionRepository())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	create := func(code, date string, req *models.TaxRequest) {
+		req.TransactionDate = datePtr(date)
+		if _, err := ledger.Create(code, req, true); err != nil {
+			t.Fatalf("%s: unexpected error: %v", code, err)
+		}
+	}
+	item := func(id, price, category string) models.Item {
+		return models.Item{ID: id, Price: models.MustParseMoney(price), Quantity: 1, TaxCategory: category}
+	}
+	shipping := []models.Charge{{ID: "ship", Type: "shipping", Amount: models.MustParseMoney("10.00")}}
+	austin := models.Address{Country: "US", State: "TX", ZipCode: "78701"}
+	losAngeles := models.Address{Country: "US", State: "CA", ZipCode: "90012"}
+
+	create("TX-1", "2025-05-10", &models.TaxRequest{Address: austin,
+		Items: []models.Item{item("lamp", "100.00", ""), item("bread", "20.00", "groceries")}, Charges: shipping})
+	create("TX-2", "2025-05-12", &models.TaxRequest{Address: austin, Items: []models.Item{item("lamp", "200.00", "")},
+		Exemption: &models.ExemptionCertificate{ID: "R-1", Reason: "resale", Jurisdictions: []string{"TX"}}})
+	create("TX-3", "2025-06-01", &models.TaxRequest{Address: austin, Items: []models.Item{item("desk", "50.00", "")}})
+	create("TX-4", "2025-07-01", &models.TaxRequest{Address: austin, Items: []models.Item{item("desk", "75.00", "")}})
+	create("CA-1", "2025-04-02", &models.TaxRequest{Address: losAngeles,
+		Items: []models.Item{item("lamp", "100.00", ""), item("pills", "30.00", "prescription_drugs")}, Charges: shipping})
+	create("CA-2", "2025-04-03", &models.TaxRequest{Address: losAngeles, Items: []models.Item{item("lamp", "500.00", "")},
+		Exemption: &models.ExemptionCertificate{ID: "G-1", Reason: "government", Jurisdictions: []string{"CA"}}})
+
+	ledger.now = func() time.Time { return time.Date(2025, 6, 20, 12, 0, 0, 0, time.UTC) }
+	if _, err := ledger.Refund("TX-1", &models.RefundRequest{Items: []models.RefundItem{{ItemID: "lamp", Quantity: 1}, {ItemID: "bread", Quantity: 1}}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return ledger
+}
+
+func TestLedger_StateReturn(t *testing.T) {
+	ledger := newReturnLedger(t)
+
+	ret, err := ledger.StateReturn(ReturnQuery{State: "tx", Period: "2025-q2", Source: SourceCommitted})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ret.State != "TX" || ret.Period != "2025-Q2" || ret.PeriodEnd.String() != "2025-06-30" || len(ret.Locations) != 3 {
+		t.Fatalf("expected Texas state, city and transit locations for Q2, got %+v", ret)
+	}
+	state := ret.Locations[0]
+	if state.Code != "TX" || state.Transactions != 4 {
+		t.Errorf("expected the state first with four transactions, got %+v", state)
+	}
+	// Gross: 130.00 + 200.00 + 50.00 less the refunded bread. Taxable: the
+	// lamp, the lamp's share of the shipping and the desk, less the lamp.
+	if state.GrossSales.String() != "360.00" || state.TaxableSales.String() != "58.33" {
+		t.Errorf("expected gross 360.00 and taxable 58.33, got %s and %s", state.GrossSales, state.TaxableSales)
+	}
+	if state.GrossSales.Sub(state.TotalDeductions) != state.TaxableSales {
+		t.Errorf("expected gross less deductions to be taxable, got %+v", state)
+	}
+	deductions := make(map[string]string)
+	for _, d := range state.Deductions {
+		deductions[d.Reason] = d.Amount.String()
+	}
+	if deductions["resale"] != "200.00" || deductions[DeductionReturns] != "100.00" ||
+		deductions["nontaxable_shipping"] != "1.67" || deductions["exempt_groceries"] != "" {
+		t.Errorf("expected resale and returns deductions, got %v", deductions)
+	}
+
+	if ret, _ := ledger.StateReturn(ReturnQuery{State: "TX", Period: "2025-07", Source: SourceCommitted}); len(ret.Locations) != 3 || ret.Locations[0].GrossSales.String() != "75.00" {
+		t.Errorf("expected only July's sale, got %+v", ret.Locations)
+	}
+	if ret, _ := ledger.StateReturn(ReturnQuery{State: "NY", Period: "2025-Q2", Source: SourceCommitted}); len(ret.Locations) != 0 {
+		t.Errorf("expected an empty New York return, got %+v", ret.Locations)
+	}
+
+	for _, period := range []string{"2025", "2025-13", "2025-Q5", "2025-6", "Q2-2025"} {
+		if _, err := ledger.StateReturn(ReturnQuery{State: "TX", Period: period, Source: SourceCommitted}); err == nil {
+			t.Errorf("%s: expected an invalid period error", period)
+		}
+	}
+	if _, err := ledger.StateReturn(ReturnQuery{State: "Texas", Period: "2025-Q2", Source: SourceCommitted}); err == nil {
+		t.Error("expected an error for a state name")
+	}
+}
+
+func TestReturnExportsGolden(t *testing.T) {
+	ledger := newReturnLedger(t)
+	exporters := DefaultReturnExporters()
+
+	tests := []struct {
+		golden string
+		state  string
+	}{
+		{"tx.csv", "TX"},
+		{"ca.csv", "CA"},
+	}
+	for _, tt := range tests {
+		ret, err := ledger.StateReturn(ReturnQuery{State: tt.state, Period: "2025-Q2", Source: SourceCommitted})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		checkGolden(t, tt.golden, exporters.For(tt.state), ret)
+		checkGolden(t, "generic_"+tt.golden, GenericReturnExporter{}, ret)
+	}
+}
+
+func TestReturnExporters_Fallback(t *testing.T) {
+	exporters := DefaultReturnExporters()
+	if _, ok := exporters.For("ny").(GenericReturnExporter); !ok {
+		t.Errorf("expected the generic layout for New York, got %T", exporters.For("ny"))
+	}
+	custom := &ReturnForm{}
+	exporters.Register("ny", custom)
+	if exporters.For("NY") != custom {
+		t.Error("expected the registered exporter for New York")
+	}
+}
+
+// checkGolden compares an export with testdata/returns/name, rewriting the
+// file instead when the tests are run with -update
+func checkGolden(t *testing.T, name string, exporter ReturnExporter, ret *models.StateReturn) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := exporter.Export(&buf, ret); err != nil {
+		t.Fatalf("%s: unexpected error: %v", name, err)
+	}
+	path := filepath.Join("testdata", "returns", name)
+	if *update {
+		if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	if buf.String() != string(expected) {
+		t.Errorf("%s: got\n%s\nexpected\n%s", name, buf.String(), expected)
+	}
+}
+
+func TestLedger_StateReturnCalculated(t *testing.T) {
+	ledger := newReturnLedger(t)
+	req := &models.TaxRequest{
+		Address:         models.Address{Country: "US", State: "TX", ZipCode: "78701"},
+		Items:           []models.Item{{ID: "desk", Price: models.MustParseMoney("80.00"), Quantity: 1}},
+		TransactionDate: datePtr("2025-06-02"),
+	}
+	result, err := ledger.tax.CalculateTax(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := ledger.RecordCalculation(req, result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The committed sales are left out
+	ret, err := ledger.StateReturn(ReturnQuery{State: "TX", Period: "2025-Q2"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ret.Source != SourceCalculated || len(ret.Locations) != 3 {
+		t.Fatalf("expected the calculation's Texas locations, got %+v", ret)
+	}
+	if state := ret.Locations[0]; state.Transactions != 1 || state.GrossSales.String() != "80.00" || state.TaxDue.String() != "5.00" {
+		t.Errorf("expected the calculated desk only, got %+v", state)
+	}
+	if _, err := ledger.StateReturn(ReturnQuery{State: "TX", Period: "2025-Q2", Source: "voided"}); err == nil {
+		t.Error("expected an error for an unknown source")
+	}
+}
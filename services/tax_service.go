@@ -11,15 +11,49 @@ import (
 
 // TaxService handles tax calculation logic
 type TaxService struct {
-	rand *rand.Rand
+	rand       *rand.Rand
+	rates      RateProvider
+	engines    map[string]TaxEngine
+	exemptions ExemptionStore
+	nexus      *NexusTracker
+	now        func() time.Time
 }
 
-// NewTaxService creates a new instance of TaxService
-func NewTaxService() *TaxService {
+// NewTaxService creates a new instance of TaxService using the given rate
+// provider for US sales tax
+func NewTaxService(rates RateProvider) *TaxService {
 	source := rand.NewSource(time.Now().UnixNano())
-	return &TaxService{
-		rand: rand.New(source),
+	s := &TaxService{
+		rand:       rand.New(source),
+		rates:      rates,
+		engines:    make(map[string]TaxEngine),
+		exemptions: NewCertificateStore(),
+		nexus:      NewNexusTracker(DefaultNexusThresholds()),
+		now:        time.Now,
 	}
+	s.RegisterEngine("US", NewUSEngine(rates, DefaultTaxabilityMatrix()))
+	s.RegisterEngine("GB", DefaultUKEngine())
+	s.RegisterEngine("IN", DefaultINEngine())
+	s.RegisterEngine("CA", DefaultCAEngine())
+
+	eu := DefaultEUEngine()
+	for _, country := range eu.MemberStates() {
+		s.RegisterEngine(country, eu)
+	}
+	return s
+}
+
+// RegisterEngine routes requests for an ISO 3166-1 alpha-2 country code to engine
+func (s *TaxService) RegisterEngine(country string, engine TaxEngine) {
+	if tracked, ok := engine.(nexusTracked); ok {
+		tracked.SetNexusTracker(s.nexus)
+	}
+	s.engines[normalizeCountry(country)] = engine
+}
+
+// RateTableVersion returns the version of the rate table in use
+func (s *TaxService) RateTableVersion() string {
+	return s.rates.Version()
 }
 
 // CalculateTax calculates tax for the given request
@@ -28,42 +62,166 @@ func (s *TaxService) CalculateTax(req *models.TaxRequest) (*models.TaxResponse,
 		return nil, err
 	}
 
-	// Get tax rate based on location
-	taxRate := s.getTaxRateForLocation(&req.Address)
-	jurisdiction := s.getTaxJurisdiction(&req.Address)
+	engine, err := s.engineFor(&req.Address)
+	if err != nil {
+		return nil, err
+	}
+	// Pin the transaction date so every engine lookup uses the same day
+	if req.TransactionDate == nil {
+		dated := *req
+		today := s.Today()
+		dated.TransactionDate = &today
+		req = &dated
+	}
+	if err := engine.ValidateAddress(&req.Address); err != nil {
+		return nil, err
+	}
+	if validator, ok := engine.(requestValidator); ok {
+		if err := validator.ValidateRequest(req); err != nil {
+			return nil, err
+		}
+	}
+
+	currency := engine.Currency()
+	if chooser, ok := engine.(currencyChooser); ok {
+		currency = chooser.CurrencyFor(req)
+	}
+	if req.Currency != "" && !strings.EqualFold(req.Currency, currency) {
+		return nil, fmt.Errorf("currency %s is not supported for country %s, use %s",
+			strings.ToUpper(req.Currency), normalizeCountry(req.Address.Country), currency)
+	}
+
+	jurisdiction := engine.Jurisdiction(req)
+
+	lines, err := applyDiscounts(req, currency)
+	if err != nil {
+		return nil, err
+	}
+	exemptions, err := s.exemptionsFor(req)
+	if err != nil {
+		return nil, err
+	}
+	// Sellers without nexus in the state collect nothing there
+	nexus := s.nexusStatus(req)
+	filter := exemptions.filter
+	if nexus != nil && nexus.Reason == NoNexusReason {
+		filter = func([]AppliedRate) ([]AppliedRate, error) { return nil, nil }
+	}
 
 	var itemDetails []models.ItemTaxDetail
-	var subtotal, totalTax float64
+	subtotal := models.NewMoney(0, currency)
+	totalDiscount := models.NewMoney(0, currency)
+	totalTax := models.NewMoney(0, currency)
+	summary := newTaxSummary(currency)
+	goods := make([]chargedGoods, len(req.Items))
+
+	// Calculate tax for each item; each line is rounded to the minor unit so
+	// the order totals are exactly the sum of the lines
+	for i, item := range req.Items {
+		appliedRates, err := engine.TaxRates(req, &req.Items[i])
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+		if appliedRates, err = filter(appliedRates); err != nil {
+			return nil, err
+		}
+
+		price := item.Price.In(currency)
+		line := lines[i]
+		itemSubtotal := line.base
 
-	// Calculate tax for each item
-	for _, item := range req.Items {
-		itemSubtotal := item.Price * float64(item.Quantity)
-		itemTax := itemSubtotal * taxRate
-		itemTotal := itemSubtotal + itemTax
+		var taxRate models.Rate
+		for _, applied := range appliedRates {
+			taxRate += applied.Rate
+		}
+		itemTotal := itemSubtotal
+		itemSubtotal, amounts := taxAmounts(itemTotal, appliedRates, req.PricesIncludeTax)
+
+		taxes := make([]models.Tax, 0, len(appliedRates))
+		itemTax := models.NewMoney(0, currency)
+		for j, applied := range appliedRates {
+			itemTax = itemTax.Add(amounts[j])
+			taxes = append(taxes, models.Tax{
+				Name:          applied.Name,
+				Rate:          applied.Rate,
+				Jurisdiction:  applied.Jurisdiction.Code,
+				TaxableAmount: itemSubtotal,
+				TaxAmount:     amounts[j],
+			})
+			summary.add(applied, itemSubtotal, amounts[j])
+		}
+		if !req.PricesIncludeTax {
+			itemTotal = itemSubtotal.Add(itemTax)
+		}
+		goods[i] = chargedGoods{amount: line.base, rates: appliedRates}
 
 		detail := models.ItemTaxDetail{
-			ItemID:      item.ID,
-			ItemName:    item.Name,
-			Price:       item.Price,
-			Quantity:    item.Quantity,
-			Subtotal:    roundToTwoDecimals(itemSubtotal),
-			TaxRate:     roundToTwoDecimals(taxRate * 100), // Convert to percentage
-			TaxAmount:   roundToTwoDecimals(itemTax),
-			TotalAmount: roundToTwoDecimals(itemTotal),
+			ItemID:         item.ID,
+			ItemName:       item.Name,
+			TaxCategory:    item.TaxCategory,
+			TaxCode:        item.TaxCode,
+			Price:          price,
+			Quantity:       item.Quantity,
+			OriginalAmount: line.original,
+			DiscountAmount: line.discount,
+			Discounts:      line.discounts,
+			Subtotal:       itemSubtotal,
+			TaxRate:        taxRate,
+			TaxAmount:      itemTax,
+			TotalAmount:    itemTotal,
+			Taxes:          taxes,
+		}
+		if reporter, ok := engine.(taxabilityReporter); ok {
+			detail.Taxability = reporter.Taxability(req, &req.Items[i])
+		}
+		if reporter, ok := engine.(sourcingReporter); ok {
+			detail.Sourcing = reporter.Sourcing(req, &req.Items[i])
 		}
 
 		itemDetails = append(itemDetails, detail)
-		subtotal += itemSubtotal
-		totalTax += itemTax
+		subtotal = subtotal.Add(itemSubtotal)
+		totalTax = totalTax.Add(itemTax)
+		totalDiscount = totalDiscount.Add(line.discount)
+		if subtotal.Units > models.MaxMoneyUnits {
+			return nil, fmt.Errorf("order subtotal exceeds maximum supported amount")
+		}
+	}
+
+	// Charges are taxed after the goods because they may follow the goods'
+	// taxability
+	var chargeDetails []models.ChargeTaxDetail
+	chargeTotal := models.NewMoney(0, currency)
+	for i := range req.Charges {
+		detail, err := taxCharge(engine, req, &req.Charges[i], currency, goods, filter, summary)
+		if err != nil {
+			return nil, fmt.Errorf("charge %d: %w", i, err)
+		}
+		chargeDetails = append(chargeDetails, *detail)
+		chargeTotal = chargeTotal.Add(detail.Subtotal)
+		totalTax = totalTax.Add(detail.TaxAmount)
 	}
 
 	response := &models.TaxResponse{
-		Address:         req.Address,
-		Items:           itemDetails,
-		Subtotal:        roundToTwoDecimals(subtotal),
-		TotalTax:        roundToTwoDecimals(totalTax),
-		GrandTotal:      roundToTwoDecimals(subtotal + totalTax),
-		TaxJurisdiction: jurisdiction,
+		Address:          req.Address,
+		Items:            itemDetails,
+		Currency:         currency,
+		TotalDiscount:    totalDiscount,
+		Subtotal:         subtotal,
+		Charges:          chargeDetails,
+		ChargeTotal:      chargeTotal,
+		TotalTax:         totalTax,
+		GrandTotal:       subtotal.Add(chargeTotal).Add(totalTax),
+		TaxJurisdiction:  jurisdiction,
+		TransactionDate:  *req.TransactionDate,
+		PricesIncludeTax: req.PricesIncludeTax,
+		TaxBreakdown:     summary.breakdown.entries,
+		Jurisdictions:    summary.jurisdictions.entries,
+		Exemptions:       exemptions.used,
+		Nexus:            nexus,
+		Notes:            summary.notes,
+	}
+	if reporter, ok := engine.(matchLevelReporter); ok {
+		response.RateMatchLevel = reporter.MatchLevel(req)
 	}
 
 	return response, nil
@@ -71,150 +229,197 @@ func (s *TaxService) CalculateTax(req *models.TaxRequest) (*models.TaxResponse,
 
 // validateRequest validates the tax calculation request
 func (s *TaxService) validateRequest(req *models.TaxRequest) error {
-	if req.Address.State == "" {
-		return fmt.Errorf("state is required")
-	}
-	if req.Address.ZipCode == "" && req.Address.PostalCode == "" {
-		return fmt.Errorf("zipcode is required")
+	if req.Address.Country == "" {
+		return fmt.Errorf("country is required")
 	}
 	if len(req.Items) == 0 {
 		return fmt.Errorf("at least one item is required")
 	}
+	if req.Currency != "" && !isCurrencyCode(req.Currency) {
+		return fmt.Errorf("currency %q is not a valid ISO 4217 code", req.Currency)
+	}
 
 	for i, item := range req.Items {
-		if item.Price < 0 {
+		if item.Price.IsNegative() {
 			return fmt.Errorf("item %d has invalid price", i)
 		}
 		if item.Quantity <= 0 {
 			return fmt.Errorf("item %d has invalid quantity", i)
 		}
+		for j, discount := range item.Discounts {
+			if err := validateDiscount(discount); err != nil {
+				return fmt.Errorf("item %d discount %d: %w", i, j, err)
+			}
+		}
+		if item.ShipFrom != nil {
+			if err := validateShipFrom(item.ShipFrom); err != nil {
+				return fmt.Errorf("item %d: %w", i, err)
+			}
+		}
+	}
+	for i, discount := range req.Discounts {
+		if err := validateDiscount(discount); err != nil {
+			return fmt.Errorf("discount %d: %w", i, err)
+		}
+	}
+	for i, charge := range req.Charges {
+		if err := validateCharge(charge); err != nil {
+			return fmt.Errorf("charge %d: %w", i, err)
+		}
+	}
+	if req.Exemption != nil {
+		if err := validateCertificate(req.Exemption); err != nil {
+			return fmt.Errorf("exemption: %w", err)
+		}
+	}
+	if req.ShipFrom != nil {
+		if err := validateShipFrom(req.ShipFrom); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-// getTaxRateForLocation returns a tax rate based on the US state
-// Rates are approximate and based on combined state and average local rates
-func (s *TaxService) getTaxRateForLocation(address *models.Address) float64 {
-	state := strings.ToUpper(address.State)
-
-	// US state sales tax rates (approximate combined rates)
-	var baseRate float64
-	switch state {
-	case "AL": // Alabama
-		baseRate = 0.0913
-	case "AK": // Alaska
-		baseRate = 0.0176
-	case "AZ": // Arizona
-		baseRate = 0.0831
-	case "AR": // Arkansas
-		baseRate = 0.0947
-	case "CA": // California
-		baseRate = 0.0850
-	case "CO": // Colorado
-		baseRate = 0.0763
-	case "CT": // Connecticut
-		baseRate = 0.0635
-	case "DE": // Delaware
-		baseRate = 0.0000 // No sales tax
-	case "FL": // Florida
-		baseRate = 0.0705
-	case "GA": // Georgia
-		baseRate = 0.0733
-	case "HI": // Hawaii
-		baseRate = 0.0444
-	case "ID": // Idaho
-		baseRate = 0.0602
-	case "IL": // Illinois
-		baseRate = 0.0868
-	case "IN": // Indiana
-		baseRate = 0.0700
-	case "IA": // Iowa
-		baseRate = 0.0694
-	case "KS": // Kansas
-		baseRate = 0.0865
-	case "KY": // Kentucky
-		baseRate = 0.0600
-	case "LA": // Louisiana
-		baseRate = 0.0952
-	case "ME": // Maine
-		baseRate = 0.0550
-	case "MD": // Maryland
-		baseRate = 0.0600
-	case "MA": // Massachusetts
-		baseRate = 0.0625
-	case "MI": // Michigan
-		baseRate = 0.0600
-	case "MN": // Minnesota
-		baseRate = 0.0744
-	case "MS": // Mississippi
-		baseRate = 0.0707
-	case "MO": // Missouri
-		baseRate = 0.0824
-	case "MT": // Montana
-		baseRate = 0.0000 // No sales tax
-	case "NE": // Nebraska
-		baseRate = 0.0694
-	case "NV": // Nevada
-		baseRate = 0.0823
-	case "NH": // New Hampshire
-		baseRate = 0.0000 // No sales tax
-	case "NJ": // New Jersey
-		baseRate = 0.0663
-	case "NM": // New Mexico
-		baseRate = 0.0779
-	case "NY": // New York
-		baseRate = 0.0852
-	case "NC": // North Carolina
-		baseRate = 0.0698
-	case "ND": // North Dakota
-		baseRate = 0.0696
-	case "OH": // Ohio
-		baseRate = 0.0723
-	case "OK": // Oklahoma
-		baseRate = 0.0897
-	case "OR": // Oregon
-		baseRate = 0.0000 // No sales tax
-	case "PA": // Pennsylvania
-		baseRate = 0.0634
-	case "RI": // Rhode Island
-		baseRate = 0.0700
-	case "SC": // South Carolina
-		baseRate = 0.0744
-	case "SD": // South Dakota
-		baseRate = 0.0645
-	case "TN": // Tennessee
-		baseRate = 0.0955
-	case "TX": // Texas
-		baseRate = 0.0820
-	case "UT": // Utah
-		baseRate = 0.0719
-	case "VT": // Vermont
-		baseRate = 0.0624
-	case "VA": // Virginia
-		baseRate = 0.0575
-	case "WA": // Washington
-		baseRate = 0.0920
-	case "WV": // West Virginia
-		baseRate = 0.0650
-	case "WI": // Wisconsin
-		baseRate = 0.0543
-	case "WY": // Wyoming
-		baseRate = 0.0536
-	default:
-		// Default rate if state not recognized
-		baseRate = 0.0700
-	}
-
-	return baseRate
-}
-
-// getTaxJurisdiction returns the tax jurisdiction string
-func (s *TaxService) getTaxJurisdiction(address *models.Address) string {
-	return fmt.Sprintf("%s, USA", address.State)
-}
-
-// roundToTwoDecimals rounds a float64 to 2 decimal places
-func roundToTwoDecimals(value float64) float64 {
-	return float64(int(value*100+0.5)) / 100
+// taxAmounts charges the applied rates on an amount and returns the amount net
+// of tax and the tax at each rate. Each tax is rounded on its own so a
+// breakdown adds up to the line. With tax-inclusive prices the amount is
+// gross: the combined tax is backed out first and then shared between the
+// taxes by rate, so net plus tax is exactly the gross amount.
+func taxAmounts(amount models.Money, appliedRates []AppliedRate, inclusive bool) (models.Money, []models.Money) {
+	amounts := make([]models.Money, len(appliedRates))
+	if !inclusive {
+		for i, applied := range appliedRates {
+			amounts[i] = amount.MulRate(applied.Rate)
+		}
+		return amount, amounts
+	}
+
+	var combined models.Rate
+	weights := make([]int64, len(appliedRates))
+	for i, applied := range appliedRates {
+		combined += applied.Rate
+		weights[i] = int64(applied.Rate)
+	}
+	tax := amount.TaxIncluded(combined)
+	return amount.Sub(tax), tax.Allocate(weights)
+}
+
+// taxSummary accumulates the order-level tax breakdowns and notes
+type taxSummary struct {
+	breakdown     *breakdownBuilder
+	jurisdictions *jurisdictionBuilder
+	notes         []string
+}
+
+func newTaxSummary(currency string) *taxSummary {
+	return &taxSummary{
+		breakdown:     newBreakdownBuilder(currency),
+		jurisdictions: newJurisdictionBuilder(currency),
+	}
+}
+
+// add records tax charged on a taxable amount at the applied rate
+func (t *taxSummary) add(applied AppliedRate, taxable, tax models.Money) {
+	t.breakdown.add(applied, taxable, tax)
+	t.jurisdictions.add(applied, taxable, tax)
+	if applied.Note != "" && !containsString(t.notes, applied.Note) {
+		t.notes = append(t.notes, applied.Note)
+	}
+}
+
+// breakdownBuilder accumulates tax per named rate in first-seen order
+type breakdownBuilder struct {
+	currency string
+	index    map[AppliedRate]int
+	entries  []models.TaxBreakdown
+}
+
+func newBreakdownBuilder(currency string) *breakdownBuilder {
+	return &breakdownBuilder{currency: currency, index: make(map[AppliedRate]int)}
+}
+
+// add records tax charged on a taxable amount at the applied rate
+func (b *breakdownBuilder) add(applied AppliedRate, taxable, tax models.Money) {
+	i, ok := b.index[applied]
+	if !ok {
+		i = len(b.entries)
+		b.index[applied] = i
+		b.entries = append(b.entries, models.TaxBreakdown{
+			Name:          applied.Name,
+			Rate:          applied.Rate,
+			TaxableAmount: models.NewMoney(0, b.currency),
+			TaxAmount:     models.NewMoney(0, b.currency),
+		})
+	}
+	b.entries[i].TaxableAmount = b.entries[i].TaxableAmount.Add(taxable)
+	b.entries[i].TaxAmount = b.entries[i].TaxAmount.Add(tax)
+}
+
+// jurisdictionKey groups tax by authority and rate
+type jurisdictionKey struct {
+	jurisdiction models.Jurisdiction
+	rate         models.Rate
+}
+
+// jurisdictionBuilder accumulates tax per jurisdiction in first-seen order
+type jurisdictionBuilder struct {
+	currency string
+	index    map[jurisdictionKey]int
+	entries  []models.JurisdictionTax
+}
+
+func newJurisdictionBuilder(currency string) *jurisdictionBuilder {
+	return &jurisdictionBuilder{currency: currency, index: make(map[jurisdictionKey]int)}
+}
+
+// add records tax owed to the applied rate's jurisdiction
+func (b *jurisdictionBuilder) add(applied AppliedRate, taxable, tax models.Money) {
+	key := jurisdictionKey{jurisdiction: applied.Jurisdiction, rate: applied.Rate}
+	i, ok := b.index[key]
+	if !ok {
+		i = len(b.entries)
+		b.index[key] = i
+		b.entries = append(b.entries, models.JurisdictionTax{
+			Jurisdiction:  applied.Jurisdiction,
+			Rate:          applied.Rate,
+			TaxableAmount: models.NewMoney(0, b.currency),
+			TaxAmount:     models.NewMoney(0, b.currency),
+		})
+	}
+	b.entries[i].TaxableAmount = b.entries[i].TaxableAmount.Add(taxable)
+	b.entries[i].TaxAmount = b.entries[i].TaxAmount.Add(tax)
+}
+
+// engineFor returns the engine registered for the address's country
+func (s *TaxService) engineFor(address *models.Address) (TaxEngine, error) {
+	country := normalizeCountry(address.Country)
+	engine, ok := s.engines[country]
+	if !ok {
+		return nil, &UnsupportedJurisdictionError{Country: country}
+	}
+	return engine, nil
+}
+
+// containsString reports whether list contains value
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// isCurrencyCode reports whether code looks like an ISO 4217 alphabetic code
+func isCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range strings.ToUpper(code) {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
 }
@@ -1,13 +1,17 @@
 package services
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/vijayraghavareddy/tax-calculation/models"
 )
 
 func TestCalculateTax_Success(t *testing.T) {
-	service := NewTaxService()
+	service := NewTaxService(DefaultRateProvider())
 
 	req := &models.TaxRequest{
 		Address: models.Address{
@@ -21,13 +25,13 @@ func TestCalculateTax_Success(t *testing.T) {
 			{
 				ID:       "item1",
 				Name:     "Product A",
-				Price:    100.00,
+				Price:    models.MustParseMoney("100.00"),
 				Quantity: 2,
 			},
 			{
 				ID:       "item2",
 				Name:     "Product B",
-				Price:    50.00,
+				Price:    models.MustParseMoney("50.00"),
 				Quantity: 1,
 			},
 		},
@@ -44,18 +48,18 @@ func TestCalculateTax_Success(t *testing.T) {
 	}
 
 	// Check subtotal
-	expectedSubtotal := 250.00
-	if resp.Subtotal != expectedSubtotal {
-		t.Errorf("Expected subtotal %f, got %f", expectedSubtotal, resp.Subtotal)
+	expectedSubtotal := models.MustParseMoney("250.00")
+	if resp.Subtotal.Units != expectedSubtotal.Units {
+		t.Errorf("Expected subtotal %s, got %s", expectedSubtotal, resp.Subtotal)
 	}
 
 	// Check that tax was calculated
-	if resp.TotalTax <= 0 {
+	if resp.TotalTax.Units <= 0 {
 		t.Error("Expected tax to be greater than 0")
 	}
 
 	// Check grand total
-	if resp.GrandTotal != resp.Subtotal+resp.TotalTax {
+	if resp.GrandTotal != resp.Subtotal.Add(resp.TotalTax) {
 		t.Error("Grand total should equal subtotal + tax")
 	}
 
@@ -68,10 +72,14 @@ func TestCalculateTax_Success(t *testing.T) {
 	if resp.TaxJurisdiction == "" {
 		t.Error("Expected tax jurisdiction to be set")
 	}
+
+	if resp.RateMatchLevel != MatchZip5 {
+		t.Errorf("Expected rate match level %s, got %q", MatchZip5, resp.RateMatchLevel)
+	}
 }
 
 func TestCalculateTax_MissingState(t *testing.T) {
-	service := NewTaxService()
+	service := NewTaxService(DefaultRateProvider())
 
 	req := &models.TaxRequest{
 		Address: models.Address{
@@ -82,7 +90,7 @@ func TestCalculateTax_MissingState(t *testing.T) {
 			{
 				ID:       "item1",
 				Name:     "Product A",
-				Price:    100.00,
+				Price:    models.MustParseMoney("100.00"),
 				Quantity: 1,
 			},
 		},
@@ -96,7 +104,7 @@ func TestCalculateTax_MissingState(t *testing.T) {
 }
 
 func TestCalculateTax_MissingZipCode(t *testing.T) {
-	service := NewTaxService()
+	service := NewTaxService(DefaultRateProvider())
 
 	req := &models.TaxRequest{
 		Address: models.Address{
@@ -107,7 +115,7 @@ func TestCalculateTax_MissingZipCode(t *testing.T) {
 			{
 				ID:       "item1",
 				Name:     "Product A",
-				Price:    100.00,
+				Price:    models.MustParseMoney("100.00"),
 				Quantity: 1,
 			},
 		},
@@ -121,7 +129,7 @@ func TestCalculateTax_MissingZipCode(t *testing.T) {
 }
 
 func TestCalculateTax_NoItems(t *testing.T) {
-	service := NewTaxService()
+	service := NewTaxService(DefaultRateProvider())
 
 	req := &models.TaxRequest{
 		Address: models.Address{
@@ -139,7 +147,7 @@ func TestCalculateTax_NoItems(t *testing.T) {
 }
 
 func TestCalculateTax_NegativePrice(t *testing.T) {
-	service := NewTaxService()
+	service := NewTaxService(DefaultRateProvider())
 
 	req := &models.TaxRequest{
 		Address: models.Address{
@@ -150,7 +158,7 @@ func TestCalculateTax_NegativePrice(t *testing.T) {
 			{
 				ID:       "item1",
 				Name:     "Product A",
-				Price:    -100.00,
+				Price:    models.MustParseMoney("-100.00"),
 				Quantity: 1,
 			},
 		},
@@ -164,7 +172,7 @@ func TestCalculateTax_NegativePrice(t *testing.T) {
 }
 
 func TestCalculateTax_InvalidQuantity(t *testing.T) {
-	service := NewTaxService()
+	service := NewTaxService(DefaultRateProvider())
 
 	req := &models.TaxRequest{
 		Address: models.Address{
@@ -175,7 +183,7 @@ func TestCalculateTax_InvalidQuantity(t *testing.T) {
 			{
 				ID:       "item1",
 				Name:     "Product A",
-				Price:    100.00,
+				Price:    models.MustParseMoney("100.00"),
 				Quantity: 0,
 			},
 		},
@@ -189,7 +197,7 @@ func TestCalculateTax_InvalidQuantity(t *testing.T) {
 }
 
 func TestCalculateTax_DifferentStates(t *testing.T) {
-	service := NewTaxService()
+	service := NewTaxService(DefaultRateProvider())
 
 	states := []string{"NY", "CA", "TX", "FL", "IL", "PA", "OH", "MI"}
 
@@ -204,7 +212,7 @@ func TestCalculateTax_DifferentStates(t *testing.T) {
 				{
 					ID:       "item1",
 					Name:     "Product A",
-					Price:    100.00,
+					Price:    models.MustParseMoney("100.00"),
 					Quantity: 1,
 				},
 			},
@@ -217,14 +225,63 @@ func TestCalculateTax_DifferentStates(t *testing.T) {
 			continue
 		}
 
-		if resp.TotalTax < 0 {
+		if resp.TotalTax.IsNegative() {
 			t.Errorf("Expected tax to be 0 or greater for state %s", state)
 		}
 	}
 }
 
+func TestCalculateTax_StateRates(t *testing.T) {
+	service := NewTaxService(DefaultRateProvider())
+
+	// The state's own rate plus its local layers: matched by ZIP code, or
+	// the state's average local rate for a ZIP code outside the table
+	tests := []struct {
+		state     string
+		zip       string
+		stateRate string
+		combined  string
+		match     string
+	}{
+		{"NY", "10001", "4.00", "8.875", "zip5"},
+		{"NY", "12345", "4.00", "8.52", "state"},
+		{"CA", "90001", "7.25", "9.75", "zip5"},
+		{"CA", "12345", "7.25", "8.50", "state"},
+		{"TX", "78701", "6.25", "8.25", "zip5"},
+		{"TX", "12345", "6.25", "8.20", "state"},
+		{"FL", "12345", "6.00", "7.05", "state"},
+		{"DE", "19901", "0.00", "0.00", "state"}, // No sales tax
+		{"MT", "59601", "0.00", "0.00", "state"}, // No sales tax
+		{"OR", "97201", "0.00", "0.00", "state"}, // No sales tax
+	}
+
+	for _, tt := range tests {
+		req := &models.TaxRequest{
+			Address:         models.Address{State: tt.state, Country: "US", ZipCode: tt.zip},
+			Items:           []models.Item{{ID: "item1", Name: "Product A", Price: models.MustParseMoney("100.00"), Quantity: 1}},
+			TransactionDate: &testDate,
+		}
+
+		resp, err := service.CalculateTax(req)
+		if err != nil {
+			t.Fatalf("Unexpected error for %s %s: %v", tt.state, tt.zip, err)
+		}
+
+		var stateRate models.Rate
+		for _, entry := range resp.Jurisdictions {
+			if entry.Type == JurisdictionState {
+				stateRate = entry.Rate
+			}
+		}
+		if stateRate.String() != tt.stateRate || resp.Items[0].TaxRate.String() != tt.combined || resp.RateMatchLevel != tt.match {
+			t.Errorf("%s %s: expected state rate %s%% and combined %s%% matched by %s, got %s%% and %s%% by %s",
+				tt.state, tt.zip, tt.stateRate, tt.combined, tt.match, stateRate, resp.Items[0].TaxRate, resp.RateMatchLevel)
+		}
+	}
+}
+
 func TestCalculateTax_MultipleItems(t *testing.T) {
-	service := NewTaxService()
+	service := NewTaxService(DefaultRateProvider())
 
 	req := &models.TaxRequest{
 		Address: models.Address{
@@ -238,19 +295,19 @@ func TestCalculateTax_MultipleItems(t *testing.T) {
 			{
 				ID:       "item1",
 				Name:     "Product A",
-				Price:    100.00,
+				Price:    models.MustParseMoney("100.00"),
 				Quantity: 1,
 			},
 			{
 				ID:       "item2",
 				Name:     "Product B",
-				Price:    50.00,
+				Price:    models.MustParseMoney("50.00"),
 				Quantity: 2,
 			},
 			{
 				ID:       "item3",
 				Name:     "Product C",
-				Price:    75.00,
+				Price:    models.MustParseMoney("75.00"),
 				Quantity: 3,
 			},
 		},
@@ -262,9 +319,9 @@ func TestCalculateTax_MultipleItems(t *testing.T) {
 		t.Fatalf("Expected no error, got %v", err)
 	}
 
-	expectedSubtotal := 100.00 + 100.00 + 225.00
-	if resp.Subtotal != expectedSubtotal {
-		t.Errorf("Expected subtotal %f, got %f", expectedSubtotal, resp.Subtotal)
+	expectedSubtotal := models.MustParseMoney("425.00")
+	if resp.Subtotal.Units != expectedSubtotal.Units {
+		t.Errorf("Expected subtotal %s, got %s", expectedSubtotal, resp.Subtotal)
 	}
 
 	if len(resp.Items) != 3 {
@@ -272,56 +329,202 @@ func TestCalculateTax_MultipleItems(t *testing.T) {
 	}
 }
 
-func TestGetTaxRateForLocation(t *testing.T) {
-	service := NewTaxService()
+func TestCalculateTax_ExactRounding(t *testing.T) {
+	// 0.145 * 100 is 14.499999999999998 in float64; exact arithmetic must
+	// round the half-cent tax on 1.45 at 10% up to 0.15
+	provider, err := ParseRateTable(strings.NewReader(`{"version": "t", "states": [{"code": "NY", "rate": "10"}]}`))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	service := NewTaxService(provider)
+
+	req := &models.TaxRequest{
+		Address: models.Address{State: "NY", Country: "US", ZipCode: "10001"},
+		Items: []models.Item{
+			{ID: "item1", Name: "Product A", Price: models.MustParseMoney("1.45"), Quantity: 1},
+			{ID: "item2", Name: "Product B", Price: models.MustParseMoney("0.10"), Quantity: 3},
+		},
+	}
+
+	resp, err := service.CalculateTax(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if resp.Items[0].TaxAmount.String() != "0.15" {
+		t.Errorf("Expected tax 0.15, got %s", resp.Items[0].TaxAmount)
+	}
+	if resp.Subtotal.String() != "1.75" {
+		t.Errorf("Expected subtotal 1.75, got %s", resp.Subtotal)
+	}
+	if resp.GrandTotal.String() != "1.93" {
+		t.Errorf("Expected grand total 1.93, got %s", resp.GrandTotal)
+	}
+	if resp.Currency != "USD" || resp.GrandTotal.Currency != "USD" {
+		t.Errorf("Expected USD amounts, got %s", resp.Currency)
+	}
+}
+
+func TestCalculateTax_AmountTooLarge(t *testing.T) {
+	service := NewTaxService(DefaultRateProvider())
+
+	req := &models.TaxRequest{
+		Address: models.Address{State: "NY", Country: "US", ZipCode: "10001"},
+		Items: []models.Item{
+			{ID: "item1", Name: "Product A", Price: models.MustParseMoney("9999999999999.99"), Quantity: 1000},
+		},
+	}
+
+	if _, err := service.CalculateTax(req); err == nil {
+		t.Fatal("Expected error for amount exceeding maximum, got nil")
+	}
+}
+
+func TestCalculateTax_MissingCountry(t *testing.T) {
+	service := NewTaxService(DefaultRateProvider())
+
+	req := &models.TaxRequest{
+		Address: models.Address{State: "NY", ZipCode: "10001"},
+		Items:   []models.Item{{ID: "item1", Name: "Product A", Price: models.MustParseMoney("100.00"), Quantity: 1}},
+	}
+
+	_, err := service.CalculateTax(req)
+	if err == nil || !strings.Contains(err.Error(), "country") {
+		t.Fatalf("Expected country error, got %v", err)
+	}
+}
+
+func TestCalculateTax_UnsupportedCountry(t *testing.T) {
+	service := NewTaxService(DefaultRateProvider())
+
+	req := &models.TaxRequest{
+		Address: models.Address{City: "Tokyo", Country: "jp", ZipCode: "100-0001"},
+		Items:   []models.Item{{ID: "item1", Name: "Product A", Price: models.MustParseMoney("100.00"), Quantity: 1}},
+	}
+
+	_, err := service.CalculateTax(req)
+	var unsupported *UnsupportedJurisdictionError
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("Expected UnsupportedJurisdictionError, got %v", err)
+	}
+	if unsupported.Country != "JP" {
+		t.Errorf("Expected country JP, got %s", unsupported.Country)
+	}
+}
+
+func TestCalculateTax_CountryAliasAndCurrency(t *testing.T) {
+	service := NewTaxService(DefaultRateProvider())
+
+	req := &models.TaxRequest{
+		Address:  models.Address{State: "NY", Country: "USA", ZipCode: "10001"},
+		Items:    []models.Item{{ID: "item1", Name: "Product A", Price: models.MustParseMoney("100.00"), Quantity: 1}},
+		Currency: "usd",
+	}
+
+	if _, err := service.CalculateTax(req); err != nil {
+		t.Fatalf("Expected USA alias to route to US engine, got %v", err)
+	}
+
+	req.Currency = "EUR"
+	if _, err := service.CalculateTax(req); err == nil {
+		t.Fatal("Expected error for currency not used by the jurisdiction")
+	}
+}
+
+func TestCalculateTax_PricesIncludeTax(t *testing.T) {
+	service := NewTaxService(DefaultRateProvider())
 
 	tests := []struct {
-		state   string
-		minRate float64
-		maxRate float64
+		name     string
+		req      models.TaxRequest
+		subtotal string
+		tax      string
+		taxes    []string
 	}{
-		{"NY", 0.08, 0.09},
-		{"CA", 0.08, 0.09},
-		{"TX", 0.08, 0.09},
-		{"FL", 0.06, 0.08},
-		{"DE", 0.00, 0.00}, // No sales tax
-		{"MT", 0.00, 0.00}, // No sales tax
-		{"OR", 0.00, 0.00}, // No sales tax
+		{
+			name: "Germany VAT",
+			req: models.TaxRequest{
+				Address: models.Address{Country: "DE", PostalCode: "10115"},
+				Items:   []models.Item{{ID: "item1", Price: models.MustParseMoney("19.99"), Quantity: 3}},
+			},
+			subtotal: "50.39",
+			tax:      "9.58",
+			taxes:    []string{"9.58"},
+		},
+		{
+			name: "India CGST and SGST",
+			req: models.TaxRequest{
+				Address:     models.Address{Country: "IN", State: "KA", PostalCode: "560001"},
+				Items:       []models.Item{{ID: "item1", Price: models.MustParseMoney("100.01"), Quantity: 1}},
+				SellerState: "KA",
+			},
+			subtotal: "84.75",
+			tax:      "15.26",
+			taxes:    []string{"7.63", "7.63"},
+		},
+		{
+			name: "New York layers",
+			req: models.TaxRequest{
+				Address: models.Address{Country: "US", State: "NY", ZipCode: "10001"},
+				Items:   []models.Item{{ID: "item1", Price: models.MustParseMoney("10.00"), Quantity: 1}},
+			},
+			subtotal: "9.18",
+			tax:      "0.82",
+			taxes:    []string{"0.37", "0.42", "0.03"},
+		},
 	}
 
 	for _, tt := range tests {
-		address := &models.Address{
-			State:   tt.state,
-			Country: "US",
-			ZipCode: "12345",
+		tt.req.PricesIncludeTax = true
+		tt.req.TransactionDate = &testDate
+		resp, err := service.CalculateTax(&tt.req)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", tt.name, err)
 		}
 
-		rate := service.getTaxRateForLocation(address)
-
-		if rate < tt.minRate || rate > tt.maxRate {
-			t.Errorf("Tax rate for %s (%f) is outside expected range [%f, %f]",
-				tt.state, rate, tt.minRate, tt.maxRate)
+		item := resp.Items[0]
+		gross, _ := tt.req.Items[0].Price.MulQuantity(tt.req.Items[0].Quantity)
+		if item.Subtotal.Add(item.TaxAmount).Units != gross.Units || item.TotalAmount.Units != gross.Units {
+			t.Errorf("%s: net %s + tax %s should equal gross %s", tt.name, item.Subtotal, item.TaxAmount, gross)
+		}
+		if item.Subtotal.String() != tt.subtotal || item.TaxAmount.String() != tt.tax {
+			t.Errorf("%s: expected net %s and tax %s, got %s and %s", tt.name, tt.subtotal, tt.tax, item.Subtotal, item.TaxAmount)
+		}
+		var taxes []string
+		for _, tax := range item.Taxes {
+			taxes = append(taxes, tax.TaxAmount.String())
+		}
+		if strings.Join(taxes, ",") != strings.Join(tt.taxes, ",") {
+			t.Errorf("%s: expected taxes %v, got %v", tt.name, tt.taxes, taxes)
+		}
+		if resp.GrandTotal.Units != gross.Units || !resp.PricesIncludeTax {
+			t.Errorf("%s: expected grand total %s, got %s", tt.name, gross, resp.GrandTotal)
 		}
 	}
 }
 
-func TestRoundToTwoDecimals(t *testing.T) {
+func TestDefaultEngines_RejectUnknownFields(t *testing.T) {
 	tests := []struct {
-		input    float64
-		expected float64
+		name  string
+		table *[]byte
+		load  func()
 	}{
-		{10.123, 10.12},
-		{10.126, 10.13},
-		{10.999, 11.00},
-		{10.001, 10.00},
-		{10.50, 10.50},
+		{"CA", &defaultCARateTable, func() { DefaultCAEngine() }},
+		{"EU", &defaultEUVATTable, func() { DefaultEUEngine() }},
+		{"IN", &defaultINGSTTable, func() { DefaultINEngine() }},
+		{"UK", &defaultUKVATTable, func() { DefaultUKEngine() }},
 	}
-
 	for _, tt := range tests {
-		result := roundToTwoDecimals(tt.input)
-		if result != tt.expected {
-			t.Errorf("roundToTwoDecimals(%f) = %f, expected %f",
-				tt.input, result, tt.expected)
-		}
+		bundled := *tt.table
+		*tt.table = append([]byte(`{"default_seller_state": "KA",`), bytes.TrimPrefix(bytes.TrimSpace(bundled), []byte("{"))...)
+		func() {
+			defer func() {
+				if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), "unknown field") {
+					t.Errorf("%s: expected a panic for an unknown field, got %v", tt.name, r)
+				}
+			}()
+			tt.load()
+		}()
+		*tt.table = bundled
 	}
 }
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+//go:embed data/us_taxability.json
+var defaultTaxabilityTable []byte
+
+// Taxability treatments a rule can give a product category or charge type.
+// TreatmentFollowsGoods taxes a charge in proportion to the taxed goods it is
+// billed with; on a product it has the same effect as TreatmentTaxable.
+const (
+	TreatmentExempt       = "exempt"
+	TreatmentReduced      = "reduced"
+	TreatmentTaxable      = "taxable"
+	TreatmentFollowsGoods = "follows_goods"
+)
+
+// allStates is the rule state that applies wherever a state has no rule of its own
+const allStates = "*"
+
+// generalCategory is fully taxable and needs no rule
+const generalCategory = "general"
+
+// TaxabilityTable is the on-disk representation of a product taxability matrix
+type TaxabilityTable struct {
+	Version    string           `json:"version"`
+	Country    string           `json:"country"`
+	Categories []string         `json:"categories"`
+	Rules      []TaxabilityRule `json:"rules"`
+}
+
+// TaxabilityRule sets how a state taxes a product category. A reduced rule
+// replaces the state rate; local layers follow the state treatment for exempt
+// and taxable rules and stay taxable for reduced ones unless LocalTreatment
+// says otherwise. A rule ID may be listed once per effective period.
+type TaxabilityRule struct {
+	ID             string        `json:"id"`
+	State          string        `json:"state"` // "*" for every state without its own rule
+	Category       string        `json:"category"`
+	Treatment      string        `json:"treatment"`
+	Rate           *models.Rate  `json:"rate,omitempty"`            // state rate for reduced rules
+	PriceBelow     *models.Money `json:"price_below,omitempty"`     // only applies to unit prices under this
+	LocalTreatment string        `json:"local_treatment,omitempty"` // "exempt" or "taxable"
+	Description    string        `json:"description,omitempty"`
+	EffectivePeriod
+}
+
+// TaxabilityMatrix answers which rule, if any, applies to a product category
+// sold into a state
+type TaxabilityMatrix struct {
+	version    string
+	categories map[string]bool
+	rules      map[string][]*TaxabilityRule // keyed by "STATE|category", state rules before "*"
+}
+
+// NewTaxabilityMatrix builds a matrix from a parsed taxability table
+func NewTaxabilityMatrix(table *TaxabilityTable) (*TaxabilityMatrix, error) {
+	m := &TaxabilityMatrix{
+		version:    table.Version,
+		categories: map[string]bool{generalCategory: true},
+		rules:      make(map[string][]*TaxabilityRule),
+	}
+	for _, category := range table.Categories {
+		m.categories[strings.ToLower(category)] = true
+	}
+
+	ids := make(map[string][]TaxabilityRule, len(table.Rules))
+	for i := range table.Rules {
+		rule := table.Rules[i]
+		if rule.ID == "" {
+			return nil, fmt.Errorf("taxability rule %d has no id", i)
+		}
+		if err := validatePeriods(rule, ids[rule.ID]); err != nil {
+			return nil, fmt.Errorf("taxability rule %s: %w", rule.ID, err)
+		}
+		ids[rule.ID] = append(ids[rule.ID], rule)
+
+		rule.State = strings.ToUpper(strings.TrimSpace(rule.State))
+		rule.Category = strings.ToLower(rule.Category)
+		if rule.State == "" {
+			return nil, fmt.Errorf("taxability rule %s has no state", rule.ID)
+		}
+		if !m.categories[rule.Category] {
+			return nil, fmt.Errorf("taxability rule %s has unknown category %q", rule.ID, rule.Category)
+		}
+		switch rule.Treatment {
+		case TreatmentExempt, TreatmentTaxable, TreatmentFollowsGoods:
+			if rule.Rate != nil {
+				return nil, fmt.Errorf("taxability rule %s is %s and cannot set a rate", rule.ID, rule.Treatment)
+			}
+		case TreatmentReduced:
+			if rule.Rate == nil || !validRate(*rule.Rate) {
+				return nil, fmt.Errorf("taxability rule %s needs a valid reduced rate", rule.ID)
+			}
+		default:
+			return nil, fmt.Errorf("taxability rule %s has invalid treatment %q", rule.ID, rule.Treatment)
+		}
+		switch rule.LocalTreatment {
+		case "", TreatmentExempt, TreatmentTaxable:
+		default:
+			return nil, fmt.Errorf("taxability rule %s has invalid local treatment %q", rule.ID, rule.LocalTreatment)
+		}
+
+		key := rule.State + "|" + rule.Category
+		m.rules[key] = append(m.rules[key], &rule)
+	}
+	return m, nil
+}
+
+// ParseTaxabilityTable reads a JSON taxability table and builds a matrix from it
+func ParseTaxabilityTable(r io.Reader) (*TaxabilityMatrix, error) {
+	var table TaxabilityTable
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&table); err != nil {
+		return nil, fmt.Errorf("invalid taxability table: %w", err)
+	}
+	return NewTaxabilityMatrix(&table)
+}
+
+// DefaultTaxabilityMatrix returns the US taxability matrix bundled with the binary
+func DefaultTaxabilityMatrix() *TaxabilityMatrix {
+	matrix, err := ParseTaxabilityTable(bytes.NewReader(defaultTaxabilityTable))
+	if err != nil {
+		panic(fmt.Sprintf("bundled taxability table is invalid: %v", err))
+	}
+	return matrix
+}
+
+// Version returns the version of the loaded taxability table
+func (m *TaxabilityMatrix) Version() string {
+	return m.version
+}
+
+// Rule returns the rule in force on date for a category sold into a state at
+// a unit price. The state's own rules are tried before the "*" rules and the
+// first rule whose price limit the item meets wins; nil means the item is
+// fully taxable.
+func (m *TaxabilityMatrix) Rule(state, category string, price models.Money, date models.Date) (*TaxabilityRule, error) {
+	category = strings.ToLower(strings.TrimSpace(category))
+	if category == "" || category == "standard" {
+		category = generalCategory
+	}
+	if !m.categories[category] {
+		return nil, fmt.Errorf("tax_category %q is not a known US taxability category", category)
+	}
+
+	state = strings.ToUpper(strings.TrimSpace(state))
+	for _, key := range []string{state + "|" + category, allStates + "|" + category} {
+		for _, rule := range m.rules[key] {
+			if !rule.Covers(date) {
+				continue
+			}
+			if rule.PriceBelow == nil || price.Units < rule.PriceBelow.Units {
+				return rule, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// layerRate returns the rate a jurisdiction layer charges under the rule and
+// whether the layer taxes the item at all
+func (r *TaxabilityRule) layerRate(layer JurisdictionRate) (models.Rate, bool) {
+	treatment := r.Treatment
+	if layer.Jurisdiction.Type != JurisdictionState {
+		treatment = r.LocalTreatment
+		if treatment == "" && r.Treatment != TreatmentReduced {
+			treatment = r.Treatment
+		}
+	}
+
+	switch treatment {
+	case TreatmentExempt:
+		return 0, false
+	case TreatmentReduced:
+		return *r.Rate, true
+	default:
+		return layer.Rate, true
+	}
+}
+
+// applied describes the rule for an item's tax detail
+func (r *TaxabilityRule) applied() *models.ItemTaxability {
+	return &models.ItemTaxability{Rule: r.ID, Treatment: r.Treatment, Description: r.Description}
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+func TestTaxabilityMatrix_Rule(t *testing.T) {
+	matrix := DefaultTaxabilityMatrix()
+
+	tests := []struct {
+		state    string
+		category string
+		price    string
+		rule     string
+	}{
+		{"NY", "clothing", "109.99", "NY-clothing-under-110"},
+		{"NY", "clothing", "110.00", ""},
+		{"CA", "clothing", "20.00", ""},
+		{"CA", "groceries", "20.00", "US-groceries"},
+		{"IL", "groceries", "20.00", "IL-groceries"},
+		{"NY", "saas", "20.00", "NY-saas"},
+		{"CA", "saas", "20.00", "US-saas"},
+		{"CA", "", "20.00", ""},
+		{"CA", "General", "20.00", ""},
+	}
+
+	for _, tt := range tests {
+		rule, err := matrix.Rule(tt.state, tt.category, models.MustParseMoney(tt.price), testDate)
+		if err != nil {
+			t.Fatalf("%s %s: unexpected error %v", tt.state, tt.category, err)
+		}
+		var id string
+		if rule != nil {
+			id = rule.ID
+		}
+		if id != tt.rule {
+			t.Errorf("%s %s at %s: expected rule %q, got %q", tt.state, tt.category, tt.price, tt.rule, id)
+		}
+	}
+
+	if _, err := matrix.Rule("NY", "firearms", models.MustParseMoney("1.00"), testDate); err == nil {
+		t.Error("Expected error for unknown category")
+	}
+}
+
+func TestParseTaxabilityTable_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		table string
+	}{
+		{"unknown category", `{"categories": ["food"], "rules": [{"id": "x", "state": "NY", "category": "toys", "treatment": "exempt"}]}`},
+		{"reduced without rate", `{"categories": ["food"], "rules": [{"id": "x", "state": "NY", "category": "food", "treatment": "reduced"}]}`},
+		{"bad treatment", `{"categories": ["food"], "rules": [{"id": "x", "state": "NY", "category": "food", "treatment": "free"}]}`},
+		{"duplicate id", `{"categories": ["food"], "rules": [
+			{"id": "x", "state": "NY", "category": "food", "treatment": "exempt"},
+			{"id": "x", "state": "NJ", "category": "food", "treatment": "exempt"}]}`},
+	}
+
+	for _, tt := range tests {
+		if _, err := ParseTaxabilityTable(strings.NewReader(tt.table)); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func TestCalculateTax_Taxability(t *testing.T) {
+	service := NewTaxService(DefaultRateProvider())
+
+	tests := []struct {
+		name      string
+		address   models.Address
+		category  string
+		price     string
+		tax       string
+		rule      string
+		treatment string
+	}{
+		{"NY clothing under $110", models.Address{State: "NY", ZipCode: "10001"}, "clothing", "50.00", "0.00", "NY-clothing-under-110", TreatmentExempt},
+		{"NY clothing at $110", models.Address{State: "NY", ZipCode: "10001"}, "clothing", "110.00", "9.76", "", ""},
+		{"CA groceries", models.Address{State: "CA", ZipCode: "90001"}, "groceries", "100.00", "0.00", "US-groceries", TreatmentExempt},
+		{"Chicago groceries", models.Address{State: "IL", ZipCode: "60601"}, "groceries", "100.00", "1.00", "IL-groceries", TreatmentReduced},
+		{"Virginia groceries", models.Address{State: "VA", ZipCode: "23219"}, "groceries", "100.00", "0.45", "VA-groceries", TreatmentExempt},
+		{"NY SaaS", models.Address{State: "NY", ZipCode: "10001"}, "saas", "100.00", "8.88", "NY-saas", TreatmentTaxable},
+	}
+
+	for _, tt := range tests {
+		tt.address.Country = "US"
+		req := &models.TaxRequest{
+			Address:         tt.address,
+			Items:           []models.Item{{ID: "item1", Price: models.MustParseMoney(tt.price), Quantity: 1, TaxCategory: tt.category}},
+			TransactionDate: &testDate,
+		}
+		resp, err := service.CalculateTax(req)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", tt.name, err)
+		}
+		item := resp.Items[0]
+		if item.TaxAmount.String() != tt.tax {
+			t.Errorf("%s: expected tax %s, got %s", tt.name, tt.tax, item.TaxAmount)
+		}
+		var rule, treatment string
+		if item.Taxability != nil {
+			rule, treatment = item.Taxability.Rule, item.Taxability.Treatment
+		}
+		if rule != tt.rule || treatment != tt.treatment {
+			t.Errorf("%s: expected rule %q (%s), got %q (%s)", tt.name, tt.rule, tt.treatment, rule, treatment)
+		}
+	}
+}
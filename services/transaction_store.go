@@ -0,0 +1,302 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+// ErrTransactionNotFound is returned for a document code that is not on file
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+// ErrTransactionExists is returned when creating a transaction whose document
+// code is already on file
+var ErrTransactionExists = errors.New("transaction already exists")
+
+// TransactionRepository stores transactions by document code.
+// Implementations must be safe for concurrent use.
+type TransactionRepository interface {
+	// Create adds a new transaction, returning ErrTransactionExists if its
+	// code is taken
+	Create(txn *models.Transaction) error
+	// Update replaces a transaction, returning ErrTransactionNotFound if its
+	// code is not on file
+	Update(txn *models.Transaction) error
+	// Get returns one transaction or ErrTransactionNotFound
+	Get(code string) (*models.Transaction, error)
+	// List returns every transaction ordered by code
+	List() ([]models.Transaction, error)
+}
+
+// MemoryTransactionRepository keeps transactions in memory
+type MemoryTransactionRepository struct {
+	mu   sync.RWMutex
+	txns map[string]models.Transaction
+}
+
+// NewMemoryTransactionRepository creates an empty in-memory repository
+func NewMemoryTransactionRepository() *MemoryTransactionRepository {
+	return &MemoryTransactionRepository{txns: make(map[string]models.Transaction)}
+}
+
+// Create adds a new transaction
+func (r *MemoryTransactionRepository) Create(txn *models.Transaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.txns[txn.Code]; ok {
+		return fmt.Errorf("transaction %s: %w", txn.Code, ErrTransactionExists)
+	}
+	r.txns[txn.Code] = *txn
+	return nil
+}
+
+// Update replaces a transaction
+func (r *MemoryTransactionRepository) Update(txn *models.Transaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.txns[txn.Code]; !ok {
+		return fmt.Errorf("transaction %s: %w", txn.Code, ErrTransactionNotFound)
+	}
+	r.txns[txn.Code] = *txn
+	return nil
+}
+
+// Get returns one transaction
+func (r *MemoryTransactionRepository) Get(code string) (*models.Transaction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	txn, ok := r.txns[code]
+	if !ok {
+		return nil, fmt.Errorf("transaction %s: %w", code, ErrTransactionNotFound)
+	}
+	return &txn, nil
+}
+
+// List returns every transaction ordered by code
+func (r *MemoryTransactionRepository) List() ([]models.Transaction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	txns := make([]models.Transaction, 0, len(r.txns))
+	for _, txn := range r.txns {
+		txns = append(txns, txn)
+	}
+	sort.Slice(txns, func(i, j int) bool { return txns[i].Code < txns[j].Code })
+	return txns, nil
+}
+
+// compactMinSize is the smallest log, in bytes, worth compacting while the
+// repository is open
+var compactMinSize int64 = 1 << 20
+
+// syncCompacted flushes a compacted log to disk before it replaces the old
+// one
+var syncCompacted = (*os.File).Sync
+
+// FileTransactionRepository keeps transactions in memory backed by an
+// append-only log file. Every create and update appends the whole transaction
+// as one JSON line and syncs the file; opening the file replays the log, the
+// last line for a code winning. A partly written last line, left by a crash
+// mid-write, is discarded.
+//
+// Superseded lines are dropped by rewriting the log with one line per
+// transaction, to a temporary file renamed over the log: when it is opened
+// with any, and while open once they take up more than half of a log of at
+// least compactMinSize. The log's size therefore stays within twice that of
+// the current transactions, however often they are updated.
+type FileTransactionRepository struct {
+	memory *MemoryTransactionRepository
+
+	mu       sync.Mutex // serializes writes so the log matches memory
+	path     string
+	file     *os.File
+	size     int64            // bytes in the log
+	live     map[string]int64 // bytes of each transaction's last line
+	liveSize int64            // sum of live
+}
+
+// OpenTransactionFile opens or creates the transaction log at path
+func OpenTransactionFile(path string) (*FileTransactionRepository, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	r := &FileTransactionRepository{
+		memory: NewMemoryTransactionRepository(),
+		path:   path,
+		live:   make(map[string]int64),
+	}
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines {
+		last := i == len(lines)-1
+		if last && len(line) == 0 {
+			break
+		}
+		var txn models.Transaction
+		if err := json.Unmarshal(line, &txn); err != nil || txn.Code == "" {
+			if last {
+				break // torn final write
+			}
+			return nil, fmt.Errorf("invalid transaction log %s line %d", path, i+1)
+		}
+		r.memory.txns[txn.Code] = txn
+		r.track(txn.Code, int64(len(line))+1)
+	}
+
+	// A log with superseded lines or a torn final record is rewritten;
+	// otherwise appends continue where it ends
+	if r.size != int64(len(data)) || r.size != r.liveSize {
+		if err := r.compact(); err != nil {
+			return nil, err
+		}
+		return r, nil
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	r.file = file
+	return r, nil
+}
+
+// Close closes the log file
+func (r *FileTransactionRepository) Close() error {
+	return r.file.Close()
+}
+
+// Create adds a new transaction
+func (r *FileTransactionRepository) Create(txn *models.Transaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.memory.Get(txn.Code); err == nil {
+		return fmt.Errorf("transaction %s: %w", txn.Code, ErrTransactionExists)
+	}
+	if err := r.append(txn); err != nil {
+		return err
+	}
+	return r.memory.Create(txn)
+}
+
+// Update replaces a transaction
+func (r *FileTransactionRepository) Update(txn *models.Transaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.memory.Get(txn.Code); err != nil {
+		return err
+	}
+	if err := r.append(txn); err != nil {
+		return err
+	}
+	if err := r.memory.Update(txn); err != nil {
+		return err
+	}
+	if r.size >= compactMinSize && r.size > 2*r.liveSize {
+		// The update is already saved; a failed compaction leaves the log
+		// as it was and is retried on the next update
+		r.compact()
+	}
+	return nil
+}
+
+// Get returns one transaction
+func (r *FileTransactionRepository) Get(code string) (*models.Transaction, error) {
+	return r.memory.Get(code)
+}
+
+// List returns every transaction ordered by code
+func (r *FileTransactionRepository) List() ([]models.Transaction, error) {
+	return r.memory.List()
+}
+
+// append writes one transaction to the end of the log; the caller holds the
+// write lock
+func (r *FileTransactionRepository) append(txn *models.Transaction) error {
+	data, err := json.Marshal(txn)
+	if err != nil {
+		return err
+	}
+	if _, err := r.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("writing transaction log: %w", err)
+	}
+	if err := r.file.Sync(); err != nil {
+		return fmt.Errorf("writing transaction log: %w", err)
+	}
+	r.track(txn.Code, int64(len(data))+1)
+	return nil
+}
+
+// track counts a line of n bytes appended for the transaction with code,
+// superseding its previous line
+func (r *FileTransactionRepository) track(code string, n int64) {
+	r.size += n
+	r.liveSize += n - r.live[code]
+	r.live[code] = n
+}
+
+// compact rewrites the log with the current version of each transaction and
+// reopens it for appending; the caller holds the write lock
+func (r *FileTransactionRepository) compact() error {
+	txns, err := r.memory.List()
+	if err != nil {
+		return err
+	}
+	tmp := r.path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(file)
+	live := make(map[string]int64, len(txns))
+	var size int64
+	for i := range txns {
+		data, err := json.Marshal(&txns[i])
+		if err == nil {
+			_, err = writer.Write(append(data, '\n'))
+		}
+		if err != nil {
+			file.Close()
+			os.Remove(tmp)
+			return fmt.Errorf("compacting transaction log: %w", err)
+		}
+		live[txns[i].Code] = int64(len(data)) + 1
+		size += int64(len(data)) + 1
+	}
+	// The old log is only replaced once the new one is fully on disk
+	err = writer.Flush()
+	if err == nil {
+		err = syncCompacted(file)
+	}
+	if err == nil {
+		err = os.Rename(tmp, r.path)
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("compacting transaction log: %w", err)
+	}
+	syncDir(filepath.Dir(r.path))
+
+	if r.file != nil {
+		r.file.Close()
+	}
+	r.file, r.size, r.live, r.liveSize = file, size, live, size
+	return nil
+}
+
+// syncDir flushes a directory entry change such as a rename to disk, where
+// the platform supports it
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vijayraghavareddy/tax-calculation/models"
+)
+
+func TestFileTransactionRepository(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transactions.log")
+
+	repo, err := OpenTransactionFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	created := time.Date(2025, time.June, 1, 9, 0, 0, 0, time.UTC)
+	txn := &models.Transaction{Code: "INV-1", Status: TransactionQuoted, Version: 1, CreatedAt: created, UpdatedAt: created}
+	if err := repo.Create(txn); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := repo.Create(txn); !errors.Is(err, ErrTransactionExists) {
+		t.Errorf("expected transaction exists, got %v", err)
+	}
+	txn.Status = TransactionCommitted
+	if err := repo.Update(txn); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := repo.Update(&models.Transaction{Code: "INV-9"}); !errors.Is(err, ErrTransactionNotFound) {
+		t.Errorf("expected transaction not found, got %v", err)
+	}
+	if err := repo.Create(&models.Transaction{Code: "INV-0", Status: TransactionQuoted}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	repo.Close()
+
+	// Simulate a crash part way through appending a record
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"code": "INV-2", "sta`)
+	f.Close()
+
+	reopened, err := OpenTransactionFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := reopened.Get("INV-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Status != TransactionCommitted || !got.CreatedAt.Equal(created) {
+		t.Errorf("expected the latest version to be replayed, got %+v", got)
+	}
+	if _, err := reopened.Get("INV-2"); !errors.Is(err, ErrTransactionNotFound) {
+		t.Errorf("expected the torn record to be dropped, got %v", err)
+	}
+	if txns, _ := reopened.List(); len(txns) != 2 || txns[0].Code != "INV-0" {
+		t.Errorf("expected transactions ordered by code, got %+v", txns)
+	}
+
+	// Appends after recovery start on a fresh line
+	if err := reopened.Create(&models.Transaction{Code: "INV-3", Status: TransactionQuoted}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reopened.Close()
+	if again, err := OpenTransactionFile(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else {
+		defer again.Close()
+		if _, err := again.Get("INV-3"); err != nil {
+			t.Errorf("expected the record written after recovery, got %v", err)
+		}
+	}
+}
+
+func TestOpenTransactionFile_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transactions.log")
+	log := strings.Join([]string{`{"code": "INV-1"}`, `not json`, `{"code": "INV-2"}`, ``}, "\n")
+	if err := os.WriteFile(path, []byte(log), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenTransactionFile(path); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected an error for a corrupt record, got %v", err)
+	}
+}
+
+// countLines returns the number of records in the log at path
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Count(string(data), "\n")
+}
+
+func TestFileTransactionRepository_Compacts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transactions.log")
+	repo, err := OpenTransactionFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	txn := &models.Transaction{Code: "INV-1", Status: TransactionQuoted, Version: 1}
+	if err := repo.Create(txn); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := repo.Create(&models.Transaction{Code: "INV-2", Status: TransactionQuoted, Version: 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for txn.Version < 5 {
+		txn.Version++
+		txn.Adjustments = append(txn.Adjustments, models.TransactionAdjustment{Version: txn.Version - 1, Reason: "fix"})
+		if err := repo.Update(txn); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	repo.Close()
+	if n := countLines(t, path); n != 6 {
+		t.Fatalf("expected 6 records before reopening, got %d", n)
+	}
+
+	// Reopening keeps only the latest version of each transaction
+	repo, err = OpenTransactionFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := countLines(t, path); n != 2 {
+		t.Errorf("expected the log compacted to 2 records, got %d", n)
+	}
+	if got, _ := repo.Get("INV-1"); got.Version != 5 || len(got.Adjustments) != 4 {
+		t.Errorf("expected version 5 with its history, got %+v", got)
+	}
+
+	// While open, the log is compacted once superseded lines outweigh the
+	// current ones
+	defer func(size int64) { compactMinSize = size }(compactMinSize)
+	compactMinSize = 1
+	for i := 0; i < 2; i++ {
+		txn.Version++
+		if err := repo.Update(txn); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := repo.Create(&models.Transaction{Code: "INV-3", Status: TransactionQuoted, Version: 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	repo.Close()
+	if n := countLines(t, path); n != 3 {
+		t.Errorf("expected 3 records after compacting while open, got %d", n)
+	}
+	reopened, err := OpenTransactionFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer reopened.Close()
+	if got, _ := reopened.Get("INV-1"); got.Version != 7 {
+		t.Errorf("expected version 7 after compaction, got %+v", got)
+	}
+}
+
+func TestFileTransactionRepository_CompactionFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transactions.log")
+	repo, err := OpenTransactionFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	txn := &models.Transaction{Code: "INV-1", Status: TransactionQuoted, Version: 1}
+	if err := repo.Create(txn); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	txn.Version++
+	if err := repo.Update(txn); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	repo.Close()
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A compacted log that cannot be synced must not replace the old one
+	defer func(sync func(*os.File) error) { syncCompacted = sync }(syncCompacted)
+	syncCompacted = func(*os.File) error { return errors.New("disk full") }
+	if _, err := OpenTransactionFile(path); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Fatalf("expected the sync failure, got %v", err)
+	}
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Errorf("expected the log untouched, got %q", after)
+	}
+	if _, err := os.Stat(path + ".tmp"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected the temporary file removed, got %v", err)
+	}
+
+	// Once the disk recovers the log is compacted and nothing is lost
+	syncCompacted = (*os.File).Sync
+	repo, err = OpenTransactionFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer repo.Close()
+	if got, _ := repo.Get("INV-1"); got == nil || got.Version != 2 {
+		t.Errorf("expected version 2, got %+v", got)
+	}
+	if n := countLines(t, path); n != 1 {
+		t.Errorf("expected the log compacted to 1 record, got %d", n)
+	}
+}
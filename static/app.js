@@ -191,10 +191,10 @@ async function calculateTax() {
 // Display calculation results
 function displayResults(data) {
     // Update summary
-    document.getElementById('subtotal').textContent = `$${data.subtotal.toFixed(2)}`;
-    document.getElementById('totalTax').textContent = `$${data.total_tax.toFixed(2)}`;
-    document.getElementById('grandTotal').textContent = `$${data.grand_total.toFixed(2)}`;
-    document.getElementById('jurisdiction').textContent = data.tax_jurisdiction;
+    document.getElementById('subtotal').textContent = `$${data.subtotal}`;
+    document.getElementById('totalTax').textContent = `$${data.total_tax}`;
+    document.getElementById('grandTotal').textContent = `$${data.grand_total}`;
+    document.getElementById('jurisdiction').textContent = formatJurisdictions(data.jurisdictions);
     
     // Display items breakdown
     const breakdownContainer = document.getElementById('itemsBreakdown');
@@ -206,12 +206,12 @@ function displayResults(data) {
         breakdownItem.innerHTML = `
             <div class="breakdown-item-header">
                 <h4>${item.item_name}</h4>
-                <strong>$${item.total_amount.toFixed(2)}</strong>
+                <strong>$${item.total_amount}</strong>
             </div>
             <div class="breakdown-details">
                 <div>
                     <span class="label">Price:</span>
-                    <span class="value">$${item.price.toFixed(2)}</span>
+                    <span class="value">$${item.price}</span>
                 </div>
                 <div>
                     <span class="label">Quantity:</span>
@@ -219,19 +219,19 @@ function displayResults(data) {
                 </div>
                 <div>
                     <span class="label">Subtotal:</span>
-                    <span class="value">$${item.subtotal.toFixed(2)}</span>
+                    <span class="value">$${item.subtotal}</span>
                 </div>
                 <div>
-                    <span class="label">Tax Rate:</span>
-                    <span class="value">${item.tax_rate.toFixed(2)}%</span>
+                    <span class="label">Taxes:</span>
+                    <span class="value">${formatTaxes(item.taxes)}</span>
                 </div>
                 <div>
                     <span class="label">Tax Amount:</span>
-                    <span class="value">$${item.tax_amount.toFixed(2)}</span>
+                    <span class="value">$${item.tax_amount}</span>
                 </div>
                 <div>
                     <span class="label">Total:</span>
-                    <span class="value">$${item.total_amount.toFixed(2)}</span>
+                    <span class="value">$${item.total_amount}</span>
                 </div>
             </div>
         `;
@@ -248,6 +248,20 @@ function displayResults(data) {
     });
 }
 
+// Format the taxing authorities of an order, each named once
+function formatJurisdictions(jurisdictions) {
+    const names = [...new Set((jurisdictions || []).map(j => j.name || j.code))];
+    return names.length > 0 ? names.join(', ') : 'None';
+}
+
+// Format a line's component taxes, e.g. "New York sales tax 4.00%"
+function formatTaxes(taxes) {
+    if (!taxes || taxes.length === 0) {
+        return 'None';
+    }
+    return taxes.map(tax => `${tax.name} ${tax.rate}%`).join(', ');
+}
+
 // Show error message
 function showError(message) {
     const errorSection = document.getElementById('errorSection');